@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Schedule time zones resolve without zoneinfo in the image
 
 	"github.com/cashbacktv/backend/internal/application"
 	"github.com/cashbacktv/backend/internal/domain"
@@ -34,6 +35,12 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load configuration")
 	}
+	if cfg.Server.ProxyHeader != "" && len(cfg.Server.TrustedProxies) == 0 {
+		log.Warn().Str("proxy_header", cfg.Server.ProxyHeader).Msg("server.proxy_header is ignored without server.trusted_proxies")
+	}
+	if cfg.Storage.DVRMaxDiskMB <= 0 {
+		log.Warn().Msg("storage.dvr_max_disk_mb is 0: DVR windows can fill the streams tmpfs")
+	}
 
 	// Connect to PostgreSQL
 	dbPool, err := connectDB(cfg.Database)
@@ -51,6 +58,11 @@ func main() {
 	channelRepo := postgres.NewChannelRepository(dbPool)
 	userRepo := postgres.NewUserRepository(dbPool)
 	settingsRepo := postgres.NewSettingsRepository(dbPool)
+	channelLogRepo := postgres.NewChannelLogRepository(dbPool)
+	channelKeyRepo := postgres.NewChannelKeyRepository(dbPool)
+	viewerHistoryRepo := postgres.NewViewerHistoryRepository(dbPool)
+	recordingRepo := postgres.NewRecordingRepository(dbPool)
+	scheduleRepo := postgres.NewScheduleRepository(dbPool)
 
 	// Initialize FFmpeg process manager
 	ffmpegConfig := &ffmpeg.Config{
@@ -59,8 +71,17 @@ func main() {
 		PlaylistSize:  cfg.FFmpeg.PlaylistSize,
 		DefaultPreset: cfg.FFmpeg.DefaultPreset,
 		DefaultBitrate: cfg.FFmpeg.DefaultBitrate,
+		EncoderBackend: cfg.FFmpeg.EncoderBackend,
+		StallTimeout:   cfg.FFmpeg.StallTimeout,
+		RunPath:        cfg.Storage.RunPath,
+		MaxSessionsPerGPU: cfg.FFmpeg.MaxSessionsPerGPU,
+		MinFreeMemoryMB:   cfg.FFmpeg.MinFreeMemoryMB,
+		KeyPath:           cfg.Storage.KeyPath,
+		DVRMaxDiskMB:      cfg.Storage.DVRMaxDiskMB,
 	}
 	processManager := ffmpeg.NewProcessManager(ffmpegConfig, cfg.Storage.HLSPath, cfg.Storage.LogoPath, settingsRepo)
+	processManager.SetLogRepository(channelLogRepo)
+	processManager.SetKeyRepository(channelKeyRepo)
 
 	// Initialize services
 	channelService := application.NewChannelService(channelRepo, processManager)
@@ -76,31 +97,79 @@ func main() {
 		cfg.JWT.RefreshHours,
 	)
 	settingsService := application.NewSettingsService(channelService, settingsRepo)
+	logService := application.NewLogService(channelRepo, channelLogRepo, settingsRepo)
+	keyService := application.NewKeyService(channelKeyRepo, channelRepo, settingsRepo, cfg.FFmpeg.SegmentTime, cfg.FFmpeg.PlaylistSize)
+	playbackService := application.NewPlaybackService(channelRepo, cfg.Playback.TokenSecret, cfg.Playback.RequireToken, cfg.Playback.TokenTTL, cfg.Playback.MaxTokenTTL)
+	if cfg.Playback.RequireToken && !playbackService.Enabled() {
+		log.Fatal().Msg("playback.require_token needs playback.token_secret")
+	}
+	viewerService := application.NewViewerService(channelRepo, viewerHistoryRepo, cfg.Viewers.IdleTimeout, cfg.Viewers.SampleInterval, cfg.Viewers.RetentionDays)
+	recordingService := application.NewRecordingService(recordingRepo, channelRepo, processManager, cfg.Storage.ArchivePath, cfg.Recordings.RetentionDays)
+	scheduleService := application.NewScheduleService(scheduleRepo, channelRepo, channelService, channelLogRepo)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	channelHandler := handlers.NewChannelHandlerWithFFmpeg(channelService, cfg.Storage.HLSPath, cfg.Storage.LogoPath, cfg.FFmpeg.BinaryPath)
 	uploadHandler := handlers.NewUploadHandler(cfg.Storage.LogoPath, cfg.Storage.UploadPath)
 	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	logHandler := handlers.NewLogHandler(logService)
+	keyHandler := handlers.NewKeyHandler(keyService)
+	playbackHandler := handlers.NewPlaybackHandler(playbackService)
+	viewerHandler := handlers.NewViewerHandler(viewerService, cfg.Storage.HLSPath)
+	recordingHandler := handlers.NewRecordingHandler(recordingService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	playbackMiddleware := middleware.NewPlaybackMiddleware(playbackService)
+	viewerMiddleware := middleware.NewViewerMiddleware(viewerService)
 
 	// Setup router with server config for performance optimizations
-	router := http.NewRouter(authHandler, channelHandler, uploadHandler, settingsHandler, authMiddleware, cfg.Storage.LogoPath, cfg.Storage.HLSPath, &cfg.Server)
+	router := http.NewRouter(authHandler, channelHandler, uploadHandler, settingsHandler, logHandler, keyHandler, playbackHandler, viewerHandler, recordingHandler, scheduleHandler, authMiddleware, playbackMiddleware, viewerMiddleware, cfg.Storage.LogoPath, cfg.Storage.HLSPath, &cfg.Server)
 	router.SetupRoutes()
 
 	// Initialize startup tasks
 	log.Info().Msg("Running startup initialization tasks...")
+	resumeChannels := cfg.Startup.Mode == "resume"
+	
+	// Adopt or kill FFmpeg processes left by the previous instance
+	adopted := recoverProcesses(channelRepo, processManager, resumeChannels && cfg.Startup.AdoptProcesses, log)
 	
-	// Clean HLS history (remove old segments)
-	cleanHLSHistory(cfg.Storage.HLSPath, log)
+	// Clean HLS history (remove old segments, adopted channels keep theirs)
+	cleanHLSHistory(cfg.Storage.HLSPath, adopted, log)
 	
 	// Create default admin user if not exists
 	createDefaultAdmin(authService)
 
-	// Stop all running channels on startup (prevent auto-start)
-	stopAllRunningChannels(channelRepo, log)
+	// Stop all running channels on startup (adopted channels keep running)
+	stopAllRunningChannels(channelRepo, adopted, log)
+
+	// Background jobs (stopped on shutdown)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Restart channels that were on air before the restart, staggered to avoid a start storm
+	if resumeChannels {
+		go channelService.ResumeChannels(jobsCtx, time.Duration(cfg.Startup.StaggerSeconds)*time.Second)
+	}
+
+	// Delete channel logs older than log_retention days
+	go logService.RunRetention(jobsCtx)
+
+	// Delete rotated content keys that left every playlist and DVR window
+	go keyService.RunKeyPrune(jobsCtx)
+
+	// Sample concurrent viewers into the viewer history
+	go viewerService.RunSampler(jobsCtx)
+
+	// Start scheduled recordings and delete archives older than recordings.retention_days
+	go recordingService.Run(jobsCtx)
+
+	// Start and stop channels on their scheduled windows
+	go scheduleService.Run(jobsCtx)
+
+	// Trim the oldest DVR segments beyond storage.dvr_max_disk_mb
+	go processManager.RunDVRJanitor(jobsCtx)
 
 	// Start server in goroutine
 	serverAddr := cfg.Server.Addr()
@@ -117,6 +186,7 @@ func main() {
 	<-quit
 
 	log.Info().Msg("Shutting down server...")
+	stopJobs()
 
 	if err := router.Shutdown(); err != nil {
 		log.Error().Err(err).Msg("Error during shutdown")
@@ -261,6 +331,111 @@ func runMigrations(dbPool *pgxpool.Pool, log *zerolog.Logger) {
 		log.Info().Msg("Database schema already exists")
 	}
 
+	// Schema upgrades for existing databases (idempotent, always run)
+	upgradeSQL := `
+		-- Channel log history is queried per channel, newest first
+		CREATE INDEX IF NOT EXISTS idx_channel_logs_channel_created ON channel_logs(channel_id, created_at DESC);
+
+		-- Per-channel restart policy (NULL uses the default policy)
+		ALTER TABLE channels ADD COLUMN IF NOT EXISTS restart_policy JSONB;
+
+		-- Ordered backup sources for input failover
+		ALTER TABLE channels ADD COLUMN IF NOT EXISTS backup_sources JSONB;
+
+		-- Desired running state, used to resume channels after a restart
+		-- Channels that were on air when the column is added are marked as desired running
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'channels' AND column_name = 'desired_running'
+			) THEN
+				ALTER TABLE channels ADD COLUMN desired_running BOOLEAN NOT NULL DEFAULT false;
+				UPDATE channels SET desired_running = true WHERE status IN ('running', 'starting');
+			END IF;
+		END
+		$$;
+
+		-- Content keys of encrypted channels, rotated every N segments
+		CREATE TABLE IF NOT EXISTS channel_keys (
+			id UUID PRIMARY KEY,
+			channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+			key BYTEA NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_channel_keys_channel_created ON channel_keys(channel_id, created_at DESC);
+
+		-- Concurrent viewer history
+		CREATE TABLE IF NOT EXISTS channel_viewer_history (
+			id BIGSERIAL PRIMARY KEY,
+			channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+			viewers INTEGER NOT NULL,
+			sampled_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_channel_viewer_history_channel_sampled ON channel_viewer_history(channel_id, sampled_at);
+		CREATE INDEX IF NOT EXISTS idx_channel_viewer_history_sampled ON channel_viewer_history(sampled_at);
+
+		-- Recording jobs
+		CREATE TABLE IF NOT EXISTS recordings (
+			id UUID PRIMARY KEY,
+			channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+			start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			duration INTEGER NOT NULL,
+			started_at TIMESTAMP WITH TIME ZONE,
+			ended_at TIMESTAMP WITH TIME ZONE,
+			file_path TEXT NOT NULL DEFAULT '',
+			size_bytes BIGINT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_recordings_channel_start ON recordings(channel_id, start_at DESC);
+		CREATE INDEX IF NOT EXISTS idx_recordings_status_start ON recordings(status, start_at);
+
+		-- Channel schedules
+		CREATE TABLE IF NOT EXISTS channel_schedules (
+			id UUID PRIMARY KEY,
+			channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			type VARCHAR(20) NOT NULL,
+			timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+			start_at TIMESTAMP WITH TIME ZONE,
+			end_at TIMESTAMP WITH TIME ZONE,
+			cron VARCHAR(255) NOT NULL DEFAULT '',
+			duration INTEGER NOT NULL DEFAULT 0,
+			enabled BOOLEAN NOT NULL DEFAULT true,
+			next_action VARCHAR(10) NOT NULL DEFAULT '',
+			next_run_at TIMESTAMP WITH TIME ZONE,
+			window_end TIMESTAMP WITH TIME ZONE,
+			last_action VARCHAR(10) NOT NULL DEFAULT '',
+			last_run_at TIMESTAMP WITH TIME ZONE,
+			last_error TEXT NOT NULL DEFAULT '',
+			last_error_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+		CREATE INDEX IF NOT EXISTS idx_channel_schedules_channel ON channel_schedules(channel_id);
+		CREATE INDEX IF NOT EXISTS idx_channel_schedules_next_run ON channel_schedules(next_run_at) WHERE enabled;
+
+		-- Overlay layers; the logo of existing channels becomes their first image layer
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'channels' AND column_name = 'overlays'
+			) THEN
+				ALTER TABLE channels ADD COLUMN overlays JSONB;
+				UPDATE channels SET overlays = jsonb_build_array(logo || '{"type": "image"}'::jsonb)
+				WHERE jsonb_typeof(logo) = 'object' AND COALESCE(logo->>'path', '') <> '';
+			END IF;
+		END
+		$$;
+	`
+	if _, err := dbPool.Exec(ctx, upgradeSQL); err != nil {
+		log.Fatal().Err(err).Msg("Failed to upgrade database schema")
+	}
+
 	// Always reset settings to defaults on startup (preserve other data)
 	// Settings are reset to optimized values for 70 streams on 2-node NUMA system
 	log.Info().Msg("Resetting settings to optimized default values...")
@@ -319,7 +494,25 @@ func createDefaultAdmin(authService *application.AuthService) {
 	}
 }
 
-func stopAllRunningChannels(repo *postgres.ChannelRepository, log *zerolog.Logger) {
+// recoverProcesses adopts (or kills) FFmpeg processes of the previous instance and returns the adopted channels
+func recoverProcesses(repo *postgres.ChannelRepository, processManager *ffmpeg.ProcessManager, adopt bool, log *zerolog.Logger) map[uuid.UUID]bool {
+	channels, err := repo.GetAll()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get channels for process recovery")
+		return nil
+	}
+
+	adopted := make(map[uuid.UUID]bool)
+	for _, channelID := range processManager.RecoverProcesses(channels, adopt) {
+		adopted[channelID] = true
+	}
+	if len(adopted) > 0 {
+		log.Info().Int("count", len(adopted)).Msg("Adopted FFmpeg processes from previous instance")
+	}
+	return adopted
+}
+
+func stopAllRunningChannels(repo domain.ChannelRepository, adopted map[uuid.UUID]bool, log *zerolog.Logger) {
 	// Get all channels
 	channels, err := repo.GetAll()
 	if err != nil {
@@ -332,36 +525,14 @@ func stopAllRunningChannels(repo *postgres.ChannelRepository, log *zerolog.Logge
 		return
 	}
 
-	// Reset all channels' output_config to optimized default values and stop running ones
-	// Use optimized values for 70 streams on 2-node NUMA system
+	// Mark channels that were on air as stopped; their saved configuration is left untouched
 	stoppedCount := 0
-	resetCount := 0
-	defaultOutputConfig := &domain.OutputConfig{
-		Codec:      "libx264",
-		Bitrate:    "3500k",
-		Resolution: "1920x1080",
-		Preset:     "veryfast",
-		Profile:    "high",
-	}
 
 	for _, channel := range channels {
-		// Reset output_config to defaults for all channels
-		channel.OutputConfig = defaultOutputConfig
-		if err := repo.Update(channel); err != nil {
-			log.Warn().
-				Str("channel_id", channel.ID.String()).
-				Str("channel_name", channel.Name).
-				Err(err).
-				Msg("Failed to reset channel output_config on startup")
-		} else {
-			resetCount++
-			log.Debug().
-				Str("channel_id", channel.ID.String()).
-				Str("channel_name", channel.Name).
-				Msg("Reset channel output_config to defaults")
+		// Stop all running channels (adopted processes are still on air)
+		if adopted[channel.ID] {
+			continue
 		}
-
-		// Stop all running channels
 		if channel.Status == domain.ChannelStatusRunning || 
 		   channel.Status == domain.ChannelStatusStarting {
 			err := repo.UpdateStatus(channel.ID, domain.ChannelStatusStopped)
@@ -381,9 +552,6 @@ func stopAllRunningChannels(repo *postgres.ChannelRepository, log *zerolog.Logge
 		}
 	}
 
-	if resetCount > 0 {
-		log.Info().Int("count", resetCount).Msg("Reset all channels' output_config to defaults on startup")
-	}
 	if stoppedCount > 0 {
 		log.Info().Int("count", stoppedCount).Msg("Stopped all running channels on startup")
 	} else {
@@ -391,7 +559,7 @@ func stopAllRunningChannels(repo *postgres.ChannelRepository, log *zerolog.Logge
 	}
 }
 
-func cleanHLSHistory(hlsPath string, log *zerolog.Logger) {
+func cleanHLSHistory(hlsPath string, adopted map[uuid.UUID]bool, log *zerolog.Logger) {
 	log.Info().Str("hls_path", hlsPath).Msg("Cleaning HLS history...")
 	
 	// Remove all HLS segment directories (they will be recreated when channels start)
@@ -405,6 +573,13 @@ func cleanHLSHistory(hlsPath string, log *zerolog.Logger) {
 			return nil
 		}
 		
+		// Keep the output of adopted processes
+		if info.IsDir() && filepath.Dir(path) == filepath.Clean(hlsPath) {
+			if channelID, err := uuid.Parse(info.Name()); err == nil && adopted[channelID] {
+				return filepath.SkipDir
+			}
+		}
+		
 		// Remove all files and directories in HLS path
 		if info.IsDir() {
 			// Remove directory and all its contents
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// memoryChannelRepository stores channels as JSON, like the JSONB columns of the database
+type memoryChannelRepository struct {
+	channels map[uuid.UUID][]byte
+}
+
+func newMemoryChannelRepository(channels ...*domain.Channel) *memoryChannelRepository {
+	repo := &memoryChannelRepository{channels: make(map[uuid.UUID][]byte)}
+	for _, channel := range channels {
+		repo.Create(channel)
+	}
+	return repo
+}
+
+func (r *memoryChannelRepository) Create(channel *domain.Channel) error {
+	data, err := json.Marshal(channel)
+	if err != nil {
+		return err
+	}
+	r.channels[channel.ID] = data
+	return nil
+}
+
+func (r *memoryChannelRepository) GetByID(id uuid.UUID) (*domain.Channel, error) {
+	data, ok := r.channels[id]
+	if !ok {
+		return nil, fmt.Errorf("channel not found")
+	}
+	var channel domain.Channel
+	if err := json.Unmarshal(data, &channel); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *memoryChannelRepository) GetAll() ([]*domain.Channel, error) {
+	channels := make([]*domain.Channel, 0, len(r.channels))
+	for id := range r.channels {
+		channel, err := r.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+func (r *memoryChannelRepository) Update(channel *domain.Channel) error {
+	return r.Create(channel)
+}
+
+func (r *memoryChannelRepository) Delete(id uuid.UUID) error {
+	delete(r.channels, id)
+	return nil
+}
+
+func (r *memoryChannelRepository) UpdateStatus(id uuid.UUID, status domain.ChannelStatus) error {
+	channel, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	channel.Status = status
+	return r.Create(channel)
+}
+
+func (r *memoryChannelRepository) SetDesiredRunning(id uuid.UUID, running bool) error {
+	channel, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	channel.DesiredRunning = running
+	return r.Create(channel)
+}
+
+func TestStopAllRunningChannelsKeepsOutputConfig(t *testing.T) {
+	encrypted := domain.NewChannel("encrypted", "srt://source")
+	encrypted.Status = domain.ChannelStatusRunning
+	encrypted.OutputConfig = &domain.OutputConfig{
+		Codec:      "hevc",
+		Bitrate:    "6000k",
+		Resolution: "1280x720",
+		Preset:     "fast",
+		Profile:    "main",
+		Level:      "4.1",
+		Container:  "fmp4",
+		Audio:      &domain.AudioConfig{Bitrate: "192k"},
+		Encryption: &domain.EncryptionConfig{Enabled: true, RotateSegments: 5},
+		DVR:        &domain.DVRConfig{Enabled: true, WindowMinutes: 30},
+	}
+	adopted := domain.NewChannel("adopted", "srt://adopted")
+	adopted.Status = domain.ChannelStatusRunning
+
+	repo := newMemoryChannelRepository(encrypted, adopted)
+	want, _ := json.Marshal(encrypted.OutputConfig)
+	log := zerolog.Nop()
+
+	stopAllRunningChannels(repo, map[uuid.UUID]bool{adopted.ID: true}, &log)
+
+	got, err := repo.GetByID(encrypted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != domain.ChannelStatusStopped {
+		t.Errorf("status = %s, want %s", got.Status, domain.ChannelStatusStopped)
+	}
+	if got.OutputConfig == nil || got.OutputConfig.Encryption == nil || !got.OutputConfig.Encryption.Enabled {
+		t.Fatalf("encryption lost on boot: %+v", got.OutputConfig)
+	}
+	if data, _ := json.Marshal(got.OutputConfig); string(data) != string(want) {
+		t.Errorf("output_config changed on boot:\n got %s\nwant %s", data, want)
+	}
+
+	if got, _ := repo.GetByID(adopted.ID); got.Status != domain.ChannelStatusRunning {
+		t.Errorf("adopted channel status = %s, want %s", got.Status, domain.ChannelStatusRunning)
+	}
+}
@@ -1,11 +1,14 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
 	"github.com/google/uuid"
 )
 
@@ -29,19 +32,129 @@ func NewChannelService(repo domain.ChannelRepository, transcoder domain.Transcod
 	}
 }
 
+// validateOutputConfig checks the output configuration before it is stored
+func validateOutputConfig(output *domain.OutputConfig) error {
+	if output == nil {
+		return nil
+	}
+
+	codec, err := domain.ParseVideoCodec(output.Codec)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := codec.ValidateProfile(output.Profile); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := codec.ValidateLevel(output.Level); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := codec.ValidatePreset(output.Preset); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if _, err := domain.ParseEncoderBackend(output.EncoderBackend); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.Audio.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.Captions.Validate(codec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.LowLatency.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	container, err := domain.ParseContainer(output.Container)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := domain.ValidateContainer(container, codec, output.LowLatency); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := domain.ValidatePushDestinations(output.Push); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.Encryption.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := domain.ValidateEncryption(output.Encryption, container, output.LowLatency, output.Push); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.DVR.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := domain.ValidateDVR(output.DVR, output.LowLatency); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := output.Slate.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+
+	names := make(map[string]bool, len(output.Ladder))
+	for i, rendition := range output.Ladder {
+		if err := rendition.Validate(); err != nil {
+			return fmt.Errorf("%w: rendition %d: %v", ErrInvalidChannel, i, err)
+		}
+		name := rendition.Name
+		if name == "" {
+			_, height, _ := domain.ParseResolution(rendition.Resolution)
+			name = fmt.Sprintf("%dp", height)
+		}
+		if strings.ContainsAny(name, "/\\ ,:") || name == "." || name == ".." {
+			return fmt.Errorf("%w: invalid rendition name %q", ErrInvalidChannel, name)
+		}
+		if names[name] {
+			return fmt.Errorf("%w: duplicate rendition name %q", ErrInvalidChannel, name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+// validateBackupSources checks the backup source list of a channel
+func validateBackupSources(sourceURL string, backupSources []string) error {
+	seen := map[string]bool{sourceURL: true}
+	for i, source := range backupSources {
+		if strings.TrimSpace(source) == "" {
+			return fmt.Errorf("%w: backup source %d is empty", ErrInvalidChannel, i)
+		}
+		if seen[source] {
+			return fmt.Errorf("%w: duplicate source %q", ErrInvalidChannel, source)
+		}
+		seen[source] = true
+	}
+	return nil
+}
+
 // CreateChannel creates a new channel
-func (s *ChannelService) CreateChannel(name, sourceURL string, logo *domain.LogoConfig, output *domain.OutputConfig) (*domain.Channel, error) {
+// backupSources, overlays, autoRestart and restartPolicy are optional; nil keeps the defaults.
+func (s *ChannelService) CreateChannel(name, sourceURL string, backupSources []string, overlays []domain.OverlayLayer, output *domain.OutputConfig, autoRestart *bool, restartPolicy *domain.RestartPolicy) (*domain.Channel, error) {
 	if name == "" || sourceURL == "" {
 		return nil, ErrInvalidChannel
 	}
+	if err := validateOutputConfig(output); err != nil {
+		return nil, err
+	}
+	if err := restartPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if err := validateBackupSources(sourceURL, backupSources); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateOverlays(overlays); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
 
 	channel := domain.NewChannel(name, sourceURL)
-	if logo != nil {
-		channel.Logo = logo
-	}
+	channel.BackupSources = backupSources
+	channel.Overlays = overlays
 	if output != nil {
 		channel.OutputConfig = output
 	}
+	if autoRestart != nil {
+		channel.AutoRestart = *autoRestart
+	}
+	channel.RestartPolicy = restartPolicy
 
 	if err := s.repo.Create(channel); err != nil {
 		return nil, err
@@ -58,6 +171,7 @@ func (s *ChannelService) GetChannel(id uuid.UUID) (*domain.Channel, error) {
 	}
 	// Set output URL dynamically with CDN
 	channel.OutputURL = fmt.Sprintf("https://cdn.cashbacktv.live/streams/%s/index.m3u8", channel.ID.String())
+	channel.ActiveSource, _ = s.transcoder.ActiveSource(channel.ID)
 	return channel, nil
 }
 
@@ -70,12 +184,15 @@ func (s *ChannelService) ListChannels() ([]*domain.Channel, error) {
 	// Set output URL dynamically for all channels with CDN
 	for _, channel := range channels {
 		channel.OutputURL = fmt.Sprintf("https://cdn.cashbacktv.live/streams/%s/index.m3u8", channel.ID.String())
+		channel.ActiveSource, _ = s.transcoder.ActiveSource(channel.ID)
 	}
 	return channels, nil
 }
 
 // UpdateChannel updates an existing channel
-func (s *ChannelService) UpdateChannel(id uuid.UUID, name, sourceURL string, logo *domain.LogoConfig, output *domain.OutputConfig) (*domain.Channel, error) {
+// backupSources, overlays, autoRestart and restartPolicy are only changed when set (an empty list removes
+// the backups or overlays).
+func (s *ChannelService) UpdateChannel(id uuid.UUID, name, sourceURL string, backupSources []string, overlays []domain.OverlayLayer, output *domain.OutputConfig, autoRestart *bool, restartPolicy *domain.RestartPolicy) (*domain.Channel, error) {
 	channel, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, ErrChannelNotFound
@@ -85,18 +202,40 @@ func (s *ChannelService) UpdateChannel(id uuid.UUID, name, sourceURL string, log
 		return nil, ErrChannelRunning
 	}
 
+	if err := validateOutputConfig(output); err != nil {
+		return nil, err
+	}
+	if err := restartPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+
 	if name != "" {
 		channel.Name = name
 	}
 	if sourceURL != "" {
 		channel.SourceURL = sourceURL
 	}
-	// Handle logo: if logo is explicitly set (including nil), update it
-	// This allows removing logo by sending null
-	channel.Logo = logo
+	if backupSources != nil {
+		channel.BackupSources = backupSources
+	}
+	if err := validateBackupSources(channel.SourceURL, channel.BackupSources); err != nil {
+		return nil, err
+	}
+	if overlays != nil {
+		if err := domain.ValidateOverlays(overlays); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+		}
+		channel.Overlays = overlays
+	}
 	if output != nil {
 		channel.OutputConfig = output
 	}
+	if autoRestart != nil {
+		channel.AutoRestart = *autoRestart
+	}
+	if restartPolicy != nil {
+		channel.RestartPolicy = restartPolicy
+	}
 	channel.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(channel); err != nil {
@@ -121,6 +260,9 @@ func (s *ChannelService) DeleteChannel(id uuid.UUID) error {
 		s.transcoder.Stop(id)
 		// Give a brief moment for cleanup
 		time.Sleep(200 * time.Millisecond)
+	} else {
+		// Cancel a pending auto-restart
+		s.transcoder.Stop(id)
 	}
 
 	return s.repo.Delete(id)
@@ -145,10 +287,21 @@ func (s *ChannelService) StartChannel(id uuid.UUID) error {
 	}
 
 	if err := s.transcoder.Start(channel); err != nil {
+		// A start refused by admission control leaves the channel stopped, nothing failed
+		var capacityErr *domain.CapacityError
+		if errors.As(err, &capacityErr) {
+			s.repo.UpdateStatus(id, domain.ChannelStatusStopped)
+			return err
+		}
 		s.repo.UpdateStatus(id, domain.ChannelStatusError)
 		return err
 	}
 
+	// Remember the channel should be on air so it is resumed after a backend restart
+	if err := s.repo.SetDesiredRunning(id, true); err != nil {
+		return err
+	}
+
 	return s.repo.UpdateStatus(id, domain.ChannelStatusRunning)
 }
 
@@ -160,9 +313,14 @@ func (s *ChannelService) StopChannel(id uuid.UUID) error {
 		return ErrChannelNotFound
 	}
 
+	if err := s.repo.SetDesiredRunning(id, false); err != nil {
+		return err
+	}
+
 	// If not running, ensure status is correct and return success
 	if !s.transcoder.IsRunning(id) {
-		// Ensure status is set to stopped (might be out of sync)
+		// Cancel a pending auto-restart and ensure status is set to stopped (might be out of sync)
+		s.transcoder.Stop(id)
 		s.repo.UpdateStatus(id, domain.ChannelStatusStopped)
 		return nil
 	}
@@ -204,6 +362,46 @@ func (s *ChannelService) RestartChannel(id uuid.UUID) error {
 	return s.StartChannel(id)
 }
 
+// ResumeChannels starts the channels whose desired state is running, one every stagger
+// Used on boot so a deploy or crash does not take channels off air; adopted channels are already running.
+func (s *ChannelService) ResumeChannels(ctx context.Context, stagger time.Duration) {
+	channels, err := s.repo.GetAll()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load channels for resume")
+		return
+	}
+
+	resumed := 0
+	for _, channel := range channels {
+		if !channel.DesiredRunning || s.transcoder.IsRunning(channel.ID) {
+			continue
+		}
+		if resumed > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(stagger):
+			}
+		}
+		resumed++
+
+		if err := s.StartChannel(channel.ID); err != nil {
+			logger.Error().
+				Err(err).
+				Str("channel_id", channel.ID.String()).
+				Str("channel_name", channel.Name).
+				Msg("Failed to resume channel")
+			continue
+		}
+		logger.Info().
+			Str("channel_id", channel.ID.String()).
+			Str("channel_name", channel.Name).
+			Msg("Resumed channel")
+	}
+
+	logger.Info().Int("count", resumed).Msg("Channel resume completed")
+}
+
 // GetChannelMetrics retrieves transcoding metrics for a channel
 func (s *ChannelService) GetChannelMetrics(id uuid.UUID) (*domain.TranscoderProcess, error) {
 	return s.transcoder.GetProcess(id)
@@ -232,6 +430,15 @@ func (s *ChannelService) GetChannelLogs(id uuid.UUID) ([]string, error) {
 	return s.transcoder.GetLogs(id)
 }
 
+// PreviewChannelCommand returns the FFmpeg command StartChannel would execute without launching it
+func (s *ChannelService) PreviewChannelCommand(id uuid.UUID) (*domain.CommandPreview, error) {
+	channel, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrChannelNotFound
+	}
+	return s.transcoder.PreviewCommand(channel)
+}
+
 // BatchResult represents the result of a batch operation
 type BatchResult struct {
 	Success []uuid.UUID `json:"success"`
@@ -240,8 +447,9 @@ type BatchResult struct {
 
 // BatchError represents an error for a specific channel in batch operation
 type BatchError struct {
-	ChannelID uuid.UUID `json:"channel_id"`
-	Error     string    `json:"error"`
+	ChannelID uuid.UUID            `json:"channel_id"`
+	Error     string               `json:"error"`
+	Limit     domain.CapacityLimit `json:"limit,omitempty"` // Set when the start was refused by admission control
 }
 
 // BatchStartChannels starts multiple channels with rate limiting
@@ -318,10 +526,15 @@ func (s *ChannelService) batchProcess(
 	for i := 0; i < len(ids); i++ {
 		job := <-results
 		if job.err != nil {
-			result.Failed = append(result.Failed, BatchError{
+			batchErr := BatchError{
 				ChannelID: job.id,
 				Error:     job.err.Error(),
-			})
+			}
+			var capacityErr *domain.CapacityError
+			if errors.As(job.err, &capacityErr) {
+				batchErr.Limit = capacityErr.Limit
+			}
+			result.Failed = append(result.Failed, batchErr)
 		} else {
 			result.Success = append(result.Success, job.id)
 		}
@@ -0,0 +1,132 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	keyPruneInterval = 10 * time.Minute
+	// Keys outlive the playlist by this much for players behind the live edge and cached playlists
+	keyRetentionMargin = 10 * time.Minute
+)
+
+// ErrKeyNotFound is returned when a channel has no key with the requested ID
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyService serves the content keys of encrypted channels and prunes the rotated ones
+// Keys of a deleted channel go with it (channel_keys cascades on the channel).
+type KeyService struct {
+	repo         domain.ChannelKeyRepository
+	channelRepo  domain.ChannelRepository
+	settingsRepo SettingsRepository
+	segmentTime  int // ffmpeg.segment_time, overridden by the segment_time setting
+	playlistSize int // ffmpeg.playlist_size, overridden by the playlist_size setting
+}
+
+// NewKeyService creates a new key service
+func NewKeyService(repo domain.ChannelKeyRepository, channelRepo domain.ChannelRepository, settingsRepo SettingsRepository, segmentTime, playlistSize int) *KeyService {
+	return &KeyService{
+		repo:         repo,
+		channelRepo:  channelRepo,
+		settingsRepo: settingsRepo,
+		segmentTime:  segmentTime,
+		playlistSize: playlistSize,
+	}
+}
+
+// GetKey returns a content key of a channel
+func (s *KeyService) GetKey(channelID, keyID uuid.UUID) ([]byte, error) {
+	key, err := s.repo.Get(channelID, keyID)
+	if err != nil {
+		return nil, ErrKeyNotFound
+	}
+	return key.Key, nil
+}
+
+// PruneKeys deletes the keys no playlist can reference anymore
+// Encrypted channels keep the keys of their playlist or DVR window (whichever is longer),
+// channels without encryption lose all their keys.
+func (s *KeyService) PruneKeys() (int64, error) {
+	channels, err := s.channelRepo.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	window := s.playlistWindow()
+	now := time.Now()
+	var deleted int64
+	for _, channel := range channels {
+		var n int64
+		if channel.OutputConfig == nil || channel.OutputConfig.Encryption == nil || !channel.OutputConfig.Encryption.Enabled {
+			n, err = s.repo.DeleteByChannel(channel.ID)
+		} else {
+			n, err = s.repo.DeleteOlderThan(channel.ID, now.Add(-keyRetention(channel, window)))
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// RunKeyPrune prunes rotated keys immediately and then every keyPruneInterval until ctx is cancelled
+func (s *KeyService) RunKeyPrune(ctx context.Context) {
+	ticker := time.NewTicker(keyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.PruneKeys()
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to prune content keys")
+		} else if deleted > 0 {
+			logger.Info().
+				Int64("deleted", deleted).
+				Msg("Pruned rotated content keys")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// playlistWindow returns the time a live playlist spans with the current segment settings
+func (s *KeyService) playlistWindow() time.Duration {
+	segmentTime, playlistSize := s.segmentTime, s.playlistSize
+	if dbSettings, err := s.settingsRepo.GetSystemSettings(); err == nil {
+		segmentTime = intSetting(dbSettings, "segment_time", segmentTime)
+		playlistSize = intSetting(dbSettings, "playlist_size", playlistSize)
+	}
+	return time.Duration(segmentTime*playlistSize) * time.Second
+}
+
+// keyRetention returns how long the keys of an encrypted channel are kept
+func keyRetention(channel *domain.Channel, playlistWindow time.Duration) time.Duration {
+	window := playlistWindow
+	if dvr := channel.OutputConfig.DVR; dvr != nil && dvr.Enabled {
+		if dvrWindow := time.Duration(dvr.WindowMinutes) * time.Minute; dvrWindow > window {
+			window = dvrWindow
+		}
+	}
+	return window + keyRetentionMargin
+}
+
+// intSetting returns a numeric system setting (JSON numbers decode as float64)
+func intSetting(settings map[string]interface{}, key string, fallback int) int {
+	switch v := settings[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return fallback
+}
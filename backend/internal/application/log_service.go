@@ -0,0 +1,102 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultLogRetentionDays = 1
+	logRetentionInterval    = time.Hour
+	defaultLogPageSize      = 100
+	maxLogPageSize          = 1000
+)
+
+// LogService handles persisted channel log history and its retention
+type LogService struct {
+	channelRepo  domain.ChannelRepository
+	repo         domain.ChannelLogRepository
+	settingsRepo SettingsRepository
+}
+
+// NewLogService creates a new log service
+func NewLogService(channelRepo domain.ChannelRepository, repo domain.ChannelLogRepository, settingsRepo SettingsRepository) *LogService {
+	return &LogService{
+		channelRepo:  channelRepo,
+		repo:         repo,
+		settingsRepo: settingsRepo,
+	}
+}
+
+// ListChannelLogs retrieves a page of a channel's log history (newest first) and the total match count
+func (s *LogService) ListChannelLogs(id uuid.UUID, filter domain.ChannelLogFilter) ([]*domain.ChannelLog, int, error) {
+	if _, err := s.channelRepo.GetByID(id); err != nil {
+		return nil, 0, ErrChannelNotFound
+	}
+
+	filter.ChannelID = id
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLogPageSize
+	}
+	if filter.Limit > maxLogPageSize {
+		filter.Limit = maxLogPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	return s.repo.List(filter)
+}
+
+// retentionDays returns log_retention from settings (days, 0 = keep forever)
+func (s *LogService) retentionDays() int {
+	dbSettings, err := s.settingsRepo.GetSystemSettings()
+	if err != nil {
+		return defaultLogRetentionDays
+	}
+	if val, ok := dbSettings["log_retention"]; ok {
+		if v, ok := val.(float64); ok {
+			return int(v)
+		} else if v, ok := val.(int); ok {
+			return v
+		}
+	}
+	return defaultLogRetentionDays
+}
+
+// PruneLogs deletes log lines older than the configured retention
+func (s *LogService) PruneLogs() (int64, error) {
+	days := s.retentionDays()
+	if days <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteOlderThan(time.Now().AddDate(0, 0, -days))
+}
+
+// RunRetention prunes old log lines immediately and then every hour until ctx is cancelled
+func (s *LogService) RunRetention(ctx context.Context) {
+	ticker := time.NewTicker(logRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.PruneLogs()
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to prune channel logs")
+		} else if deleted > 0 {
+			logger.Info().
+				Int64("deleted", deleted).
+				Int("retention_days", s.retentionDays()).
+				Msg("Pruned old channel logs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
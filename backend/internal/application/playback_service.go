@@ -0,0 +1,140 @@
+package application
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPlaybackTokensDisabled = errors.New("playback tokens are not configured")
+	ErrPlaybackTokenRequired  = errors.New("playback token required")
+	ErrInvalidPlaybackToken   = errors.New("invalid playback token")
+	ErrPlaybackTokenExpired   = errors.New("playback token expired")
+	ErrPlaybackTokenScope     = errors.New("playback token not valid for this channel or client")
+)
+
+// PlaybackToken is the signed payload of a playback URL token
+type PlaybackToken struct {
+	ChannelID uuid.UUID `json:"c"`
+	ExpiresAt int64     `json:"e"`            // Unix seconds
+	ClientIP  string    `json:"ip,omitempty"` // Only this client may use the token ("" = any client)
+}
+
+// PlaybackService issues and validates HMAC-signed playback tokens for /streams
+type PlaybackService struct {
+	channelRepo domain.ChannelRepository
+	secret      []byte
+	required    bool
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+}
+
+// NewPlaybackService creates a new playback service (an empty secret disables tokens)
+func NewPlaybackService(channelRepo domain.ChannelRepository, secret string, required bool, defaultTTLSeconds, maxTTLSeconds int) *PlaybackService {
+	if maxTTLSeconds < defaultTTLSeconds {
+		maxTTLSeconds = defaultTTLSeconds
+	}
+	return &PlaybackService{
+		channelRepo: channelRepo,
+		secret:      []byte(secret),
+		required:    required,
+		defaultTTL:  time.Duration(defaultTTLSeconds) * time.Second,
+		maxTTL:      time.Duration(maxTTLSeconds) * time.Second,
+	}
+}
+
+// Enabled reports whether playback tokens can be issued and validated
+func (s *PlaybackService) Enabled() bool {
+	return len(s.secret) > 0
+}
+
+// Required reports whether /streams refuses requests without a token
+func (s *PlaybackService) Required() bool {
+	return s.required
+}
+
+// IssueToken signs a token for a channel (ttlSeconds 0 = default lifetime, clientIP "" = unbound)
+func (s *PlaybackService) IssueToken(channelID uuid.UUID, ttlSeconds int, clientIP string) (string, *PlaybackToken, error) {
+	if !s.Enabled() {
+		return "", nil, ErrPlaybackTokensDisabled
+	}
+	if _, err := s.channelRepo.GetByID(channelID); err != nil {
+		return "", nil, ErrChannelNotFound
+	}
+
+	ttl := s.defaultTTL
+	if ttlSeconds > 0 {
+		ttl = time.Duration(ttlSeconds) * time.Second
+	}
+	if ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	if clientIP != "" {
+		ip := net.ParseIP(clientIP)
+		if ip == nil {
+			return "", nil, errors.New("invalid client IP")
+		}
+		clientIP = ip.String()
+	}
+
+	token := &PlaybackToken{
+		ChannelID: channelID,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+		ClientIP:  clientIP,
+	}
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), token, nil
+}
+
+// ValidateToken checks the signature, expiry, channel scope and client binding of a token
+func (s *PlaybackService) ValidateToken(value string, channelID uuid.UUID, clientIP string) error {
+	if !s.Enabled() {
+		return ErrPlaybackTokensDisabled
+	}
+	encoded, signature, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return ErrInvalidPlaybackToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidPlaybackToken
+	}
+	var token PlaybackToken
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return ErrInvalidPlaybackToken
+	}
+
+	if time.Now().Unix() >= token.ExpiresAt {
+		return ErrPlaybackTokenExpired
+	}
+	if token.ChannelID != channelID {
+		return ErrPlaybackTokenScope
+	}
+	if token.ClientIP != "" {
+		ip := net.ParseIP(clientIP)
+		if ip == nil || ip.String() != token.ClientIP {
+			return ErrPlaybackTokenScope
+		}
+	}
+	return nil
+}
+
+// sign returns the base64url HMAC-SHA256 of an encoded payload
+func (s *PlaybackService) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
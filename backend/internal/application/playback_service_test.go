@@ -0,0 +1,78 @@
+package application
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testPlaybackToken signs a token with the service secret, as IssueToken does
+func testPlaybackToken(t *testing.T, s *PlaybackService, token PlaybackToken) string {
+	t.Helper()
+	payload, err := json.Marshal(token)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded)
+}
+
+func TestPlaybackServiceValidateToken(t *testing.T) {
+	s := NewPlaybackService(nil, "secret", true, 3600, 86400)
+	channelID := uuid.MustParse("6f1c2d3e-4b5a-4c6d-8e7f-901234567890")
+	otherChannelID := uuid.MustParse("0a1b2c3d-4e5f-4a6b-8c7d-8e9f01234567")
+	valid := time.Now().Add(time.Hour).Unix()
+
+	unbound := testPlaybackToken(t, s, PlaybackToken{ChannelID: channelID, ExpiresAt: valid})
+	bound := testPlaybackToken(t, s, PlaybackToken{ChannelID: channelID, ExpiresAt: valid, ClientIP: "203.0.113.7"})
+	encoded, signature, _ := strings.Cut(unbound, ".")
+	forged, _, _ := strings.Cut(testPlaybackToken(t, s, PlaybackToken{ChannelID: otherChannelID, ExpiresAt: valid}), ".")
+	tampered := "A" + signature[1:]
+	if signature[0] == 'A' {
+		tampered = "B" + signature[1:]
+	}
+	otherSecret := NewPlaybackService(nil, "other", true, 3600, 86400)
+
+	tests := []struct {
+		name      string
+		service   *PlaybackService
+		token     string
+		channelID uuid.UUID
+		clientIP  string
+		want      error
+	}{
+		{name: "valid", token: unbound, channelID: channelID, clientIP: "198.51.100.1"},
+		{name: "valid bound to the client", token: bound, channelID: channelID, clientIP: "203.0.113.7"},
+		{
+			name:      "expired",
+			token:     testPlaybackToken(t, s, PlaybackToken{ChannelID: channelID, ExpiresAt: time.Now().Add(-time.Second).Unix()}),
+			channelID: channelID,
+			want:      ErrPlaybackTokenExpired,
+		},
+		{name: "wrong channel", token: unbound, channelID: otherChannelID, want: ErrPlaybackTokenScope},
+		{name: "client IP mismatch", token: bound, channelID: channelID, clientIP: "203.0.113.8", want: ErrPlaybackTokenScope},
+		{name: "client IP missing", token: bound, channelID: channelID, want: ErrPlaybackTokenScope},
+		{name: "tampered signature", token: encoded + "." + tampered, channelID: channelID, want: ErrInvalidPlaybackToken},
+		{name: "tampered payload", token: forged + "." + signature, channelID: otherChannelID, want: ErrInvalidPlaybackToken},
+		{name: "signed with another secret", service: otherSecret, token: unbound, channelID: channelID, want: ErrInvalidPlaybackToken},
+		{name: "missing signature", token: encoded, channelID: channelID, want: ErrInvalidPlaybackToken},
+		{name: "tokens disabled", service: NewPlaybackService(nil, "", false, 3600, 86400), token: unbound, channelID: channelID, want: ErrPlaybackTokensDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := s
+			if tt.service != nil {
+				service = tt.service
+			}
+			if err := service.ValidateToken(tt.token, tt.channelID, tt.clientIP); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateToken() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	recordingCheckInterval     = 5 * time.Second
+	recordingRetentionInterval = time.Hour
+	recordingPartSuffix        = ".part" // Archives still written by FFmpeg (see ffmpeg.RecordingPartSuffix)
+)
+
+var (
+	ErrRecordingNotFound = errors.New("recording not found")
+	ErrInvalidRecording  = errors.New("invalid recording data")
+	ErrRecordingFinished = errors.New("recording already finished")
+	ErrRecordingActive   = errors.New("recording in progress")
+	ErrArchiveNotFound   = errors.New("recording has no archive")
+)
+
+// CreateRecordingInput represents input for scheduling a recording
+type CreateRecordingInput struct {
+	Name     string     `json:"name"`
+	StartAt  *time.Time `json:"start_at"` // nil or past = start now
+	Duration int        `json:"duration"` // Seconds
+}
+
+// RecordingService schedules recording jobs and manages their archives
+type RecordingService struct {
+	repo          domain.RecordingRepository
+	channelRepo   domain.ChannelRepository
+	recorder      domain.Recorder
+	archivePath   string
+	retentionDays int
+	mu            sync.Mutex
+	active        map[uuid.UUID]context.CancelFunc // Cancels the running jobs
+	wake          chan struct{}
+}
+
+// NewRecordingService creates a new recording service
+func NewRecordingService(repo domain.RecordingRepository, channelRepo domain.ChannelRepository, recorder domain.Recorder, archivePath string, retentionDays int) *RecordingService {
+	return &RecordingService{
+		repo:          repo,
+		channelRepo:   channelRepo,
+		recorder:      recorder,
+		archivePath:   archivePath,
+		retentionDays: retentionDays,
+		active:        make(map[uuid.UUID]context.CancelFunc),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Create schedules a recording of a channel
+func (s *RecordingService) Create(channelID uuid.UUID, input CreateRecordingInput) (*domain.Recording, error) {
+	channel, err := s.channelRepo.GetByID(channelID)
+	if err != nil {
+		return nil, ErrChannelNotFound
+	}
+
+	duration := time.Duration(input.Duration) * time.Second
+	if duration <= 0 || duration > domain.MaxRecordingDuration {
+		return nil, fmt.Errorf("%w: duration must be between 1 and %d seconds", ErrInvalidRecording, int(domain.MaxRecordingDuration.Seconds()))
+	}
+
+	now := time.Now()
+	startAt := now
+	if input.StartAt != nil && input.StartAt.After(now) {
+		startAt = *input.StartAt
+	}
+	name := strings.TrimSpace(input.Name)
+	if len(name) > 255 {
+		return nil, fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidRecording)
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s %s", channel.Name, startAt.Format("2006-01-02 15:04"))
+	}
+
+	id := uuid.New()
+	recording := &domain.Recording{
+		ID:        id,
+		ChannelID: channelID,
+		Name:      name,
+		Status:    domain.RecordingStatusScheduled,
+		StartAt:   startAt,
+		Duration:  input.Duration,
+		FilePath:  filepath.Join(s.archivePath, channelID.String(), id.String()+".mp4"),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(recording); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return recording, nil
+}
+
+// List returns the recordings of a channel (nil = all channels)
+func (s *RecordingService) List(channelID *uuid.UUID) ([]*domain.Recording, error) {
+	return s.repo.List(channelID)
+}
+
+// Get returns a recording by ID
+func (s *RecordingService) Get(id uuid.UUID) (*domain.Recording, error) {
+	recording, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrRecordingNotFound
+	}
+	return recording, nil
+}
+
+// Cancel cancels a scheduled recording or stops a running one (the captured part is kept)
+func (s *RecordingService) Cancel(id uuid.UUID) (*domain.Recording, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recording, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrRecordingNotFound
+	}
+
+	switch recording.Status {
+	case domain.RecordingStatusScheduled:
+		now := time.Now()
+		recording.Status = domain.RecordingStatusCancelled
+		recording.EndedAt = &now
+		if err := s.repo.Update(recording); err != nil {
+			return nil, err
+		}
+	case domain.RecordingStatusRecording:
+		// The job goroutine records the final state once FFmpeg finalized the archive
+		if cancel, ok := s.active[id]; ok {
+			cancel()
+		}
+	default:
+		return nil, ErrRecordingFinished
+	}
+	return recording, nil
+}
+
+// Delete deletes a finished or scheduled recording and its archive
+func (s *RecordingService) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recording, err := s.repo.GetByID(id)
+	if err != nil {
+		return ErrRecordingNotFound
+	}
+	if recording.Status == domain.RecordingStatusRecording {
+		return ErrRecordingActive
+	}
+	if err := s.removeArchive(recording); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// Archive returns the recording and the path of its MP4 archive
+func (s *RecordingService) Archive(id uuid.UUID) (*domain.Recording, string, error) {
+	recording, err := s.Get(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if !recording.HasArchive() {
+		return nil, "", ErrArchiveNotFound
+	}
+	if _, err := os.Stat(recording.FilePath); err != nil {
+		return nil, "", ErrArchiveNotFound
+	}
+	return recording, recording.FilePath, nil
+}
+
+// removeArchive removes the archive file of a recording
+func (s *RecordingService) removeArchive(recording *domain.Recording) error {
+	if recording.FilePath == "" {
+		return nil
+	}
+	paths := append([]string{recording.FilePath, recording.FilePath + recordingPartSuffix}, archiveParts(recording.FilePath)...)
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// archiveParts returns the per-run part files of an archive (path.N.part)
+func archiveParts(path string) []string {
+	parts, _ := filepath.Glob(path + ".*" + recordingPartSuffix)
+	return parts
+}
+
+// Run starts due recordings and prunes expired archives until ctx is cancelled
+func (s *RecordingService) Run(ctx context.Context) {
+	s.recoverInterrupted()
+
+	ticker := time.NewTicker(recordingCheckInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		now := time.Now()
+		s.startDue(ctx, now)
+
+		if s.retentionDays > 0 && now.Sub(lastPrune) >= recordingRetentionInterval {
+			lastPrune = now
+			s.prune(now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// recoverInterrupted fails the jobs left recording by a previous backend instance
+// The recorder got SIGTERM with the backend, so FFmpeg finalized what it captured.
+func (s *RecordingService) recoverInterrupted() {
+	recordings, err := s.repo.ListByStatus(domain.RecordingStatusRecording)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load interrupted recordings")
+		return
+	}
+	for _, recording := range recordings {
+		if _, err := os.Stat(recording.FilePath); os.IsNotExist(err) {
+			os.Rename(recording.FilePath+recordingPartSuffix, recording.FilePath)
+		}
+		// A recording that spanned channel restarts left one part per run; a single part is the archive
+		if parts := archiveParts(recording.FilePath); len(parts) == 1 {
+			if _, err := os.Stat(recording.FilePath); os.IsNotExist(err) {
+				os.Rename(parts[0], recording.FilePath)
+			}
+		}
+		s.finish(recording, domain.RecordingStatusFailed, "interrupted by a backend restart")
+	}
+}
+
+// startDue starts the scheduled recordings whose start time has come
+func (s *RecordingService) startDue(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordings, err := s.repo.ListByStatus(domain.RecordingStatusScheduled)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load scheduled recordings")
+		return
+	}
+	for _, recording := range recordings {
+		if recording.StartAt.After(now) {
+			break // Ordered by start time
+		}
+
+		// Jobs due while the backend was down record what is left of their window
+		end := recording.StartAt.Add(time.Duration(recording.Duration) * time.Second)
+		remaining := end.Sub(now)
+		if remaining < time.Second {
+			s.finish(recording, domain.RecordingStatusFailed, "missed: the backend was not running during the scheduled window")
+			continue
+		}
+
+		startedAt := now
+		recording.Status = domain.RecordingStatusRecording
+		recording.StartedAt = &startedAt
+		if err := s.repo.Update(recording); err != nil {
+			logger.Error().Err(err).Str("recording_id", recording.ID.String()).Msg("Failed to start recording")
+			continue
+		}
+
+		jobCtx, cancel := context.WithCancel(ctx)
+		s.active[recording.ID] = cancel
+		go s.record(ctx, jobCtx, recording, remaining)
+	}
+}
+
+// record runs one recording job and stores its outcome
+func (s *RecordingService) record(ctx, jobCtx context.Context, recording *domain.Recording, duration time.Duration) {
+	err := s.recorder.Record(jobCtx, recording.ChannelID, recording.FilePath, duration)
+	shutdown, cancelled := ctx.Err() != nil, jobCtx.Err() != nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.active[recording.ID]; ok {
+		cancel()
+		delete(s.active, recording.ID)
+	}
+
+	switch {
+	case shutdown:
+		s.finish(recording, domain.RecordingStatusFailed, "interrupted by a backend shutdown")
+	case cancelled:
+		s.finish(recording, domain.RecordingStatusCancelled, "")
+	case err != nil:
+		s.finish(recording, domain.RecordingStatusFailed, err.Error())
+	default:
+		s.finish(recording, domain.RecordingStatusCompleted, "")
+	}
+}
+
+// finish stores the final state of a recording with the size of its archive
+func (s *RecordingService) finish(recording *domain.Recording, status domain.RecordingStatus, message string) {
+	now := time.Now()
+	recording.Status = status
+	recording.EndedAt = &now
+	recording.Error = message
+	recording.SizeBytes = 0
+	if info, err := os.Stat(recording.FilePath); err == nil {
+		recording.SizeBytes = info.Size()
+	}
+	if err := s.repo.Update(recording); err != nil {
+		logger.Error().Err(err).Str("recording_id", recording.ID.String()).Msg("Failed to store recording state")
+		return
+	}
+
+	event := logger.Info()
+	if status == domain.RecordingStatusFailed {
+		event = logger.Warn().Str("error", message)
+	}
+	event.
+		Str("recording_id", recording.ID.String()).
+		Str("channel_id", recording.ChannelID.String()).
+		Str("status", string(status)).
+		Int64("size_bytes", recording.SizeBytes).
+		Msg("Recording finished")
+}
+
+// prune deletes the recordings and archives that ended before the retention period
+func (s *RecordingService) prune(now time.Time) {
+	recordings, err := s.repo.ListFinishedBefore(now.AddDate(0, 0, -s.retentionDays))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load expired recordings")
+		return
+	}
+
+	deleted := 0
+	for _, recording := range recordings {
+		if err := s.removeArchive(recording); err != nil {
+			logger.Error().Err(err).Str("recording_id", recording.ID.String()).Msg("Failed to prune recording")
+			continue
+		}
+		if err := s.repo.Delete(recording.ID); err != nil {
+			logger.Error().Err(err).Str("recording_id", recording.ID.String()).Msg("Failed to prune recording")
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		logger.Info().
+			Int("deleted", deleted).
+			Int("retention_days", s.retentionDays).
+			Msg("Pruned expired recordings")
+	}
+}
@@ -0,0 +1,332 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	scheduleCheckInterval   = 10 * time.Second
+	scheduleRetryDelay      = time.Minute // Failed starts are retried while their window lasts
+	defaultUpcomingRange    = 24 * time.Hour
+	maxUpcomingRange        = 31 * 24 * time.Hour
+	maxUpcomingPerSchedule  = 500
+	scheduleLocalTimeLayout = "2006-01-02T15:04"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrInvalidSchedule  = errors.New("invalid schedule data")
+)
+
+// ScheduleInput represents input for creating or updating a schedule
+// StartAt/EndAt accept RFC3339 or a local time (2006-01-02T15:04[:05]) in Timezone.
+type ScheduleInput struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`     // once or recurring
+	Timezone string `json:"timezone"` // IANA name (default UTC)
+	StartAt  string `json:"start_at"`
+	EndAt    string `json:"end_at"`
+	Cron     string `json:"cron"`
+	Duration int    `json:"duration"` // Minutes
+	Enabled  *bool  `json:"enabled"`  // Default true
+}
+
+// ScheduleService stores channel schedules and runs their start and stop actions
+type ScheduleService struct {
+	repo           domain.ScheduleRepository
+	channelRepo    domain.ChannelRepository
+	channelService *ChannelService
+	logRepo        domain.ChannelLogRepository // Failed and missed starts are reported in the channel log history
+	mu             sync.Mutex
+	wake           chan struct{}
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(repo domain.ScheduleRepository, channelRepo domain.ChannelRepository, channelService *ChannelService, logRepo domain.ChannelLogRepository) *ScheduleService {
+	return &ScheduleService{
+		repo:           repo,
+		channelRepo:    channelRepo,
+		channelService: channelService,
+		logRepo:        logRepo,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Create adds a schedule to a channel and plans its first window
+func (s *ScheduleService) Create(channelID uuid.UUID, input ScheduleInput) (*domain.Schedule, error) {
+	if _, err := s.channelRepo.GetByID(channelID); err != nil {
+		return nil, ErrChannelNotFound
+	}
+
+	now := time.Now()
+	schedule := &domain.Schedule{
+		ID:        uuid.New(),
+		ChannelID: channelID,
+		CreatedAt: now,
+	}
+	if err := applyScheduleInput(schedule, input, now); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.repo.Create(schedule); err != nil {
+		return nil, err
+	}
+	s.notify()
+	return schedule, nil
+}
+
+// Update replaces the definition of a schedule and plans it again
+func (s *ScheduleService) Update(id uuid.UUID, input ScheduleInput) (*domain.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrScheduleNotFound
+	}
+	if err := applyScheduleInput(schedule, input, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(schedule); err != nil {
+		return nil, err
+	}
+	s.notify()
+	return schedule, nil
+}
+
+// applyScheduleInput validates input into schedule and plans its next action
+func applyScheduleInput(schedule *domain.Schedule, input ScheduleInput, now time.Time) error {
+	schedule.Name = strings.TrimSpace(input.Name)
+	schedule.Type = domain.ScheduleType(input.Type)
+	schedule.Timezone = input.Timezone
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	schedule.Cron = strings.TrimSpace(input.Cron)
+	schedule.Duration = input.Duration
+	schedule.Enabled = input.Enabled == nil || *input.Enabled
+	schedule.UpdatedAt = now
+
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+	}
+	if schedule.StartAt, err = parseScheduleTime(input.StartAt, loc); err != nil {
+		return fmt.Errorf("%w: start_at: %v", ErrInvalidSchedule, err)
+	}
+	if schedule.EndAt, err = parseScheduleTime(input.EndAt, loc); err != nil {
+		return fmt.Errorf("%w: end_at: %v", ErrInvalidSchedule, err)
+	}
+	if err := schedule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	schedule.Plan(now)
+	if schedule.Enabled && schedule.NextRunAt == nil {
+		return fmt.Errorf("%w: the schedule has no window after now", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// parseScheduleTime parses an RFC3339 time or a local time in loc ("" = nil)
+func parseScheduleTime(value string, loc *time.Location) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	for _, layout := range []string{scheduleLocalTimeLayout, scheduleLocalTimeLayout + ":05"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("expected RFC3339 or %s, got %q", scheduleLocalTimeLayout, value)
+}
+
+// Delete deletes a schedule (the channel keeps its current state)
+func (s *ScheduleService) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.repo.GetByID(id); err != nil {
+		return ErrScheduleNotFound
+	}
+	return s.repo.Delete(id)
+}
+
+// Get returns a schedule by ID
+func (s *ScheduleService) Get(id uuid.UUID) (*domain.Schedule, error) {
+	schedule, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// List returns the schedules of a channel (nil = all channels)
+func (s *ScheduleService) List(channelID *uuid.UUID) ([]*domain.Schedule, error) {
+	return s.repo.List(channelID)
+}
+
+// Upcoming returns the planned start and stop actions within a range (default: next 24 hours), in time order
+// Starts already due are included, they run on the next scheduler pass.
+func (s *ScheduleService) Upcoming(channelID *uuid.UUID, within time.Duration) ([]domain.ScheduledAction, error) {
+	if within <= 0 {
+		within = defaultUpcomingRange
+	}
+	if within > maxUpcomingRange {
+		within = maxUpcomingRange
+	}
+
+	schedules, err := s.repo.List(channelID)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Now().Add(within)
+	actions := make([]domain.ScheduledAction, 0)
+	for _, schedule := range schedules {
+		actions = append(actions, schedule.Upcoming(until, maxUpcomingPerSchedule)...)
+	}
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].At.Before(actions[j].At)
+	})
+	return actions, nil
+}
+
+// notify wakes the scheduler after a plan changed
+func (s *ScheduleService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run executes due schedule actions until ctx is cancelled
+func (s *ScheduleService) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runDue executes the actions due at now and stores the next plan of their schedules
+func (s *ScheduleService) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules, err := s.repo.ListDue(now)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load due schedules")
+		return
+	}
+
+	for _, schedule := range schedules {
+		switch schedule.NextAction {
+		case domain.ScheduleActionStart:
+			s.runStart(schedule, now)
+		case domain.ScheduleActionStop:
+			s.runStop(schedule, now)
+		default:
+			schedule.Plan(now)
+		}
+
+		schedule.UpdatedAt = now
+		if err := s.repo.Update(schedule); err != nil {
+			logger.Error().Err(err).Str("schedule_id", schedule.ID.String()).Msg("Failed to store schedule plan")
+		}
+	}
+}
+
+// runStart starts the channel of a due window, retrying failures while the window lasts
+func (s *ScheduleService) runStart(schedule *domain.Schedule, now time.Time) {
+	// The backend was down for the whole window
+	if schedule.WindowEnd != nil && !schedule.WindowEnd.After(now) {
+		s.reportFailure(schedule, now, fmt.Sprintf("Scheduled start %q missed: its window ended at %s",
+			schedule.Name, schedule.WindowEnd.In(schedule.Location()).Format(time.RFC3339)))
+		schedule.Plan(now)
+		return
+	}
+
+	err := s.channelService.StartChannel(schedule.ChannelID)
+	if err == nil {
+		schedule.LastAction = domain.ScheduleActionStart
+		schedule.LastRunAt = &now
+		schedule.LastError = ""
+		schedule.LastErrorAt = nil
+		logger.Info().
+			Str("schedule_id", schedule.ID.String()).
+			Str("channel_id", schedule.ChannelID.String()).
+			Msg("Scheduled start completed")
+		schedule.Advance(now)
+		return
+	}
+
+	s.reportFailure(schedule, now, fmt.Sprintf("Scheduled start %q failed: %v", schedule.Name, err))
+	retryAt := now.Add(scheduleRetryDelay)
+	if schedule.WindowEnd == nil || retryAt.Before(*schedule.WindowEnd) {
+		schedule.NextRunAt = &retryAt
+		return
+	}
+	schedule.Advance(now)
+}
+
+// runStop stops the channel at the end of a window
+func (s *ScheduleService) runStop(schedule *domain.Schedule, now time.Time) {
+	if err := s.channelService.StopChannel(schedule.ChannelID); err != nil {
+		s.reportFailure(schedule, now, fmt.Sprintf("Scheduled stop %q failed: %v", schedule.Name, err))
+	} else {
+		schedule.LastAction = domain.ScheduleActionStop
+		schedule.LastRunAt = &now
+		logger.Info().
+			Str("schedule_id", schedule.ID.String()).
+			Str("channel_id", schedule.ChannelID.String()).
+			Msg("Scheduled stop completed")
+	}
+	schedule.Advance(now)
+}
+
+// reportFailure records a failed or missed action on the schedule and in the channel log history
+func (s *ScheduleService) reportFailure(schedule *domain.Schedule, now time.Time, message string) {
+	schedule.LastError = message
+	schedule.LastErrorAt = &now
+
+	logger.Warn().
+		Str("schedule_id", schedule.ID.String()).
+		Str("channel_id", schedule.ChannelID.String()).
+		Msg(message)
+	if s.logRepo == nil {
+		return
+	}
+	entry := &domain.ChannelLog{
+		ID:        uuid.New(),
+		ChannelID: schedule.ChannelID,
+		Level:     domain.LogLevelError,
+		Message:   message,
+		CreatedAt: now,
+	}
+	if err := s.logRepo.InsertBatch([]*domain.ChannelLog{entry}); err != nil {
+		logger.Error().Err(err).Str("schedule_id", schedule.ID.String()).Msg("Failed to persist schedule failure")
+	}
+}
@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultViewerIdleTimeout    = 30 * time.Second
+	defaultViewerSampleInterval = time.Minute
+	defaultViewerHistoryRange   = 24 * time.Hour
+	maxViewerHistoryRange       = 31 * 24 * time.Hour
+)
+
+// channelViewers holds the playback sessions of one channel
+type channelViewers struct {
+	sessions    map[string]time.Time // Session key -> last request
+	peak        int
+	peakAt      time.Time
+	lastSampled int // Count written by the previous sample (a drop to zero is written once)
+}
+
+// ViewerService tracks playback sessions from /streams requests and samples viewer counts
+type ViewerService struct {
+	channelRepo    domain.ChannelRepository
+	repo           domain.ViewerHistoryRepository
+	idleTimeout    time.Duration
+	sampleInterval time.Duration
+	retentionDays  int
+	mu             sync.Mutex
+	channels       map[uuid.UUID]*channelViewers
+}
+
+// NewViewerService creates a new viewer service
+func NewViewerService(channelRepo domain.ChannelRepository, repo domain.ViewerHistoryRepository, idleTimeoutSeconds, sampleIntervalSeconds, retentionDays int) *ViewerService {
+	idleTimeout := time.Duration(idleTimeoutSeconds) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = defaultViewerIdleTimeout
+	}
+	sampleInterval := time.Duration(sampleIntervalSeconds) * time.Second
+	if sampleInterval <= 0 {
+		sampleInterval = defaultViewerSampleInterval
+	}
+	return &ViewerService{
+		channelRepo:    channelRepo,
+		repo:           repo,
+		idleTimeout:    idleTimeout,
+		sampleInterval: sampleInterval,
+		retentionDays:  retentionDays,
+		channels:       make(map[uuid.UUID]*channelViewers),
+	}
+}
+
+// Touch records a playlist or segment request of a playback session
+func (s *ViewerService) Touch(channelID uuid.UUID, session string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	viewers, ok := s.channels[channelID]
+	if !ok {
+		viewers = &channelViewers{sessions: make(map[string]time.Time)}
+		s.channels[channelID] = viewers
+	}
+	viewers.sessions[session] = now
+
+	if len(viewers.sessions) > viewers.peak {
+		// Expired sessions must not inflate the peak
+		s.expireLocked(viewers, now)
+		if len(viewers.sessions) > viewers.peak {
+			viewers.peak = len(viewers.sessions)
+			viewers.peakAt = now
+		}
+	}
+}
+
+// expireLocked drops the sessions idle for longer than the idle timeout (s.mu held)
+func (s *ViewerService) expireLocked(viewers *channelViewers, now time.Time) {
+	for session, lastSeen := range viewers.sessions {
+		if now.Sub(lastSeen) > s.idleTimeout {
+			delete(viewers.sessions, session)
+		}
+	}
+}
+
+// statsLocked returns the current and peak viewers of a channel (s.mu held)
+func (s *ViewerService) statsLocked(channelID uuid.UUID, now time.Time) domain.ViewerStats {
+	stats := domain.ViewerStats{ChannelID: channelID}
+	viewers, ok := s.channels[channelID]
+	if !ok {
+		return stats
+	}
+	s.expireLocked(viewers, now)
+	stats.Viewers = len(viewers.sessions)
+	stats.PeakViewers = viewers.peak
+	if viewers.peak > 0 {
+		peakAt := viewers.peakAt
+		stats.PeakAt = &peakAt
+	}
+	return stats
+}
+
+// GetViewers returns the current and peak viewers of a channel
+func (s *ViewerService) GetViewers(channelID uuid.UUID) domain.ViewerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statsLocked(channelID, time.Now())
+}
+
+// GetAllViewers returns the current and peak viewers of every channel watched since the backend started
+func (s *ViewerService) GetAllViewers() []domain.ViewerStats {
+	now := time.Now()
+	s.mu.Lock()
+	stats := make([]domain.ViewerStats, 0, len(s.channels))
+	for channelID := range s.channels {
+		stats = append(stats, s.statsLocked(channelID, now))
+	}
+	s.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Viewers > stats[j].Viewers
+	})
+	return stats
+}
+
+// History returns the sampled viewer counts of a channel within a time range (default: last 24 hours)
+func (s *ViewerService) History(channelID uuid.UUID, since, until *time.Time) ([]*domain.ViewerSample, error) {
+	if _, err := s.channelRepo.GetByID(channelID); err != nil {
+		return nil, ErrChannelNotFound
+	}
+
+	end := time.Now()
+	if until != nil {
+		end = *until
+	}
+	start := end.Add(-defaultViewerHistoryRange)
+	if since != nil {
+		start = *since
+	}
+	if end.Sub(start) > maxViewerHistoryRange {
+		start = end.Add(-maxViewerHistoryRange)
+	}
+	return s.repo.List(channelID, start, end)
+}
+
+// sample collects the viewer counts to persist
+// Channels are written while they have viewers, plus once when they drop to zero.
+func (s *ViewerService) sample(now time.Time) []*domain.ViewerSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var samples []*domain.ViewerSample
+	for channelID, viewers := range s.channels {
+		s.expireLocked(viewers, now)
+		count := len(viewers.sessions)
+		if count > 0 || viewers.lastSampled > 0 {
+			samples = append(samples, &domain.ViewerSample{ChannelID: channelID, Viewers: count, SampledAt: now})
+		}
+		viewers.lastSampled = count
+	}
+	return samples
+}
+
+// RunSampler writes viewer counts every sample interval and prunes old history until ctx is cancelled
+func (s *ViewerService) RunSampler(ctx context.Context) {
+	ticker := time.NewTicker(s.sampleInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.repo.InsertBatch(s.sample(now)); err != nil {
+				logger.Error().Err(err).Msg("Failed to store viewer counts")
+			}
+
+			if s.retentionDays > 0 && now.Sub(lastPrune) >= time.Hour {
+				lastPrune = now
+				deleted, err := s.repo.DeleteOlderThan(now.AddDate(0, 0, -s.retentionDays))
+				if err != nil {
+					logger.Error().Err(err).Msg("Failed to prune viewer history")
+				} else if deleted > 0 {
+					logger.Info().
+						Int64("deleted", deleted).
+						Int("retention_days", s.retentionDays).
+						Msg("Pruned old viewer history")
+				}
+			}
+		}
+	}
+}
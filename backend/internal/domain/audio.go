@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AudioMode selects whether audio is re-encoded or copied from the source
+type AudioMode string
+
+const (
+	AudioModeTranscode   AudioMode = "transcode"   // Encode to AAC (default)
+	AudioModePassthrough AudioMode = "passthrough" // Copy the source codec unchanged
+)
+
+// MissingAudio selects what happens when the source has no audio stream
+type MissingAudio string
+
+const (
+	MissingAudioSilence MissingAudio = "silence" // Generate a silent track (default, players expect audio)
+	MissingAudioDrop    MissingAudio = "drop"    // Publish video only
+)
+
+// AudioLayouts maps the accepted channel layouts to their channel count
+var AudioLayouts = map[string]int{
+	"mono":   1,
+	"stereo": 2,
+	"5.1":    6,
+}
+
+var (
+	languageRegex  = regexp.MustCompile(`^[a-z]{2,3}$`)
+	trackNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// AudioTrack selects one source audio track, by index or by language
+type AudioTrack struct {
+	Index    *int   `json:"index,omitempty"`    // Position among the source audio streams (0 = first)
+	Language string `json:"language,omitempty"` // ISO 639 code matched against the stream language tag (e.g. eng)
+	Name     string `json:"name,omitempty"`     // HLS rendition name, defaults to audio_<language> or audio_<n>
+	Default  bool   `json:"default,omitempty"`  // Marked as the default rendition (first track if none)
+}
+
+// AudioConfig controls audio track selection and encoding
+// A nil config keeps the first source track encoded as stereo 128k AAC.
+type AudioConfig struct {
+	Tracks        []AudioTrack `json:"tracks,omitempty"`         // Selected tracks in output order; empty selects the first track
+	AllTracks     bool         `json:"all_tracks,omitempty"`     // Keep every source audio track (Tracks is ignored)
+	Mode          AudioMode    `json:"mode,omitempty"`           // transcode (default) or passthrough
+	Bitrate       string       `json:"bitrate,omitempty"`        // AAC bitrate per track (default 128k, 384k for 5.1)
+	ChannelLayout string       `json:"channel_layout,omitempty"` // mono, stereo (default) or 5.1
+	SampleRate    int          `json:"sample_rate,omitempty"`    // Default 48000
+	Missing       MissingAudio `json:"missing,omitempty"`        // silence (default) or drop when the source has no audio
+}
+
+// Normalized returns the config with unset fields filled with defaults (nil returns the defaults)
+func (a *AudioConfig) Normalized() AudioConfig {
+	var config AudioConfig
+	if a != nil {
+		config = *a
+	}
+	if config.Mode == "" {
+		config.Mode = AudioModeTranscode
+	}
+	if config.ChannelLayout == "" {
+		config.ChannelLayout = "stereo"
+	}
+	if config.Bitrate == "" {
+		config.Bitrate = "128k"
+		if config.ChannelLayout == "5.1" {
+			config.Bitrate = "384k"
+		}
+	}
+	if config.SampleRate == 0 {
+		config.SampleRate = 48000
+	}
+	if config.Missing == "" {
+		config.Missing = MissingAudioSilence
+	}
+	return config
+}
+
+// Validate checks the audio configuration
+func (a *AudioConfig) Validate() error {
+	if a == nil {
+		return nil
+	}
+	switch a.Mode {
+	case "", AudioModeTranscode, AudioModePassthrough:
+	default:
+		return fmt.Errorf("invalid audio mode %q (allowed: transcode, passthrough)", a.Mode)
+	}
+	switch a.Missing {
+	case "", MissingAudioSilence, MissingAudioDrop:
+	default:
+		return fmt.Errorf("invalid missing audio action %q (allowed: silence, drop)", a.Missing)
+	}
+	if _, ok := AudioLayouts[a.ChannelLayout]; a.ChannelLayout != "" && !ok {
+		return fmt.Errorf("invalid audio channel layout %q (allowed: mono, stereo, 5.1)", a.ChannelLayout)
+	}
+	if a.Bitrate != "" {
+		if _, err := fmt.Sscanf(strings.TrimSuffix(a.Bitrate, "k"), "%d", new(int)); err != nil || !strings.HasSuffix(a.Bitrate, "k") {
+			return fmt.Errorf("invalid audio bitrate %q (e.g. 128k)", a.Bitrate)
+		}
+	}
+	if a.SampleRate != 0 && (a.SampleRate < 8000 || a.SampleRate > 96000) {
+		return fmt.Errorf("invalid audio sample rate %d", a.SampleRate)
+	}
+
+	names := make(map[string]bool, len(a.Tracks))
+	defaults := 0
+	for i, track := range a.Tracks {
+		if (track.Index == nil) == (track.Language == "") {
+			return fmt.Errorf("audio track %d must select either an index or a language", i)
+		}
+		if track.Index != nil && *track.Index < 0 {
+			return fmt.Errorf("audio track %d has a negative index", i)
+		}
+		if track.Language != "" && !languageRegex.MatchString(track.Language) {
+			return fmt.Errorf("audio track %d has invalid language %q (ISO 639 code, e.g. eng)", i, track.Language)
+		}
+		if track.Name != "" {
+			if !trackNameRegex.MatchString(track.Name) {
+				return fmt.Errorf("audio track %d has invalid name %q", i, track.Name)
+			}
+			if names[track.Name] {
+				return fmt.Errorf("duplicate audio track name %q", track.Name)
+			}
+			names[track.Name] = true
+		}
+		if track.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("only one audio track can be the default")
+	}
+	return nil
+}
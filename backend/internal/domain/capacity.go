@@ -0,0 +1,34 @@
+package domain
+
+import "fmt"
+
+// CapacityLimit identifies the node limit that refused a channel start
+type CapacityLimit string
+
+const (
+	CapacityLimitChannels    CapacityLimit = "max_channels"
+	CapacityLimitGPUSessions CapacityLimit = "gpu_sessions"
+	CapacityLimitMemory      CapacityLimit = "memory"
+)
+
+// CapacityError is returned when admission control refuses to start a channel
+type CapacityError struct {
+	Limit   CapacityLimit  `json:"limit"`
+	Used    int64          `json:"used"`
+	Max     int64          `json:"max"`
+	Backend EncoderBackend `json:"backend,omitempty"` // Only for gpu_sessions
+	Device  int            `json:"device,omitempty"`  // Only for gpu_sessions
+}
+
+func (e *CapacityError) Error() string {
+	switch e.Limit {
+	case CapacityLimitChannels:
+		return fmt.Sprintf("channel limit reached: %d of %d channels running (max_channels)", e.Used, e.Max)
+	case CapacityLimitGPUSessions:
+		return fmt.Sprintf("GPU session limit reached: %s device %d has %d of %d encoder sessions in use (max_sessions_per_gpu)",
+			e.Backend, e.Device, e.Used, e.Max)
+	case CapacityLimitMemory:
+		return fmt.Sprintf("not enough memory: %d MB available, %d MB required (min_free_memory_mb)", e.Used, e.Max)
+	}
+	return fmt.Sprintf("capacity limit %s reached (%d/%d)", e.Limit, e.Used, e.Max)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+// CaptionConfig controls how captions and subtitles of the source are carried into the HLS output
+type CaptionConfig struct {
+	// ClosedCaptions keeps EIA-608/708 captions embedded in the source video as CEA-608 in the
+	// output video stream (H.264 and HEVC only)
+	ClosedCaptions bool   `json:"closed_captions,omitempty"`
+	CCLanguage     string `json:"cc_language,omitempty"` // Language announced for CC1 in the master playlist
+	// Subtitles converts text subtitle streams (SubRip, ASS, mov_text, WebVTT) into WebVTT renditions;
+	// bitmap subtitles such as DVB cannot be converted and are skipped
+	Subtitles bool     `json:"subtitles,omitempty"`
+	Languages []string `json:"languages,omitempty"` // Subtitle languages to keep in order (empty = every text subtitle stream)
+}
+
+// Validate checks the caption configuration against the output codec
+func (c *CaptionConfig) Validate(codec VideoCodec) error {
+	if c == nil {
+		return nil
+	}
+	if c.ClosedCaptions && codec != VideoCodecH264 && codec != VideoCodecHEVC {
+		return fmt.Errorf("closed captions are only supported for h264 and hevc, not %s", codec)
+	}
+	if c.CCLanguage != "" && !languageRegex.MatchString(c.CCLanguage) {
+		return fmt.Errorf("invalid closed caption language %q (ISO 639 code, e.g. eng)", c.CCLanguage)
+	}
+	seen := make(map[string]bool, len(c.Languages))
+	for _, language := range c.Languages {
+		if !languageRegex.MatchString(language) {
+			return fmt.Errorf("invalid subtitle language %q (ISO 639 code, e.g. eng)", language)
+		}
+		if seen[language] {
+			return fmt.Errorf("duplicate subtitle language %q", language)
+		}
+		seen[language] = true
+	}
+	if len(c.Languages) > 0 && !c.Subtitles {
+		return fmt.Errorf("subtitle languages are set but subtitles are disabled")
+	}
+	return nil
+}
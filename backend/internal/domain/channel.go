@@ -1,6 +1,10 @@
 package domain
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,37 +21,88 @@ const (
 	ChannelStatusStopping ChannelStatus = "stopping"
 )
 
-// LogoConfig represents logo overlay configuration
-type LogoConfig struct {
-	Path    string  `json:"path"`
-	X       int     `json:"x"`
-	Y       int     `json:"y"`
-	Width   int     `json:"width"`
-	Height  int     `json:"height"`
-	Opacity float64 `json:"opacity"`
+// Rendition represents one output of an adaptive bitrate ladder
+type Rendition struct {
+	Name       string `json:"name"`              // Variant name, used as the HLS sub-directory (e.g. 720p)
+	Resolution string `json:"resolution"`        // Output resolution (e.g. 1280x720)
+	Bitrate    string `json:"bitrate"`           // Target bitrate (e.g. 3000k)
+	Maxrate    string `json:"maxrate,omitempty"` // Defaults to Bitrate
+	Bufsize    string `json:"bufsize,omitempty"` // Defaults to 2x Maxrate
+}
+
+// rateRegex matches FFmpeg rates in bits per second with an optional k or M suffix (e.g. 3000k, 6M)
+var rateRegex = regexp.MustCompile(`^[1-9][0-9]*[kM]?$`)
+
+// Validate checks the resolution and rate control values of a rendition
+func (r Rendition) Validate() error {
+	if _, _, ok := ParseResolution(r.Resolution); !ok {
+		return fmt.Errorf("invalid resolution %q (e.g. 1280x720)", r.Resolution)
+	}
+	if r.Bitrate == "" {
+		return fmt.Errorf("no bitrate")
+	}
+	for _, rate := range []string{r.Bitrate, r.Maxrate, r.Bufsize} {
+		if rate != "" && !rateRegex.MatchString(rate) {
+			return fmt.Errorf("invalid rate %q (e.g. 3000k or 6M)", rate)
+		}
+	}
+	return nil
+}
+
+// ParseResolution parses a "WIDTHxHEIGHT" string
+func ParseResolution(resolution string) (int, int, bool) {
+	parts := strings.Split(resolution, "x")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	width, err := strconv.Atoi(parts[0])
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
 }
 
 // OutputConfig represents encoding output configuration
 type OutputConfig struct {
-	Codec      string `json:"codec"`
+	Codec      string `json:"codec"` // h264, hevc or av1 (encoder names such as libx264 are accepted)
 	Bitrate    string `json:"bitrate"`
 	Resolution string `json:"resolution"`
 	Preset     string `json:"preset"`
 	Profile    string `json:"profile"`
+	Level      string `json:"level,omitempty"` // Codec level (e.g. 4.1); defaults per codec
+	// EncoderBackend forces nvenc, vaapi, qsv or software for this channel ("" or auto uses the node setting)
+	EncoderBackend string            `json:"encoder_backend,omitempty"`
+	Ladder         []Rendition       `json:"ladder,omitempty"`      // Optional ABR ladder; when set, Bitrate/Resolution are ignored
+	Audio          *AudioConfig      `json:"audio,omitempty"`       // Audio track selection and encoding (nil = first track, stereo AAC)
+	Captions       *CaptionConfig    `json:"captions,omitempty"`    // Closed caption and subtitle carriage (nil = dropped)
+	LowLatency     *LowLatencyConfig `json:"low_latency,omitempty"` // Low-Latency HLS output (nil = classic HLS)
+	Container      string            `json:"container,omitempty"`   // mpegts, fmp4 or cmaf (HLS + DASH); "" picks by codec
+	Push           []PushDestination `json:"push,omitempty"`        // RTMP/SRT/UDP destinations fed from the same encode
+	Encryption     *EncryptionConfig `json:"encryption,omitempty"`  // AES-128 HLS encryption with rotating keys (nil = clear)
+	DVR            *DVRConfig        `json:"dvr,omitempty"`         // Time-shift window kept on disk (nil = live edge only)
+	Slate          *SlateConfig      `json:"slate,omitempty"`       // Shown while the source is down (nil = the output stops)
 }
 
 // Channel represents a video channel entity
 type Channel struct {
-	ID             uuid.UUID     `json:"id"`
-	Name           string        `json:"name"`
-	SourceURL      string        `json:"source_url"`
-	OutputURL      string        `json:"output_url,omitempty"`
-	Logo           *LogoConfig   `json:"logo,omitempty"`
-	OutputConfig   *OutputConfig `json:"output_config,omitempty"`
-	Status         ChannelStatus `json:"status"`
-	AutoRestart    bool          `json:"auto_restart"`
-	CreatedAt      time.Time     `json:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at"`
+	ID             uuid.UUID      `json:"id"`
+	Name           string         `json:"name"`
+	SourceURL      string         `json:"source_url"`
+	BackupSources  []string       `json:"backup_sources,omitempty"` // Ordered failover sources, tried after SourceURL
+	ActiveSource   string         `json:"active_source,omitempty"`  // Source currently fed to FFmpeg (set only while running)
+	OutputURL      string         `json:"output_url,omitempty"`
+	Overlays       []OverlayLayer `json:"overlays,omitempty"` // Image, text and clock layers drawn over the video
+	OutputConfig   *OutputConfig  `json:"output_config,omitempty"`
+	Status         ChannelStatus  `json:"status"`
+	AutoRestart    bool           `json:"auto_restart"`
+	DesiredRunning bool           `json:"desired_running"`          // Operator intent, used to resume channels after a backend restart
+	RestartPolicy  *RestartPolicy `json:"restart_policy,omitempty"` // nil uses DefaultRestartPolicy
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 // NewChannel creates a new channel with default values
@@ -71,6 +126,16 @@ func NewChannel(name, sourceURL string) *Channel {
 	}
 }
 
+// HasSlate reports whether the channel shows a slate while its source is down
+func (c *Channel) HasSlate() bool {
+	return c.OutputConfig != nil && c.OutputConfig.Slate != nil && c.OutputConfig.Slate.Enabled
+}
+
+// Sources returns the primary source followed by the backup sources
+func (c *Channel) Sources() []string {
+	return append([]string{c.SourceURL}, c.BackupSources...)
+}
+
 // ChannelRepository defines the interface for channel persistence
 type ChannelRepository interface {
 	Create(channel *Channel) error
@@ -79,5 +144,6 @@ type ChannelRepository interface {
 	Update(channel *Channel) error
 	Delete(id uuid.UUID) error
 	UpdateStatus(id uuid.UUID, status ChannelStatus) error
+	SetDesiredRunning(id uuid.UUID, running bool) error
 }
 
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogLevel represents the severity of a channel log line
+type LogLevel string
+
+const (
+	LogLevelDebug   LogLevel = "debug"
+	LogLevelInfo    LogLevel = "info"
+	LogLevelWarning LogLevel = "warning"
+	LogLevelError   LogLevel = "error"
+)
+
+// IsValid checks if the log level is known
+func (l LogLevel) IsValid() bool {
+	switch l {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarning, LogLevelError:
+		return true
+	}
+	return false
+}
+
+// ChannelLog represents a persisted FFmpeg log line of a channel
+type ChannelLog struct {
+	ID        uuid.UUID `json:"id"`
+	ChannelID uuid.UUID `json:"channel_id"`
+	Level     LogLevel  `json:"level"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChannelLogFilter selects channel log history
+type ChannelLogFilter struct {
+	ChannelID uuid.UUID
+	Levels    []LogLevel // Empty means all levels
+	Search    string     // Case-insensitive substring match on message
+	Since     *time.Time
+	Until     *time.Time
+	Limit     int
+	Offset    int
+}
+
+// ChannelLogRepository defines the interface for channel log persistence
+type ChannelLogRepository interface {
+	InsertBatch(logs []*ChannelLog) error
+	List(filter ChannelLogFilter) ([]*ChannelLog, int, error)
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
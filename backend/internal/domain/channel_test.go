@@ -0,0 +1,33 @@
+package domain
+
+import "testing"
+
+func TestRenditionValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		rendition Rendition
+		wantErr   bool
+	}{
+		{name: "kilobits", rendition: Rendition{Resolution: "1280x720", Bitrate: "3000k"}},
+		{name: "megabits with maxrate and bufsize", rendition: Rendition{Resolution: "1920x1080", Bitrate: "6M", Maxrate: "7M", Bufsize: "14M"}},
+		{name: "plain bits per second", rendition: Rendition{Resolution: "640x360", Bitrate: "800000"}},
+		{name: "missing bitrate", rendition: Rendition{Resolution: "1280x720"}, wantErr: true},
+		{name: "bitrate with unit", rendition: Rendition{Resolution: "1280x720", Bitrate: "3000kbps"}, wantErr: true},
+		{name: "decimal bitrate", rendition: Rendition{Resolution: "1280x720", Bitrate: "2.5M"}, wantErr: true},
+		{name: "zero bitrate", rendition: Rendition{Resolution: "1280x720", Bitrate: "0k"}, wantErr: true},
+		{name: "invalid maxrate", rendition: Rendition{Resolution: "1280x720", Bitrate: "3000k", Maxrate: "fast"}, wantErr: true},
+		{name: "invalid bufsize", rendition: Rendition{Resolution: "1280x720", Bitrate: "3000k", Bufsize: "-6000k"}, wantErr: true},
+		{name: "trailing characters in resolution", rendition: Rendition{Resolution: "1280x720p", Bitrate: "3000k"}, wantErr: true},
+		{name: "zero height", rendition: Rendition{Resolution: "1280x0", Bitrate: "3000k"}, wantErr: true},
+		{name: "missing resolution", rendition: Rendition{Bitrate: "3000k"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rendition.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VideoCodec represents an output video codec
+type VideoCodec string
+
+const (
+	VideoCodecH264 VideoCodec = "h264"
+	VideoCodecHEVC VideoCodec = "hevc"
+	VideoCodecAV1  VideoCodec = "av1"
+)
+
+// CodecCapabilities describes the profiles and levels accepted for a codec
+type CodecCapabilities struct {
+	Profiles       []string
+	Levels         []string
+	DefaultProfile string
+	DefaultLevel   string // Empty means the encoder picks the level
+}
+
+var codecCapabilities = map[VideoCodec]CodecCapabilities{
+	VideoCodecH264: {
+		Profiles:       []string{"baseline", "main", "high"},
+		Levels:         []string{"3.0", "3.1", "3.2", "4.0", "4.1", "4.2", "5.0", "5.1", "5.2"},
+		DefaultProfile: "high",
+		DefaultLevel:   "4.1",
+	},
+	VideoCodecHEVC: {
+		Profiles:       []string{"main", "main10"},
+		Levels:         []string{"3.0", "3.1", "4.0", "4.1", "5.0", "5.1", "5.2", "6.0", "6.1", "6.2"},
+		DefaultProfile: "main",
+		DefaultLevel:   "4.1",
+	},
+	VideoCodecAV1: {
+		Profiles:       []string{"main"},
+		Levels:         []string{"2.0", "2.1", "3.0", "3.1", "4.0", "4.1", "5.0", "5.1", "5.2", "5.3", "6.0", "6.1", "6.2", "6.3"},
+		DefaultProfile: "main",
+	},
+}
+
+// SoftwarePresets are the x264/x265 style presets, fastest first
+var SoftwarePresets = []string{
+	"ultrafast", "superfast", "veryfast", "faster", "fast",
+	"medium", "slow", "slower", "veryslow",
+}
+
+// ParseVideoCodec maps a codec or encoder name to a VideoCodec
+// Empty input defaults to H.264 (existing channels store "libx264")
+func ParseVideoCodec(name string) (VideoCodec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "h264", "avc", "libx264", "h264_nvenc":
+		return VideoCodecH264, nil
+	case "hevc", "h265", "libx265", "hevc_nvenc":
+		return VideoCodecHEVC, nil
+	case "av1", "libsvtav1", "av1_nvenc":
+		return VideoCodecAV1, nil
+	}
+	return "", fmt.Errorf("unsupported codec: %s", name)
+}
+
+// Capabilities returns the accepted profiles and levels for the codec
+func (c VideoCodec) Capabilities() CodecCapabilities {
+	return codecCapabilities[c]
+}
+
+// ValidateProfile checks that the profile is valid for the codec
+func (c VideoCodec) ValidateProfile(profile string) error {
+	if profile == "" || contains(c.Capabilities().Profiles, profile) {
+		return nil
+	}
+	return fmt.Errorf("profile %q is not valid for %s (allowed: %s)", profile, c, strings.Join(c.Capabilities().Profiles, ", "))
+}
+
+// ValidateLevel checks that the level is valid for the codec
+func (c VideoCodec) ValidateLevel(level string) error {
+	if level == "" || contains(c.Capabilities().Levels, level) {
+		return nil
+	}
+	return fmt.Errorf("level %q is not valid for %s (allowed: %s)", level, c, strings.Join(c.Capabilities().Levels, ", "))
+}
+
+// ValidatePreset checks that the preset is valid for the codec
+// Software preset names and NVENC presets (p1-p7) are accepted for every codec,
+// AV1 additionally accepts SVT-AV1 numeric presets (0-13)
+func (c VideoCodec) ValidatePreset(preset string) error {
+	if preset == "" || contains(SoftwarePresets, preset) {
+		return nil
+	}
+	if n, ok := NVENCPresetNumber(preset); ok && n >= 1 && n <= 7 {
+		return nil
+	}
+	if c == VideoCodecAV1 {
+		if n, err := strconv.Atoi(preset); err == nil && n >= 0 && n <= 13 {
+			return nil
+		}
+	}
+	return fmt.Errorf("preset %q is not valid for %s", preset, c)
+}
+
+// NVENCPresetNumber parses an NVENC preset name ("p1".."p7")
+func NVENCPresetNumber(preset string) (int, bool) {
+	if !strings.HasPrefix(preset, "p") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(preset[1:])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// EncoderBackend selects the hardware used for encoding
+type EncoderBackend string
+
+const (
+	EncoderBackendAuto     EncoderBackend = "auto"
+	EncoderBackendNVENC    EncoderBackend = "nvenc"
+	EncoderBackendVAAPI    EncoderBackend = "vaapi"
+	EncoderBackendQSV      EncoderBackend = "qsv"
+	EncoderBackendSoftware EncoderBackend = "software"
+)
+
+// ParseEncoderBackend validates an encoder backend name (empty means auto)
+func ParseEncoderBackend(name string) (EncoderBackend, error) {
+	switch backend := EncoderBackend(strings.ToLower(strings.TrimSpace(name))); backend {
+	case "":
+		return EncoderBackendAuto, nil
+	case EncoderBackendAuto, EncoderBackendNVENC, EncoderBackendVAAPI, EncoderBackendQSV, EncoderBackendSoftware:
+		return backend, nil
+	}
+	return "", fmt.Errorf("unsupported encoder backend: %s", name)
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "fmt"
+
+// Container is the segment container of the channel output
+type Container string
+
+const (
+	ContainerMPEGTS Container = "mpegts" // MPEG-TS segments, HLS only
+	ContainerFMP4   Container = "fmp4"   // fMP4/CMAF segments, HLS only
+	ContainerCMAF   Container = "cmaf"   // fMP4/CMAF segments published as HLS and MPEG-DASH
+)
+
+// ParseContainer validates a container name ("" picks the container by codec)
+func ParseContainer(value string) (Container, error) {
+	switch Container(value) {
+	case "", ContainerMPEGTS, ContainerFMP4, ContainerCMAF:
+		return Container(value), nil
+	}
+	return "", fmt.Errorf("invalid container %q (allowed: mpegts, fmp4, cmaf)", value)
+}
+
+// DefaultContainer is the container used when none is configured
+// HEVC and AV1 are only widely playable (and AV1 only muxable) in fMP4
+func DefaultContainer(codec VideoCodec) Container {
+	if codec == VideoCodecH264 {
+		return ContainerMPEGTS
+	}
+	return ContainerFMP4
+}
+
+// ValidateContainer checks the container against the codec and the low-latency mode
+func ValidateContainer(container Container, codec VideoCodec, lowLatency *LowLatencyConfig) error {
+	lowLatencyEnabled := lowLatency != nil && lowLatency.Enabled
+	switch container {
+	case ContainerMPEGTS:
+		if codec != VideoCodecH264 {
+			return fmt.Errorf("%s requires an fmp4 or cmaf container", codec)
+		}
+		if lowLatencyEnabled {
+			return fmt.Errorf("low-latency HLS requires an fmp4 container")
+		}
+	case ContainerCMAF:
+		if lowLatencyEnabled {
+			return fmt.Errorf("low-latency HLS parts cannot be published as DASH, use the fmp4 container")
+		}
+	}
+	return nil
+}
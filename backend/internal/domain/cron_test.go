@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"@every 5m",
+	}
+	for _, expression := range tests {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expression)
+		}
+	}
+}
+
+func TestCronExpressionNext(t *testing.T) {
+	istanbul := mustLoadLocation(t, "Europe/Istanbul")
+	newYork := mustLoadLocation(t, "America/New_York")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name       string
+		expression string
+		from       time.Time
+		want       []time.Time
+	}{
+		{
+			name:       "every 15 minutes",
+			expression: "*/15 * * * *",
+			from:       time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC),
+				time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC),
+				time.Date(2026, 10, 16, 10, 45, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "strictly after a matching minute",
+			expression: "0 * * * *",
+			from:       time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC),
+			want:       []time.Time{time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:       "weekdays by name in a time zone",
+			expression: "0 18 * * mon-fri",
+			from:       time.Date(2026, 10, 16, 18, 0, 0, 0, istanbul), // Friday
+			want: []time.Time{
+				time.Date(2026, 10, 19, 18, 0, 0, 0, istanbul),
+				time.Date(2026, 10, 20, 18, 0, 0, 0, istanbul),
+			},
+		},
+		{
+			name:       "day of month or day of week",
+			expression: "0 12 1 * 7",
+			from:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "single value with step",
+			expression: "5/20 9-10 * * *",
+			from:       time.Date(2026, 10, 16, 10, 50, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 10, 17, 9, 5, 0, 0, time.UTC),
+				time.Date(2026, 10, 17, 9, 25, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:       "leap day",
+			expression: "0 0 29 feb *",
+			from:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			want:       []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:       "never matches",
+			expression: "0 0 30 2 *",
+			from:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			want:       []time.Time{{}},
+		},
+		{
+			name:       "macro",
+			expression: "@daily",
+			from:       time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+			want:       []time.Time{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:       "daily across spring forward keeps wall time",
+			expression: "0 9 * * *",
+			from:       time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 9, 0, 0, 0, newYork),
+				time.Date(2026, 3, 9, 9, 0, 0, 0, newYork),
+			},
+		},
+		{
+			name:       "time skipped by spring forward",
+			expression: "30 2 * * *",
+			from:       time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want:       []time.Time{time.Date(2026, 3, 9, 2, 30, 0, 0, newYork)},
+		},
+		{
+			name:       "hourly across fall back",
+			expression: "0 * * * *",
+			from:       time.Date(2026, 10, 25, 1, 30, 0, 0, berlin),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), // 02:00 CEST
+				time.Date(2026, 10, 25, 1, 0, 0, 0, time.UTC), // 02:00 CET
+				time.Date(2026, 10, 25, 2, 0, 0, 0, time.UTC), // 03:00 CET
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expression, err)
+			}
+			at := tt.from
+			for i, want := range tt.want {
+				at = expression.Next(at)
+				if !at.Equal(want) {
+					t.Fatalf("run %d: Next = %v, want %v", i+1, at, want)
+				}
+				if !at.IsZero() && at.Location() != tt.from.Location() {
+					t.Fatalf("run %d: location %v, want %v", i+1, at.Location(), tt.from.Location())
+				}
+			}
+		})
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+// MaxDVRWindowMinutes is the longest time-shift window a channel may keep
+const MaxDVRWindowMinutes = 24 * 60
+
+// DVRConfig keeps a time-shift window of segments so viewers can pause and rewind
+type DVRConfig struct {
+	Enabled       bool `json:"enabled"`
+	WindowMinutes int  `json:"window_minutes"` // Length of the window kept on disk and listed in the playlist
+}
+
+// Validate checks the DVR configuration
+func (d *DVRConfig) Validate() error {
+	if d == nil || !d.Enabled {
+		return nil
+	}
+	if d.WindowMinutes < 1 || d.WindowMinutes > MaxDVRWindowMinutes {
+		return fmt.Errorf("dvr window must be between 1 and %d minutes, got %d", MaxDVRWindowMinutes, d.WindowMinutes)
+	}
+	return nil
+}
+
+// WindowSegments returns the number of segments covering the window
+func (d *DVRConfig) WindowSegments(segmentSeconds int) int {
+	if segmentSeconds <= 0 {
+		segmentSeconds = 1
+	}
+	return (d.WindowMinutes*60 + segmentSeconds - 1) / segmentSeconds
+}
+
+// ValidateDVR checks that the DVR window can be combined with the channel output
+func ValidateDVR(dvr *DVRConfig, lowLatency *LowLatencyConfig) error {
+	if dvr == nil || !dvr.Enabled {
+		return nil
+	}
+	if lowLatency != nil && lowLatency.Enabled {
+		return fmt.Errorf("dvr cannot be combined with low-latency HLS")
+	}
+	return nil
+}
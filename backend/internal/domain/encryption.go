@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EncryptionMethod is the HLS segment encryption method
+type EncryptionMethod string
+
+const (
+	EncryptionAES128    EncryptionMethod = "aes-128"    // Whole segments encrypted with AES-128-CBC
+	EncryptionSampleAES EncryptionMethod = "sample-aes" // Not supported by FFmpeg's HLS muxer
+)
+
+// DefaultKeyRotationSegments is the number of segments encrypted with one key
+const DefaultKeyRotationSegments = 10
+
+// EncryptionConfig enables HLS encryption with keys rotated by the backend
+type EncryptionConfig struct {
+	Enabled        bool             `json:"enabled"`
+	Method         EncryptionMethod `json:"method,omitempty"`          // aes-128 (default)
+	RotateSegments int              `json:"rotate_segments,omitempty"` // New key every N segments (default 10)
+}
+
+// Normalized returns the configuration with defaults applied
+func (e *EncryptionConfig) Normalized() EncryptionConfig {
+	if e == nil {
+		return EncryptionConfig{}
+	}
+	config := *e
+	if config.Method == "" {
+		config.Method = EncryptionAES128
+	}
+	if config.RotateSegments == 0 {
+		config.RotateSegments = DefaultKeyRotationSegments
+	}
+	return config
+}
+
+// Validate checks the encryption configuration
+func (e *EncryptionConfig) Validate() error {
+	if e == nil || !e.Enabled {
+		return nil
+	}
+	switch e.Method {
+	case "", EncryptionAES128:
+	case EncryptionSampleAES:
+		return fmt.Errorf("sample-aes encryption is not supported by the HLS muxer, use aes-128")
+	default:
+		return fmt.Errorf("invalid encryption method %q (allowed: aes-128)", e.Method)
+	}
+	if e.RotateSegments < 0 || e.RotateSegments > 10000 {
+		return fmt.Errorf("rotate_segments must be between 1 and 10000, got %d", e.RotateSegments)
+	}
+	return nil
+}
+
+// ValidateEncryption checks that encryption can be combined with the other output options
+func ValidateEncryption(encryption *EncryptionConfig, container Container, lowLatency *LowLatencyConfig, push []PushDestination) error {
+	if encryption == nil || !encryption.Enabled {
+		return nil
+	}
+	if container == ContainerCMAF {
+		return fmt.Errorf("encrypted channels cannot publish DASH, use the mpegts or fmp4 container")
+	}
+	if lowLatency != nil && lowLatency.Enabled {
+		return fmt.Errorf("encryption cannot be combined with low-latency HLS")
+	}
+	for _, destination := range push {
+		if !destination.Disabled {
+			return fmt.Errorf("encryption cannot be combined with push destinations (relays read the HLS output)")
+		}
+	}
+	return nil
+}
+
+// ChannelKey is an AES-128 content key of an encrypted channel
+type ChannelKey struct {
+	ID        uuid.UUID `json:"id"`
+	ChannelID uuid.UUID `json:"channel_id"`
+	Key       []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChannelKeyRepository defines the interface for content key persistence
+type ChannelKeyRepository interface {
+	Create(key *ChannelKey) error
+	Get(channelID, keyID uuid.UUID) (*ChannelKey, error)
+	// DeleteOlderThan deletes the keys of a channel created before a time, except the key
+	// still in use at that time (the newest one created before it)
+	DeleteOlderThan(channelID uuid.UUID, before time.Time) (int64, error)
+	DeleteByChannel(channelID uuid.UUID) (int64, error)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "fmt"
+
+// DefaultPartDuration is the LL-HLS part target in seconds
+const DefaultPartDuration = 1.0
+
+// LowLatencyConfig enables Low-Latency HLS output (partial segments and blocking playlist reload)
+type LowLatencyConfig struct {
+	Enabled      bool    `json:"enabled"`
+	PartDuration float64 `json:"part_duration,omitempty"` // Part target in seconds (0.2-2, default 1); rounded so a segment holds whole parts
+}
+
+// Validate checks the low-latency configuration
+func (l *LowLatencyConfig) Validate() error {
+	if l == nil || !l.Enabled {
+		return nil
+	}
+	if l.PartDuration != 0 && (l.PartDuration < 0.2 || l.PartDuration > 2) {
+		return fmt.Errorf("part duration must be between 0.2 and 2 seconds, got %v", l.PartDuration)
+	}
+	return nil
+}
+
+// PartTarget returns the configured part target (default if unset)
+func (l *LowLatencyConfig) PartTarget() float64 {
+	if l == nil || l.PartDuration == 0 {
+		return DefaultPartDuration
+	}
+	return l.PartDuration
+}
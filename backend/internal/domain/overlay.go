@@ -0,0 +1,264 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OverlayType is the kind of an overlay layer
+type OverlayType string
+
+const (
+	OverlayImage OverlayType = "image" // Picture file (logo, sponsor bug, badge)
+	OverlayText  OverlayType = "text"  // Static text drawn by drawtext
+	OverlayClock OverlayType = "clock" // Local time drawn by drawtext
+)
+
+// OverlayAnchor is the frame corner (or centre) a layer is placed against
+type OverlayAnchor string
+
+const (
+	AnchorTopLeft     OverlayAnchor = "top-left"
+	AnchorTopRight    OverlayAnchor = "top-right"
+	AnchorBottomLeft  OverlayAnchor = "bottom-left"
+	AnchorBottomRight OverlayAnchor = "bottom-right"
+	AnchorCenter      OverlayAnchor = "center" // Margins are ignored
+)
+
+var validAnchors = map[OverlayAnchor]bool{
+	AnchorTopLeft: true, AnchorTopRight: true, AnchorBottomLeft: true, AnchorBottomRight: true, AnchorCenter: true,
+}
+
+const (
+	MaxOverlayLayers        = 16
+	DefaultOverlayFontSize  = 32
+	DefaultOverlayFontColor = "white"
+	DefaultOverlayBoxColor  = "black@0.5"
+	DefaultOverlayBoxBorder = 8
+	DefaultClockFormat      = "%H:%M:%S"
+)
+
+// overlayColorRegex accepts FFmpeg colour names and hex values with an optional @alpha
+var overlayColorRegex = regexp.MustCompile(`^(#|0x)?[A-Za-z0-9]{1,32}(@[0-9.]{1,5})?$`)
+
+// OverlayLayer is one layer composed over the channel video
+// Layers are drawn from the lowest ZIndex up; layers with the same ZIndex keep their list order.
+// Anchored layers are placed and sized in percent of the frame, so they look the same at every
+// output resolution; without an anchor X/Y/Width/Height are pixels of the output frame.
+type OverlayLayer struct {
+	Type     OverlayType   `json:"type"`
+	Name     string        `json:"name,omitempty"`
+	Disabled bool          `json:"disabled,omitempty"`
+	Anchor   OverlayAnchor `json:"anchor,omitempty"`   // Corner or centre ("" = pixel position X/Y from the top left)
+	MarginX  float64       `json:"margin_x,omitempty"` // Anchored layers: percent of the frame width from the anchored edge
+	MarginY  float64       `json:"margin_y,omitempty"` // Anchored layers: percent of the frame height from the anchored edge
+	X        int           `json:"x"`
+	Y        int           `json:"y"`
+	Opacity  float64       `json:"opacity,omitempty"` // 0-1 (0 or unset = opaque)
+	ZIndex   int           `json:"z_index,omitempty"`
+
+	// Image layers
+	Path          string  `json:"path,omitempty"` // Relative to the logo directory or absolute
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	WidthPercent  float64 `json:"width_percent,omitempty"`  // Percent of the frame width, replaces Width
+	HeightPercent float64 `json:"height_percent,omitempty"` // Percent of the frame height, replaces Height
+	AutoHeight    bool    `json:"auto_height,omitempty"`    // Height follows the width with the image aspect ratio
+
+	// Text and clock layers
+	Text            string  `json:"text,omitempty"`              // Text layers: the text drawn
+	Format          string  `json:"format,omitempty"`            // Clock layers: strftime format (default %H:%M:%S)
+	Timezone        string  `json:"timezone,omitempty"`          // Clock layers: IANA name (default: node local time)
+	Font            string  `json:"font,omitempty"`              // Font file, relative to the logo directory or absolute (default: system sans font)
+	FontSize        int     `json:"font_size,omitempty"`         // Pixels (default 32)
+	FontSizePercent float64 `json:"font_size_percent,omitempty"` // Percent of the frame height, replaces FontSize
+	FontColor       string  `json:"font_color,omitempty"`        // FFmpeg colour, e.g. white or #ffcc00@0.8 (default white)
+	Box             bool    `json:"box,omitempty"`               // Draw a box behind the text
+	BoxColor        string  `json:"box_color,omitempty"`         // Default black@0.5
+	BoxBorder       int     `json:"box_border,omitempty"`        // Box padding in pixels (default 8)
+}
+
+// Normalized returns the layer with defaults applied
+func (l OverlayLayer) Normalized() OverlayLayer {
+	if l.Opacity == 0 {
+		l.Opacity = 1
+	}
+	if l.Type == OverlayText || l.Type == OverlayClock {
+		if l.FontSize == 0 {
+			l.FontSize = DefaultOverlayFontSize
+		}
+		if l.FontColor == "" {
+			l.FontColor = DefaultOverlayFontColor
+		}
+		if l.Box && l.BoxColor == "" {
+			l.BoxColor = DefaultOverlayBoxColor
+		}
+		if l.Box && l.BoxBorder == 0 {
+			l.BoxBorder = DefaultOverlayBoxBorder
+		}
+	}
+	if l.Type == OverlayClock && l.Format == "" {
+		l.Format = DefaultClockFormat
+	}
+	return l
+}
+
+// Validate checks one overlay layer
+func (l OverlayLayer) Validate() error {
+	if l.X < 0 || l.Y < 0 {
+		return fmt.Errorf("position must not be negative")
+	}
+	if l.Anchor != "" && !validAnchors[l.Anchor] {
+		return fmt.Errorf("invalid anchor %q (allowed: top-left, top-right, bottom-left, bottom-right, center)", l.Anchor)
+	}
+	if l.MarginX < 0 || l.MarginX > 50 || l.MarginY < 0 || l.MarginY > 50 {
+		return fmt.Errorf("margins must be between 0 and 50 percent")
+	}
+	if l.Opacity < 0 || l.Opacity > 1 {
+		return fmt.Errorf("opacity must be between 0 and 1, got %g", l.Opacity)
+	}
+
+	switch l.Type {
+	case OverlayImage:
+		if l.Path == "" {
+			return fmt.Errorf("image layers need a path")
+		}
+		if strings.Contains(l.Path, "..") {
+			return fmt.Errorf("invalid image path %q", l.Path)
+		}
+		if l.WidthPercent < 0 || l.WidthPercent > 100 || l.HeightPercent < 0 || l.HeightPercent > 100 {
+			return fmt.Errorf("width and height percent must be between 0 and 100")
+		}
+		if l.Width <= 0 && l.WidthPercent == 0 {
+			return fmt.Errorf("image layers need a positive width")
+		}
+		if l.Height <= 0 && l.HeightPercent == 0 && !l.AutoHeight {
+			return fmt.Errorf("image layers need a positive height or auto height")
+		}
+		return nil
+	case OverlayText:
+		if strings.TrimSpace(l.Text) == "" {
+			return fmt.Errorf("text layers need a text")
+		}
+		if len(l.Text) > 255 {
+			return fmt.Errorf("text must be at most 255 characters")
+		}
+	case OverlayClock:
+		if strings.ContainsAny(l.Format, "{}\\") {
+			return fmt.Errorf("invalid clock format %q", l.Format)
+		}
+		if l.Timezone != "" {
+			if _, err := time.LoadLocation(l.Timezone); err != nil {
+				return fmt.Errorf("unknown clock timezone %q", l.Timezone)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid layer type %q (allowed: image, text, clock)", l.Type)
+	}
+
+	if strings.Contains(l.Font, "..") {
+		return fmt.Errorf("invalid font path %q", l.Font)
+	}
+	if l.FontSize < 0 || l.FontSize > 500 {
+		return fmt.Errorf("font size must be between 1 and 500, got %d", l.FontSize)
+	}
+	if l.FontSizePercent < 0 || l.FontSizePercent > 50 {
+		return fmt.Errorf("font size percent must be between 0 and 50, got %g", l.FontSizePercent)
+	}
+	if l.BoxBorder < 0 || l.BoxBorder > 200 {
+		return fmt.Errorf("box border must be between 0 and 200, got %d", l.BoxBorder)
+	}
+	for _, color := range []string{l.FontColor, l.BoxColor} {
+		if color != "" && !overlayColorRegex.MatchString(color) {
+			return fmt.Errorf("invalid colour %q", color)
+		}
+	}
+	return nil
+}
+
+// ValidateOverlays checks the overlay layers of a channel
+// FFmpeg renders local time in a single zone per process, so all clock layers share one timezone.
+func ValidateOverlays(layers []OverlayLayer) error {
+	if len(layers) > MaxOverlayLayers {
+		return fmt.Errorf("at most %d overlay layers are allowed, got %d", MaxOverlayLayers, len(layers))
+	}
+	timezone := ""
+	for i, layer := range layers {
+		if err := layer.Validate(); err != nil {
+			return fmt.Errorf("overlay layer %d: %v", i, err)
+		}
+		if layer.Type != OverlayClock || layer.Disabled {
+			continue
+		}
+		if timezone != "" && layer.Timezone != timezone {
+			return fmt.Errorf("overlay layer %d: all clock layers must use the same timezone", i)
+		}
+		timezone = layer.Timezone
+	}
+	return nil
+}
+
+// ActiveOverlays returns the enabled layers in drawing order (lowest ZIndex first)
+func ActiveOverlays(layers []OverlayLayer) []OverlayLayer {
+	active := make([]OverlayLayer, 0, len(layers))
+	for _, layer := range layers {
+		if !layer.Disabled {
+			active = append(active, layer.Normalized())
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].ZIndex < active[j].ZIndex
+	})
+	return active
+}
+
+// ClockTimezone returns the timezone of the enabled clock layers ("" = node local time)
+func ClockTimezone(layers []OverlayLayer) string {
+	for _, layer := range layers {
+		if layer.Type == OverlayClock && !layer.Disabled {
+			return layer.Timezone
+		}
+	}
+	return ""
+}
+
+// ImageSize returns the pixel size of an image layer in a frame
+// A height of -1 keeps the aspect ratio of the image.
+func (l OverlayLayer) ImageSize(frameWidth, frameHeight int) (int, int) {
+	width, height := l.Width, l.Height
+	if l.WidthPercent > 0 {
+		width = percentOf(frameWidth, l.WidthPercent)
+	}
+	if l.HeightPercent > 0 {
+		height = percentOf(frameHeight, l.HeightPercent)
+	}
+	if l.AutoHeight {
+		height = -1
+	}
+	return width, height
+}
+
+// TextSize returns the font size of a text or clock layer in pixels for a frame height
+func (l OverlayLayer) TextSize(frameHeight int) int {
+	if l.FontSizePercent > 0 {
+		return percentOf(frameHeight, l.FontSizePercent)
+	}
+	return l.FontSize
+}
+
+// Margins returns the margins of an anchored layer in pixels for a frame
+func (l OverlayLayer) Margins(frameWidth, frameHeight int) (int, int) {
+	return percentOf(frameWidth, l.MarginX), percentOf(frameHeight, l.MarginY)
+}
+
+// percentOf returns percent of size in whole pixels (at least 1 for a positive percent)
+func percentOf(size int, percent float64) int {
+	if percent <= 0 {
+		return 0
+	}
+	return max(1, int(math.Round(float64(size)*percent/100)))
+}
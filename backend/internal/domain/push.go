@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PushProtocol is the transport of a push destination
+type PushProtocol string
+
+const (
+	PushProtocolRTMP  PushProtocol = "rtmp"
+	PushProtocolRTMPS PushProtocol = "rtmps"
+	PushProtocolSRT   PushProtocol = "srt" // Caller mode, MPEG-TS payload
+	PushProtocolUDP   PushProtocol = "udp" // MPEG-TS over UDP (unicast or multicast)
+	PushProtocolRTP   PushProtocol = "rtp" // MPEG-TS over RTP (unicast or multicast)
+)
+
+// MaxPushDestinations is the number of push destinations allowed per channel
+const MaxPushDestinations = 8
+
+var pushNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// PushDestination is an external endpoint the encoded channel output is pushed to
+type PushDestination struct {
+	Name     string `json:"name"`               // Unique per channel, used in status and logs
+	URL      string `json:"url"`                // rtmp(s)://, srt://, udp:// or rtp:// URL
+	Disabled bool   `json:"disabled,omitempty"` // Keep the destination configured without pushing
+}
+
+// Protocol returns the transport of the destination URL
+func (d PushDestination) Protocol() (PushProtocol, error) {
+	parsed, err := url.Parse(d.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid push URL for %s: %w", d.Name, err)
+	}
+	protocol := PushProtocol(strings.ToLower(parsed.Scheme))
+	switch protocol {
+	case PushProtocolRTMP, PushProtocolRTMPS, PushProtocolSRT, PushProtocolUDP, PushProtocolRTP:
+	default:
+		return "", fmt.Errorf("unsupported push protocol %q for %s (allowed: rtmp, rtmps, srt, udp, rtp)", parsed.Scheme, d.Name)
+	}
+	if parsed.Hostname() == "" {
+		return "", fmt.Errorf("push URL for %s has no host", d.Name)
+	}
+	if protocol != PushProtocolRTMP && protocol != PushProtocolRTMPS && parsed.Port() == "" {
+		return "", fmt.Errorf("push URL for %s has no port", d.Name)
+	}
+	if protocol == PushProtocolSRT {
+		if mode := parsed.Query().Get("mode"); mode != "" && mode != "caller" {
+			return "", fmt.Errorf("SRT destination %s must use caller mode, not %s", d.Name, mode)
+		}
+	}
+	return protocol, nil
+}
+
+// ValidatePushDestinations checks the push destinations of a channel
+func ValidatePushDestinations(destinations []PushDestination) error {
+	if len(destinations) > MaxPushDestinations {
+		return fmt.Errorf("at most %d push destinations are allowed, got %d", MaxPushDestinations, len(destinations))
+	}
+	names := make(map[string]bool, len(destinations))
+	for i, destination := range destinations {
+		if !pushNameRegex.MatchString(destination.Name) {
+			return fmt.Errorf("invalid push destination name %q at %d (letters, digits, - and _)", destination.Name, i)
+		}
+		if names[destination.Name] {
+			return fmt.Errorf("duplicate push destination name %q", destination.Name)
+		}
+		names[destination.Name] = true
+		if _, err := destination.Protocol(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PushState is the health of a push destination
+type PushState string
+
+const (
+	PushStateWaiting    PushState = "waiting"    // Waiting for the channel output
+	PushStateConnecting PushState = "connecting" // Relay started, nothing sent yet
+	PushStateRunning    PushState = "running"    // Sending
+	PushStateRetrying   PushState = "retrying"   // Failed, retry scheduled
+)
+
+// PushStatus reports the health of one push destination of a running channel
+type PushStatus struct {
+	Name        string       `json:"name"`
+	Protocol    PushProtocol `json:"protocol"`
+	State       PushState    `json:"state"`
+	Bitrate     int          `json:"bitrate"`              // Sent bitrate in kbps
+	BytesSent   int64        `json:"bytes_sent"`           // Bytes sent by the current relay run
+	Since       time.Time    `json:"since"`                // Time of the last state change
+	Failures    int          `json:"failures"`             // Failed relay runs since the channel started
+	LastError   string       `json:"last_error,omitempty"` // Last error reported by the relay
+	NextRetryAt *time.Time   `json:"next_retry_at,omitempty"`
+}
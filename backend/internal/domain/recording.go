@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxRecordingDuration is the longest recording a job may capture
+const MaxRecordingDuration = 24 * time.Hour
+
+// RecordingStatus represents the state of a recording job
+type RecordingStatus string
+
+const (
+	RecordingStatusScheduled RecordingStatus = "scheduled" // Waiting for its start time
+	RecordingStatusRecording RecordingStatus = "recording"
+	RecordingStatusCompleted RecordingStatus = "completed"
+	RecordingStatusFailed    RecordingStatus = "failed"    // Partial archives stay downloadable
+	RecordingStatusCancelled RecordingStatus = "cancelled" // Stopped by an operator (a started archive is kept)
+)
+
+// IsFinished reports whether the job will not change anymore
+func (s RecordingStatus) IsFinished() bool {
+	return s == RecordingStatusCompleted || s == RecordingStatusFailed || s == RecordingStatusCancelled
+}
+
+// Recording is a job copying the live output of a channel into an MP4 archive
+type Recording struct {
+	ID        uuid.UUID       `json:"id"`
+	ChannelID uuid.UUID       `json:"channel_id"`
+	Name      string          `json:"name"`
+	Status    RecordingStatus `json:"status"`
+	StartAt   time.Time       `json:"start_at"` // Planned start
+	Duration  int             `json:"duration"` // Planned length in seconds
+	StartedAt *time.Time      `json:"started_at,omitempty"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+	FilePath  string          `json:"-"` // Archive file under the archive path
+	SizeBytes int64           `json:"size_bytes"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// HasArchive reports whether the job produced a downloadable file
+func (r *Recording) HasArchive() bool {
+	return r.FilePath != "" && r.SizeBytes > 0 && r.Status.IsFinished()
+}
+
+// RecordingRepository defines the interface for recording job persistence
+type RecordingRepository interface {
+	Create(recording *Recording) error
+	GetByID(id uuid.UUID) (*Recording, error)
+	List(channelID *uuid.UUID) ([]*Recording, error)
+	ListByStatus(status RecordingStatus) ([]*Recording, error)
+	ListFinishedBefore(cutoff time.Time) ([]*Recording, error)
+	Update(recording *Recording) error
+	Delete(id uuid.UUID) error
+}
+
+// Recorder copies the live output of a running channel into a file
+type Recorder interface {
+	// Record blocks until the duration is captured, ctx is cancelled or the channel stops
+	Record(ctx context.Context, channelID uuid.UUID, path string, duration time.Duration) error
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// GiveUpAction is what happens when a channel exhausts its restart attempts
+type GiveUpAction string
+
+const (
+	GiveUpError    GiveUpAction = "error"    // Mark the channel as error and stop retrying
+	GiveUpStop     GiveUpAction = "stop"     // Mark the channel as stopped and stop retrying
+	GiveUpCooldown GiveUpAction = "cooldown" // Wait CooldownSeconds, then retry with a fresh window
+)
+
+// RestartPolicy controls how a crashed channel is restarted when AutoRestart is enabled
+// Zero values fall back to the defaults (see Normalized); a negative MaxAttempts allows unlimited restarts.
+type RestartPolicy struct {
+	MaxAttempts           int          `json:"max_attempts"`            // Restarts allowed inside the window
+	WindowSeconds         int          `json:"window_seconds"`          // Sliding window for counting restarts
+	InitialBackoffSeconds int          `json:"initial_backoff_seconds"` // Delay before the first restart
+	MaxBackoffSeconds     int          `json:"max_backoff_seconds"`     // Upper bound of the backoff delay
+	BackoffMultiplier     float64      `json:"backoff_multiplier"`      // Delay growth per consecutive failure
+	Jitter                float64      `json:"jitter"`                  // Random +/- fraction applied to the delay (0-1)
+	MinUptimeSeconds      int          `json:"min_uptime_seconds"`      // Runs at least this long reset the backoff
+	GiveUp                GiveUpAction `json:"give_up"`                 // error, stop or cooldown
+	CooldownSeconds       int          `json:"cooldown_seconds"`        // Pause before retrying when GiveUp is cooldown
+}
+
+// DefaultRestartPolicy returns the policy used by channels without one
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		MaxAttempts:           10,
+		WindowSeconds:         600,
+		InitialBackoffSeconds: 2,
+		MaxBackoffSeconds:     60,
+		BackoffMultiplier:     2,
+		Jitter:                0.2,
+		MinUptimeSeconds:      10,
+		GiveUp:                GiveUpError,
+		CooldownSeconds:       300,
+	}
+}
+
+// Normalized returns the policy with unset fields filled from the defaults (nil returns the defaults)
+func (p *RestartPolicy) Normalized() RestartPolicy {
+	defaults := DefaultRestartPolicy()
+	if p == nil {
+		return defaults
+	}
+
+	policy := *p
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.WindowSeconds == 0 {
+		policy.WindowSeconds = defaults.WindowSeconds
+	}
+	if policy.InitialBackoffSeconds == 0 {
+		policy.InitialBackoffSeconds = defaults.InitialBackoffSeconds
+	}
+	if policy.MaxBackoffSeconds == 0 {
+		policy.MaxBackoffSeconds = defaults.MaxBackoffSeconds
+	}
+	if policy.BackoffMultiplier == 0 {
+		policy.BackoffMultiplier = defaults.BackoffMultiplier
+	}
+	if policy.MinUptimeSeconds == 0 {
+		policy.MinUptimeSeconds = defaults.MinUptimeSeconds
+	}
+	if policy.GiveUp == "" {
+		policy.GiveUp = defaults.GiveUp
+	}
+	if policy.CooldownSeconds == 0 {
+		policy.CooldownSeconds = defaults.CooldownSeconds
+	}
+	return policy
+}
+
+// Validate checks the policy values
+func (p *RestartPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.WindowSeconds < 0 || p.InitialBackoffSeconds < 0 || p.MaxBackoffSeconds < 0 || p.MinUptimeSeconds < 0 || p.CooldownSeconds < 0 {
+		return fmt.Errorf("restart policy durations must not be negative")
+	}
+	if p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1 {
+		return fmt.Errorf("restart policy backoff_multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("restart policy jitter must be between 0 and 1")
+	}
+	switch p.GiveUp {
+	case "", GiveUpError, GiveUpStop, GiveUpCooldown:
+	default:
+		return fmt.Errorf("unsupported restart policy give_up: %s (allowed: error, stop, cooldown)", p.GiveUp)
+	}
+	normalized := p.Normalized()
+	if normalized.MaxBackoffSeconds < normalized.InitialBackoffSeconds {
+		return fmt.Errorf("restart policy max_backoff_seconds must not be lower than initial_backoff_seconds")
+	}
+	return nil
+}
+
+// Backoff returns the delay before the next restart after the given number of consecutive failures
+// The first failure waits the initial backoff, each further one multiplies it. random is a value
+// in [0, 1) used for jitter.
+func (p RestartPolicy) Backoff(failures int, random float64) time.Duration {
+	delay := float64(p.InitialBackoffSeconds)
+	for i := 1; i < failures && delay < float64(p.MaxBackoffSeconds); i++ {
+		delay *= p.BackoffMultiplier
+	}
+	if delay > float64(p.MaxBackoffSeconds) {
+		delay = float64(p.MaxBackoffSeconds)
+	}
+	delay *= 1 + p.Jitter*(2*random-1)
+	return time.Duration(delay * float64(time.Second))
+}
+
+// RestartStatus reports the auto-restart state of a channel
+type RestartStatus struct {
+	Attempts            int        `json:"attempts"`             // Restarts inside the current policy window
+	TotalRestarts       int        `json:"total_restarts"`       // Restarts since the channel was last started manually
+	ConsecutiveFailures int        `json:"consecutive_failures"` // Runs in a row shorter than the policy min uptime
+	NextRetryAt         *time.Time `json:"next_retry_at,omitempty"`
+	GaveUp              bool       `json:"gave_up"`
+	LastExitAt          *time.Time `json:"last_exit_at,omitempty"`
+	LastExitError       string     `json:"last_exit_error,omitempty"`
+}
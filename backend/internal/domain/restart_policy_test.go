@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := RestartPolicy{InitialBackoffSeconds: 2, BackoffMultiplier: 2, MaxBackoffSeconds: 30, Jitter: 0.5}
+
+	tests := []struct {
+		name     string
+		failures int
+		random   float64
+		want     time.Duration
+	}{
+		{name: "clean exit", failures: 0, random: 0.5, want: 2 * time.Second},
+		{name: "first failure waits the initial backoff", failures: 1, random: 0.5, want: 2 * time.Second},
+		{name: "second failure", failures: 2, random: 0.5, want: 4 * time.Second},
+		{name: "third failure", failures: 3, random: 0.5, want: 8 * time.Second},
+		{name: "capped at max backoff", failures: 10, random: 0.5, want: 30 * time.Second},
+		{name: "lowest jitter", failures: 1, random: 0, want: time.Second},
+		{name: "jitter on the cap", failures: 10, random: 0.75, want: 37500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Backoff(tt.failures, tt.random); got != tt.want {
+				t.Errorf("Backoff(%d, %v) = %v, want %v", tt.failures, tt.random, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxScheduleWindow is the longest window a recurring schedule may keep a channel on air
+const MaxScheduleWindow = 7 * 24 * time.Hour
+
+// ScheduleType represents how a schedule defines its windows
+type ScheduleType string
+
+const (
+	ScheduleTypeOnce      ScheduleType = "once"      // One window between StartAt and EndAt
+	ScheduleTypeRecurring ScheduleType = "recurring" // A window of Duration minutes at every Cron run
+)
+
+// ScheduleAction is what a schedule does to its channel
+type ScheduleAction string
+
+const (
+	ScheduleActionStart ScheduleAction = "start"
+	ScheduleActionStop  ScheduleAction = "stop"
+)
+
+// Schedule starts and stops a channel on one-off or recurring windows
+// NextAction/NextRunAt are the persisted plan of the scheduler: a start due in the past
+// runs as soon as the backend sees it, as long as its window has not ended.
+type Schedule struct {
+	ID          uuid.UUID      `json:"id"`
+	ChannelID   uuid.UUID      `json:"channel_id"`
+	Name        string         `json:"name"`
+	Type        ScheduleType   `json:"type"`
+	Timezone    string         `json:"timezone"`           // IANA name the cron rule is evaluated in
+	StartAt     *time.Time     `json:"start_at,omitempty"` // Once: window start
+	EndAt       *time.Time     `json:"end_at,omitempty"`   // Once: window end (nil = the channel keeps running)
+	Cron        string         `json:"cron,omitempty"`     // Recurring: window start times (5-field cron)
+	Duration    int            `json:"duration,omitempty"` // Recurring: window length in minutes
+	Enabled     bool           `json:"enabled"`
+	NextAction  ScheduleAction `json:"next_action,omitempty"`
+	NextRunAt   *time.Time     `json:"next_run_at,omitempty"`
+	WindowEnd   *time.Time     `json:"window_end,omitempty"` // End of the window the next action belongs to
+	LastAction  ScheduleAction `json:"last_action,omitempty"`
+	LastRunAt   *time.Time     `json:"last_run_at,omitempty"`
+	LastError   string         `json:"last_error,omitempty"` // Last failed or missed start, cleared by the next successful start
+	LastErrorAt *time.Time     `json:"last_error_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ScheduledAction is one planned start or stop of a channel
+type ScheduledAction struct {
+	ScheduleID   uuid.UUID      `json:"schedule_id"`
+	ScheduleName string         `json:"schedule_name"`
+	ChannelID    uuid.UUID      `json:"channel_id"`
+	Action       ScheduleAction `json:"action"`
+	At           time.Time      `json:"at"`
+}
+
+// Validate checks the schedule definition
+func (s *Schedule) Validate() error {
+	if s.Name == "" || len(s.Name) > 255 {
+		return fmt.Errorf("name must be between 1 and 255 characters")
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+
+	switch s.Type {
+	case ScheduleTypeOnce:
+		if s.StartAt == nil {
+			return fmt.Errorf("start_at is required for once schedules")
+		}
+		if s.EndAt != nil && !s.EndAt.After(*s.StartAt) {
+			return fmt.Errorf("end_at must be after start_at")
+		}
+		if s.Cron != "" || s.Duration != 0 {
+			return fmt.Errorf("cron and duration are only used by recurring schedules")
+		}
+	case ScheduleTypeRecurring:
+		if _, err := ParseCron(s.Cron); err != nil {
+			return err
+		}
+		if s.Duration <= 0 || time.Duration(s.Duration)*time.Minute > MaxScheduleWindow {
+			return fmt.Errorf("duration must be between 1 and %d minutes", int(MaxScheduleWindow.Minutes()))
+		}
+		if s.StartAt != nil || s.EndAt != nil {
+			return fmt.Errorf("start_at and end_at are only used by once schedules")
+		}
+	default:
+		return fmt.Errorf("type must be once or recurring")
+	}
+	return nil
+}
+
+// Location returns the time zone of the schedule (UTC when unknown)
+func (s *Schedule) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Plan sets the next action to the start of the first window that has not ended at now
+// A window already open at now is due immediately. Disabled and finished schedules have no next action.
+func (s *Schedule) Plan(now time.Time) {
+	s.NextAction, s.NextRunAt, s.WindowEnd = "", nil, nil
+	if !s.Enabled {
+		return
+	}
+
+	switch s.Type {
+	case ScheduleTypeOnce:
+		if s.StartAt == nil || (s.EndAt != nil && !s.EndAt.After(now)) {
+			return
+		}
+		start := *s.StartAt
+		s.NextAction, s.NextRunAt, s.WindowEnd = ScheduleActionStart, &start, s.EndAt
+	case ScheduleTypeRecurring:
+		cron, err := ParseCron(s.Cron)
+		if err != nil {
+			return
+		}
+		window := time.Duration(s.Duration) * time.Minute
+		start := cron.Next(now.In(s.Location()).Add(-window))
+		if start.IsZero() {
+			return
+		}
+		end := start.Add(window)
+		s.NextAction, s.NextRunAt, s.WindowEnd = ScheduleActionStart, &start, &end
+	}
+}
+
+// Advance moves the plan past an action that ran (or was given up) at now
+func (s *Schedule) Advance(now time.Time) {
+	if s.NextAction == ScheduleActionStart && s.WindowEnd != nil {
+		end := *s.WindowEnd
+		s.NextAction, s.NextRunAt = ScheduleActionStop, &end
+		return
+	}
+	if s.NextAction == ScheduleActionStart || s.Type == ScheduleTypeOnce {
+		// Open-ended and one-off schedules are done after their window
+		s.NextAction, s.NextRunAt, s.WindowEnd = "", nil, nil
+		return
+	}
+	s.Plan(now)
+}
+
+// Upcoming returns the planned actions of the schedule up to until (at most limit)
+func (s *Schedule) Upcoming(until time.Time, limit int) []ScheduledAction {
+	plan := *s
+	var actions []ScheduledAction
+	for len(actions) < limit && plan.NextRunAt != nil && !plan.NextRunAt.After(until) {
+		at := *plan.NextRunAt
+		actions = append(actions, ScheduledAction{
+			ScheduleID:   s.ID,
+			ScheduleName: s.Name,
+			ChannelID:    s.ChannelID,
+			Action:       plan.NextAction,
+			At:           at,
+		})
+		plan.Advance(at)
+	}
+	return actions
+}
+
+// ScheduleRepository defines the interface for schedule persistence
+type ScheduleRepository interface {
+	Create(schedule *Schedule) error
+	GetByID(id uuid.UUID) (*Schedule, error)
+	List(channelID *uuid.UUID) ([]*Schedule, error)
+	ListDue(now time.Time) ([]*Schedule, error)
+	Update(schedule *Schedule) error
+	Delete(id uuid.UUID) error
+}
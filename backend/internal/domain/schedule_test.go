@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulePlan(t *testing.T) {
+	istanbul := mustLoadLocation(t, "Europe/Istanbul")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, istanbul)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	recurring := Schedule{Type: ScheduleTypeRecurring, Timezone: "Europe/Istanbul", Cron: "0 18 * * *", Duration: 120, Enabled: true}
+
+	tests := []struct {
+		name       string
+		schedule   Schedule
+		now        time.Time
+		wantStart  *time.Time
+		wantWindow *time.Time
+	}{
+		{name: "recurring before the window", schedule: recurring, now: at(16, 17, 0), wantStart: ptr(at(16, 18, 0)), wantWindow: ptr(at(16, 20, 0))},
+		{name: "recurring inside the window is due", schedule: recurring, now: at(16, 19, 0), wantStart: ptr(at(16, 18, 0)), wantWindow: ptr(at(16, 20, 0))},
+		{name: "recurring after the window", schedule: recurring, now: at(16, 20, 0), wantStart: ptr(at(17, 18, 0)), wantWindow: ptr(at(17, 20, 0))},
+		{
+			name:       "once ahead",
+			schedule:   Schedule{Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: ptr(at(20, 9, 0)), EndAt: ptr(at(20, 12, 0)), Enabled: true},
+			now:        at(16, 12, 0),
+			wantStart:  ptr(at(20, 9, 0)),
+			wantWindow: ptr(at(20, 12, 0)),
+		},
+		{
+			name:     "once ended",
+			schedule: Schedule{Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: ptr(at(10, 9, 0)), EndAt: ptr(at(10, 12, 0)), Enabled: true},
+			now:      at(16, 12, 0),
+		},
+		{
+			name:      "once open ended",
+			schedule:  Schedule{Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: ptr(at(10, 9, 0)), Enabled: true},
+			now:       at(16, 12, 0),
+			wantStart: ptr(at(10, 9, 0)),
+		},
+		{
+			name: "disabled",
+			schedule: func() Schedule {
+				s := recurring
+				s.Enabled = false
+				return s
+			}(),
+			now: at(16, 17, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := tt.schedule
+			schedule.Plan(tt.now)
+			if !equalTimes(schedule.NextRunAt, tt.wantStart) {
+				t.Fatalf("NextRunAt = %v, want %v", schedule.NextRunAt, tt.wantStart)
+			}
+			if !equalTimes(schedule.WindowEnd, tt.wantWindow) {
+				t.Fatalf("WindowEnd = %v, want %v", schedule.WindowEnd, tt.wantWindow)
+			}
+			if tt.wantStart != nil && schedule.NextAction != ScheduleActionStart {
+				t.Fatalf("NextAction = %q, want start", schedule.NextAction)
+			}
+			if tt.wantStart == nil && schedule.NextAction != "" {
+				t.Fatalf("NextAction = %q, want none", schedule.NextAction)
+			}
+		})
+	}
+}
+
+func TestScheduleUpcoming(t *testing.T) {
+	istanbul := mustLoadLocation(t, "Europe/Istanbul")
+	now := time.Date(2026, 10, 16, 19, 0, 0, 0, istanbul)
+	schedule := Schedule{Type: ScheduleTypeRecurring, Timezone: "Europe/Istanbul", Cron: "0 18 * * *", Duration: 120, Enabled: true}
+	schedule.Plan(now)
+
+	got := schedule.Upcoming(now.Add(48*time.Hour), 10)
+	want := []struct {
+		action ScheduleAction
+		at     time.Time
+	}{
+		{ScheduleActionStart, time.Date(2026, 10, 16, 18, 0, 0, 0, istanbul)},
+		{ScheduleActionStop, time.Date(2026, 10, 16, 20, 0, 0, 0, istanbul)},
+		{ScheduleActionStart, time.Date(2026, 10, 17, 18, 0, 0, 0, istanbul)},
+		{ScheduleActionStop, time.Date(2026, 10, 17, 20, 0, 0, 0, istanbul)},
+		{ScheduleActionStart, time.Date(2026, 10, 18, 18, 0, 0, 0, istanbul)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d actions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Action != want[i].action || !got[i].At.Equal(want[i].at) {
+			t.Errorf("action %d = %s at %v, want %s at %v", i, got[i].Action, got[i].At, want[i].action, want[i].at)
+		}
+	}
+	if !schedule.NextRunAt.Equal(want[0].at) {
+		t.Errorf("Upcoming changed the plan: NextRunAt = %v", schedule.NextRunAt)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	start := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{name: "valid recurring", schedule: Schedule{Name: "news", Type: ScheduleTypeRecurring, Timezone: "Europe/Istanbul", Cron: "0 18 * * 1-5", Duration: 60}},
+		{name: "valid once", schedule: Schedule{Name: "match", Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: &start}},
+		{name: "missing name", schedule: Schedule{Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: &start}, wantErr: true},
+		{name: "unknown timezone", schedule: Schedule{Name: "x", Type: ScheduleTypeOnce, Timezone: "Mars/Olympus", StartAt: &start}, wantErr: true},
+		{name: "once without start", schedule: Schedule{Name: "x", Type: ScheduleTypeOnce, Timezone: "UTC"}, wantErr: true},
+		{name: "once ending before start", schedule: Schedule{Name: "x", Type: ScheduleTypeOnce, Timezone: "UTC", StartAt: &start, EndAt: &before}, wantErr: true},
+		{name: "recurring bad cron", schedule: Schedule{Name: "x", Type: ScheduleTypeRecurring, Timezone: "UTC", Cron: "0 25 * * *", Duration: 60}, wantErr: true},
+		{name: "recurring without duration", schedule: Schedule{Name: "x", Type: ScheduleTypeRecurring, Timezone: "UTC", Cron: "@daily"}, wantErr: true},
+		{name: "unknown type", schedule: Schedule{Name: "x", Type: "weekly", Timezone: "UTC"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SlateAudio is the audio played while the slate is on air
+type SlateAudio string
+
+const (
+	SlateAudioSilence SlateAudio = "silence" // Generated silence (default)
+	SlateAudioTone    SlateAudio = "tone"    // Generated sine tone
+	SlateAudioClip    SlateAudio = "clip"    // The first audio track of a slate clip
+)
+
+// DefaultSlateToneFrequency is the tone frequency in Hz when none is configured
+const DefaultSlateToneFrequency = 1000
+
+// slateImageExtensions are the still image formats; any other file is looped as a clip
+var slateImageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".bmp": true, ".webp": true}
+
+// SlateConfig replaces the output with a still image or looping clip while the source is down
+// Channels with a slate are restarted automatically, the slate stays on air until the source
+// answers a probe again.
+type SlateConfig struct {
+	Enabled       bool       `json:"enabled"`
+	Path          string     `json:"path"`                     // Image or clip, relative to the logo directory or absolute
+	Audio         SlateAudio `json:"audio,omitempty"`          // silence (default), tone or clip
+	ToneFrequency int        `json:"tone_frequency,omitempty"` // Hz, default 1000
+}
+
+// IsImage reports whether the slate is a still image (looped as a single frame)
+func (s *SlateConfig) IsImage() bool {
+	return slateImageExtensions[strings.ToLower(filepath.Ext(s.Path))]
+}
+
+// Normalized returns the configuration with defaults applied
+func (s *SlateConfig) Normalized() SlateConfig {
+	if s == nil {
+		return SlateConfig{}
+	}
+	config := *s
+	if config.Audio == "" {
+		config.Audio = SlateAudioSilence
+	}
+	if config.ToneFrequency == 0 {
+		config.ToneFrequency = DefaultSlateToneFrequency
+	}
+	return config
+}
+
+// Validate checks the slate configuration
+func (s *SlateConfig) Validate() error {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	if s.Path == "" {
+		return fmt.Errorf("slate path is required")
+	}
+	if strings.Contains(s.Path, "..") {
+		return fmt.Errorf("invalid slate path %q", s.Path)
+	}
+	switch s.Audio {
+	case "", SlateAudioSilence, SlateAudioTone:
+	case SlateAudioClip:
+		if s.IsImage() {
+			return fmt.Errorf("slate audio clip needs a video clip, %q is an image", s.Path)
+		}
+	default:
+		return fmt.Errorf("invalid slate audio %q (allowed: silence, tone, clip)", s.Audio)
+	}
+	if s.ToneFrequency != 0 && (s.ToneFrequency < 20 || s.ToneFrequency > 20000) {
+		return fmt.Errorf("slate tone frequency must be between 20 and 20000 Hz, got %d", s.ToneFrequency)
+	}
+	return nil
+}
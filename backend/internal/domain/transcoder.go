@@ -8,21 +8,27 @@ import (
 
 // TranscoderProcess represents an active FFmpeg process
 type TranscoderProcess struct {
-	ChannelID     uuid.UUID `json:"channel_id"`
-	PID           int       `json:"pid"`
-	StartedAt     time.Time `json:"started_at"`
-	CPUUsage      float64   `json:"cpu_usage"`
-	MemoryUsage   int64     `json:"memory_usage"`
-	InputBitrate  int       `json:"input_bitrate"`
-	OutputBitrate int       `json:"output_bitrate"`
-	DroppedFrames int       `json:"dropped_frames"`
-	FPS           float64   `json:"fps"`
-	Speed         float64   `json:"speed"`
-	LastError     string    `json:"last_error,omitempty"`
-	Uptime        int64     `json:"uptime"`
+	ChannelID     uuid.UUID       `json:"channel_id"`
+	PID           int             `json:"pid"`
+	StartedAt     time.Time       `json:"started_at"`
+	CPUUsage      float64         `json:"cpu_usage"`
+	MemoryUsage   int64           `json:"memory_usage"`
+	InputBitrate  int             `json:"input_bitrate"`
+	OutputBitrate int             `json:"output_bitrate"`
+	DroppedFrames int             `json:"dropped_frames"`
+	FPS           float64         `json:"fps"`
+	Speed         float64         `json:"speed"`
+	LastError     string          `json:"last_error,omitempty"`
+	Uptime        int64           `json:"uptime"`
+	Metrics       *ProcessMetrics `json:"metrics,omitempty"`  // Latest complete -progress block
+	Restarts      *RestartStatus  `json:"restarts,omitempty"` // Auto-restart counters (PID is 0 while a retry is pending)
+	SourceURL     string          `json:"source_url,omitempty"`
+	SourceIndex   int             `json:"source_index"` // 0 = primary source, 1+ = backup sources
+	Push          []PushStatus    `json:"push,omitempty"` // Health of the push destinations
+	SlateSince    *time.Time      `json:"slate_since,omitempty"` // Set while the slate replaces the source
 }
 
-// ProcessMetrics holds real-time metrics from FFmpeg
+// ProcessMetrics holds real-time metrics from FFmpeg (one -progress block)
 type ProcessMetrics struct {
 	Frame         int64   `json:"frame"`
 	FPS           float64 `json:"fps"`
@@ -53,11 +59,13 @@ type SystemMetrics struct {
 
 // StreamInfo holds HLS stream information
 type StreamInfo struct {
-	ChannelID       uuid.UUID `json:"channel_id"`
-	PlaylistURL     string    `json:"playlist_url"`
-	SegmentCount    int       `json:"segment_count"`
-	LastSegmentTime time.Time `json:"last_segment_time"`
-	Viewers         int       `json:"viewers"`
+	ChannelID       uuid.UUID  `json:"channel_id"`
+	PlaylistURL     string     `json:"playlist_url"`
+	SegmentCount    int        `json:"segment_count"`
+	LastSegmentTime time.Time  `json:"last_segment_time"`
+	Viewers         int        `json:"viewers"`
+	PeakViewers     int        `json:"peak_viewers"`
+	PeakAt          *time.Time `json:"peak_at,omitempty"`
 }
 
 // GPUInfo holds information about a single GPU
@@ -86,6 +94,33 @@ type SystemInfo struct {
 	GPUs            []GPUInfo `json:"gpus"`              // GPU information
 }
 
+// Value sources reported for effective encoding values
+const (
+	ValueSourceDefault  = "default"  // Built-in default
+	ValueSourceConfig   = "config"   // Config file (config.yaml / environment)
+	ValueSourceSettings = "settings" // settings table
+	ValueSourceChannel  = "channel"  // Channel output_config
+	ValueSourceDetected = "detected" // Hardware detection / load balancing
+)
+
+// EffectiveValue is a resolved encoding value and where it came from
+type EffectiveValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// CommandPreview is the FFmpeg command that would be executed for a channel (dry-run)
+type CommandPreview struct {
+	ChannelID      uuid.UUID                 `json:"channel_id"`
+	Argv           []string                  `json:"argv"`    // Full argv including numactl wrapper
+	Command        string                    `json:"command"` // Shell quoted argv
+	EncoderBackend string                    `json:"encoder_backend"`
+	Device         int                       `json:"device"`
+	NUMANode       *int                      `json:"numa_node,omitempty"`
+	Values         map[string]EffectiveValue `json:"values"`
+	SourceProbed   bool                      `json:"source_probed"` // False until a start probed the source, track mapping is then unresolved
+}
+
 // TranscoderManager defines the interface for transcoder operations
 type TranscoderManager interface {
 	Start(channel *Channel) error
@@ -95,5 +130,7 @@ type TranscoderManager interface {
 	GetAllProcesses() ([]*TranscoderProcess, error)
 	IsRunning(channelID uuid.UUID) bool
 	GetLogs(channelID uuid.UUID) ([]string, error)
+	PreviewCommand(channel *Channel) (*CommandPreview, error)
+	ActiveSource(channelID uuid.UUID) (string, bool)
 }
 
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ViewerStats holds the concurrent viewers of a channel
+type ViewerStats struct {
+	ChannelID   uuid.UUID  `json:"channel_id"`
+	Viewers     int        `json:"viewers"`           // Sessions active within the idle timeout
+	PeakViewers int        `json:"peak_viewers"`      // Highest concurrent count since the backend started
+	PeakAt      *time.Time `json:"peak_at,omitempty"` // When the peak was reached
+}
+
+// ViewerSample is the number of concurrent viewers of a channel at a point in time
+type ViewerSample struct {
+	ChannelID uuid.UUID `json:"-"`
+	Viewers   int       `json:"viewers"`
+	SampledAt time.Time `json:"sampled_at"`
+}
+
+// ViewerHistoryRepository defines the interface for viewer count history persistence
+type ViewerHistoryRepository interface {
+	InsertBatch(samples []*ViewerSample) error
+	List(channelID uuid.UUID, since, until time.Time) ([]*ViewerSample, error)
+	DeleteOlderThan(cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,246 @@
+package dash
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+)
+
+const (
+	// ManifestName is the URL name of the DASH manifest in the channel directory
+	ManifestName = "manifest.mpd"
+
+	// layoutName is the file describing the representations of a CMAF channel
+	// The MPD itself is rendered per request: its availability start time is anchored on the
+	// program date time FFmpeg writes into the HLS playlists of the same segments.
+	layoutName = ".dash"
+
+	timescale = 1000
+)
+
+// Representation is one CMAF track published in the manifest (an HLS variant directory)
+type Representation struct {
+	ID         string `json:"id"`
+	Init       string `json:"init"` // Init segment name in the variant directory (FFmpeg numbers it per variant)
+	Codecs     string `json:"codecs,omitempty"`
+	Bandwidth  int    `json:"bandwidth"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Language   string `json:"language,omitempty"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	Channels   int    `json:"channels,omitempty"`
+	Default    bool   `json:"default,omitempty"`
+}
+
+// Layout describes the DASH presentation of a CMAF channel
+type Layout struct {
+	SegmentDuration int              `json:"segment_duration"` // Seconds, every segment starts on a forced keyframe
+	WindowSegments  int              `json:"window_segments"`  // Segments kept on disk (time shift buffer)
+	Video           []Representation `json:"video"`
+	Audio           []Representation `json:"audio,omitempty"`
+	ClosedCaptions  string           `json:"closed_captions,omitempty"` // CEA-608 accessibility value (e.g. CC1=eng)
+}
+
+// WriteLayout marks dir as CMAF output published as DASH
+func WriteLayout(dir string, layout Layout) error {
+	data, err := json.Marshal(layout)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, layoutName), data, 0644)
+}
+
+// RemoveLayout drops the DASH layout of dir (the channel no longer publishes DASH)
+func RemoveLayout(dir string) {
+	os.Remove(filepath.Join(dir, layoutName))
+}
+
+// ReadLayout returns the DASH layout of dir
+func ReadLayout(dir string) (*Layout, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, layoutName))
+	if err != nil {
+		return nil, false
+	}
+	var layout Layout
+	if err := json.Unmarshal(data, &layout); err != nil || len(layout.Video) == 0 || layout.SegmentDuration <= 0 {
+		return nil, false
+	}
+	return &layout, true
+}
+
+// AnchorPlaylist is the HLS playlist whose program date time anchors the timeline
+func (l Layout) AnchorPlaylist(dir string) string {
+	return filepath.Join(dir, l.Video[0].ID, "index.m3u8")
+}
+
+type mpd struct {
+	XMLName                    xml.Name   `xml:"MPD"`
+	Xmlns                      string     `xml:"xmlns,attr"`
+	Profiles                   string     `xml:"profiles,attr"`
+	Type                       string     `xml:"type,attr"`
+	AvailabilityStartTime      string     `xml:"availabilityStartTime,attr"`
+	PublishTime                string     `xml:"publishTime,attr"`
+	MinimumUpdatePeriod        string     `xml:"minimumUpdatePeriod,attr"`
+	MinBufferTime              string     `xml:"minBufferTime,attr"`
+	TimeShiftBufferDepth       string     `xml:"timeShiftBufferDepth,attr"`
+	SuggestedPresentationDelay string     `xml:"suggestedPresentationDelay,attr"`
+	Period                     period     `xml:"Period"`
+	UTCTiming                  descriptor `xml:"UTCTiming"`
+}
+
+type period struct {
+	ID             string          `xml:"id,attr"`
+	Start          string          `xml:"start,attr"`
+	AdaptationSets []adaptationSet `xml:"AdaptationSet"`
+}
+
+type adaptationSet struct {
+	ID               int              `xml:"id,attr"`
+	ContentType      string           `xml:"contentType,attr"`
+	MimeType         string           `xml:"mimeType,attr"`
+	Lang             string           `xml:"lang,attr,omitempty"`
+	SegmentAlignment bool             `xml:"segmentAlignment,attr"`
+	StartWithSAP     int              `xml:"startWithSAP,attr"`
+	Accessibility    *descriptor      `xml:"Accessibility,omitempty"`
+	Role             *descriptor      `xml:"Role,omitempty"`
+	Representations  []representation `xml:"Representation"`
+}
+
+type segmentTemplate struct {
+	Timescale      int    `xml:"timescale,attr"`
+	Duration       int    `xml:"duration,attr"`
+	StartNumber    int    `xml:"startNumber,attr"`
+	Initialization string `xml:"initialization,attr"`
+	Media          string `xml:"media,attr"`
+}
+
+type representation struct {
+	ID                        string          `xml:"id,attr"`
+	Codecs                    string          `xml:"codecs,attr,omitempty"`
+	Bandwidth                 int             `xml:"bandwidth,attr"`
+	Width                     int             `xml:"width,attr,omitempty"`
+	Height                    int             `xml:"height,attr,omitempty"`
+	AudioSamplingRate         int             `xml:"audioSamplingRate,attr,omitempty"`
+	AudioChannelConfiguration *descriptor     `xml:"AudioChannelConfiguration,omitempty"`
+	SegmentTemplate           segmentTemplate `xml:"SegmentTemplate"`
+}
+
+type descriptor struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+// Render builds the live MPD of a CMAF channel from the HLS playlist anchoring its timeline
+// Segments are addressed by number with the nominal segment duration: keyframes are forced
+// every SegmentDuration seconds, so FFmpeg cuts every segment at the same media time.
+func Render(layout Layout, anchor []byte, now time.Time) ([]byte, error) {
+	sequence, programDateTime, ok := hls.FirstSegmentTime(anchor)
+	if !ok {
+		return nil, fmt.Errorf("playlist has no program date time yet")
+	}
+	segment := time.Duration(layout.SegmentDuration) * time.Second
+	availabilityStart := programDateTime.Add(-time.Duration(sequence) * segment)
+
+	template := func(r Representation) segmentTemplate {
+		return segmentTemplate{
+			Timescale:      timescale,
+			Duration:       layout.SegmentDuration * timescale,
+			StartNumber:    0,
+			Initialization: r.ID + "/" + r.Init,
+			Media:          r.ID + "/segment_$Number%05d$.m4s",
+		}
+	}
+
+	video := adaptationSet{
+		ID:               0,
+		ContentType:      "video",
+		MimeType:         "video/mp4",
+		SegmentAlignment: true,
+		StartWithSAP:     1,
+	}
+	if layout.ClosedCaptions != "" {
+		video.Accessibility = &descriptor{SchemeIDURI: "urn:scte:dash:cc:cea-608:2015", Value: layout.ClosedCaptions}
+	}
+	for _, r := range layout.Video {
+		video.Representations = append(video.Representations, representation{
+			ID:              r.ID,
+			Codecs:          r.Codecs,
+			Bandwidth:       r.Bandwidth,
+			Width:           r.Width,
+			Height:          r.Height,
+			SegmentTemplate: template(r),
+		})
+	}
+	sets := []adaptationSet{video}
+
+	// One adaptation set per audio track, players switch between them by language
+	for i, r := range layout.Audio {
+		set := adaptationSet{
+			ID:               i + 1,
+			ContentType:      "audio",
+			MimeType:         "audio/mp4",
+			Lang:             r.Language,
+			SegmentAlignment: true,
+			StartWithSAP:     1,
+			Representations: []representation{{
+				ID:                r.ID,
+				Codecs:            r.Codecs,
+				Bandwidth:         r.Bandwidth,
+				AudioSamplingRate: r.SampleRate,
+				SegmentTemplate:   template(r),
+			}},
+		}
+		if r.Channels > 0 {
+			set.Representations[0].AudioChannelConfiguration = &descriptor{
+				SchemeIDURI: "urn:mpeg:dash:23003:3:audio_channel_configuration:2011",
+				Value:       fmt.Sprint(r.Channels),
+			}
+		}
+		if r.Default {
+			set.Role = &descriptor{SchemeIDURI: "urn:mpeg:dash:role:2011", Value: "main"}
+		}
+		sets = append(sets, set)
+	}
+
+	manifest := mpd{
+		Xmlns:                      "urn:mpeg:dash:schema:mpd:2011",
+		Profiles:                   "urn:mpeg:dash:profile:isoff-live:2011,urn:mpeg:dash:profile:cmaf:2019",
+		Type:                       "dynamic",
+		AvailabilityStartTime:      availabilityStart.UTC().Format(time.RFC3339Nano),
+		PublishTime:                now.UTC().Format(time.RFC3339),
+		MinimumUpdatePeriod:        duration(segment),
+		MinBufferTime:              duration(segment),
+		TimeShiftBufferDepth:       duration(time.Duration(layout.WindowSegments) * segment),
+		SuggestedPresentationDelay: duration(3 * segment),
+		Period:                     period{ID: "0", Start: "PT0S", AdaptationSets: sets},
+		UTCTiming:                  descriptor{SchemeIDURI: "urn:mpeg:dash:utc:direct:2014", Value: now.UTC().Format(time.RFC3339Nano)},
+	}
+
+	data, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// duration formats an xs:duration in seconds
+func duration(d time.Duration) string {
+	return fmt.Sprintf("PT%gS", d.Seconds())
+}
+
+// templateAttributeRegex matches the segment template URLs of a rendered manifest
+var templateAttributeRegex = regexp.MustCompile(`(initialization|media)="([^"]*)"`)
+
+// AppendQuery adds query (without "?", XML-escaped) to the segment template URLs of a manifest
+func AppendQuery(data []byte, query string) []byte {
+	return templateAttributeRegex.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := templateAttributeRegex.FindSubmatch(match)
+		return []byte(fmt.Sprintf(`%s="%s"`, groups[1], hls.WithQuery(string(groups[2]), query)))
+	})
+}
@@ -0,0 +1,153 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// admitLocked checks the channel and memory limits before a process is started (must be called with m.mu held)
+// Manual starts, restarts and auto-restarts all pass through here; a refused auto-restart is retried with backoff.
+func (m *ProcessManager) admitLocked() error {
+	if max := m.maxChannels(); max > 0 {
+		if used := m.channelsInUseLocked(); used >= max {
+			return &domain.CapacityError{
+				Limit: domain.CapacityLimitChannels,
+				Used:  int64(used),
+				Max:   int64(max),
+			}
+		}
+	}
+
+	if required := int64(m.config.MinFreeMemoryMB); required > 0 {
+		if available, ok := availableMemoryMB(); ok && available < required {
+			return &domain.CapacityError{
+				Limit: domain.CapacityLimitMemory,
+				Used:  available,
+				Max:   required,
+			}
+		}
+	}
+	return nil
+}
+
+// channelsInUseLocked counts the channels holding a place against max_channels (must be called with m.mu held)
+// Running processes include the ones adopted from a previous instance. A channel waiting for its
+// auto-restart keeps its place, otherwise another start could take it while the restart backs off.
+// The restart being started has already cleared its timer, so it does not count against itself.
+func (m *ProcessManager) channelsInUseLocked() int {
+	used := len(m.processes)
+	for channelID, state := range m.restarts {
+		if _, running := m.processes[channelID]; !running && state.timer != nil {
+			used++
+		}
+	}
+	return used
+}
+
+// admitSession checks the encoder session limit of the device selected for a process
+func (m *ProcessManager) admitSession(encoder encoderSelection) error {
+	name := encoder.backend.Name()
+	max := m.sessionLimit(name)
+	if max == 0 {
+		return nil
+	}
+
+	m.gpuMu.Lock()
+	used := m.deviceSessions[name][encoder.device]
+	m.gpuMu.Unlock()
+
+	if used >= max {
+		return &domain.CapacityError{
+			Limit:   domain.CapacityLimitGPUSessions,
+			Used:    int64(used),
+			Max:     int64(max),
+			Backend: name,
+			Device:  encoder.device,
+		}
+	}
+	return nil
+}
+
+// sessionLimit returns the encoder sessions allowed per device of a backend (0 = unlimited)
+func (m *ProcessManager) sessionLimit(backend domain.EncoderBackend) int {
+	if backend == domain.EncoderBackendSoftware || m.config.MaxSessionsPerGPU <= 0 {
+		return 0
+	}
+	return m.config.MaxSessionsPerGPU
+}
+
+// acquireSession counts a started process against its encoder device
+func (m *ProcessManager) acquireSession(backend domain.EncoderBackend, device int) {
+	m.gpuMu.Lock()
+	defer m.gpuMu.Unlock()
+
+	if m.deviceSessions[backend] == nil {
+		m.deviceSessions[backend] = make(map[int]int)
+	}
+	m.deviceSessions[backend][device]++
+}
+
+// releaseSession frees the encoder device session of an exited process
+// Adopted processes whose command line could not be read have no backend and were never counted.
+func (m *ProcessManager) releaseSession(process *Process) {
+	m.releaseDeviceSession(process.Backend, process.GPUIndex)
+}
+
+// releaseDeviceSession frees one encoder session of a device
+func (m *ProcessManager) releaseDeviceSession(backend domain.EncoderBackend, device int) {
+	if backend == "" {
+		return
+	}
+
+	m.gpuMu.Lock()
+	defer m.gpuMu.Unlock()
+
+	if sessions := m.deviceSessions[backend]; sessions[device] > 0 {
+		sessions[device]--
+	}
+}
+
+// maxChannels returns the max_channels setting (0 = unlimited)
+func (m *ProcessManager) maxChannels() int {
+	if m.settingsRepo == nil {
+		return 0
+	}
+	dbSettings, err := m.settingsRepo.GetSystemSettings()
+	if err != nil {
+		return 0
+	}
+	switch v := dbSettings["max_channels"].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// availableMemoryMB returns MemAvailable from /proc/meminfo
+func availableMemoryMB() (int64, bool) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: "MemAvailable:   12345678 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return kb / 1024, true
+		}
+	}
+	return 0, false
+}
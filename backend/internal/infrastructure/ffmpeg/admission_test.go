@@ -0,0 +1,30 @@
+package ffmpeg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChannelsInUseLocked(t *testing.T) {
+	running, adopted, pending, gaveUp := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	timer := time.AfterFunc(time.Hour, func() {})
+	defer timer.Stop()
+
+	m := &ProcessManager{
+		processes: map[uuid.UUID]*Process{
+			running: {ChannelID: running},
+			adopted: {ChannelID: adopted, Adopted: true},
+		},
+		restarts: map[uuid.UUID]*restartState{
+			running: {},             // Restarted successfully, counters only
+			pending: {timer: timer}, // Waiting for its auto-restart
+			gaveUp:  {gaveUp: true}, // Nothing scheduled
+		},
+	}
+
+	if got := m.channelsInUseLocked(); got != 3 {
+		t.Errorf("channelsInUseLocked() = %d, want 3 (running, adopted and pending restart)", got)
+	}
+}
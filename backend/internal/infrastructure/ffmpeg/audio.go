@@ -0,0 +1,179 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+const (
+	audioGroup       = "audio" // HLS audio group shared by all video variants
+	videoVariantName = "video" // Variant directory of a single-rendition channel published with a master playlist
+)
+
+// audioStream is a source audio stream as reported by ffprobe
+type audioStream struct {
+	codec    string
+	channels int
+	language string
+}
+
+// audioTrack is a resolved output audio track
+type audioTrack struct {
+	input     string // -map specifier (e.g. 0:a:1 or the silent lavfi input)
+	language  string
+	name      string // HLS rendition name (variant directory)
+	isDefault bool
+	silent    bool // Generated by anullsrc, the source has no audio
+}
+
+// resolveAudioTracks selects the output audio tracks of a channel
+// With probed set, selections are matched against the source streams and unmatched ones are
+// returned separately (the first stream is used if nothing matched). taken holds variant names
+// already in use (ladder renditions) so audio rendition names stay unique.
+func resolveAudioTracks(config domain.AudioConfig, streams []audioStream, probed bool, silentInput int, taken map[string]bool) ([]audioTrack, []string) {
+	var tracks []audioTrack
+	var unmatched []string
+
+	switch {
+	case probed && len(streams) == 0:
+		if config.Missing == domain.MissingAudioSilence {
+			tracks = append(tracks, audioTrack{input: fmt.Sprintf("%d:a", silentInput), silent: true})
+		}
+	case config.AllTracks && probed:
+		for i, stream := range streams {
+			tracks = append(tracks, audioTrack{input: fmt.Sprintf("0:a:%d", i), language: stream.language})
+		}
+	case len(config.Tracks) > 0:
+		for i, selection := range config.Tracks {
+			track, ok := matchAudioTrack(selection, streams, probed)
+			if !ok {
+				unmatched = append(unmatched, describeSelection(i, selection))
+				continue
+			}
+			tracks = append(tracks, track)
+		}
+		if len(tracks) == 0 && len(streams) > 0 {
+			tracks = append(tracks, audioTrack{input: "0:a:0", language: streams[0].language})
+		}
+	case probed:
+		tracks = append(tracks, audioTrack{input: "0:a:0", language: streams[0].language})
+	default:
+		// Not probed: map the first track if there is one
+		tracks = append(tracks, audioTrack{input: "0:a:0?"})
+	}
+
+	hasDefault := false
+	for _, track := range tracks {
+		hasDefault = hasDefault || track.isDefault
+	}
+	for i := range tracks {
+		if !hasDefault && i == 0 {
+			tracks[i].isDefault = true
+		}
+		if tracks[i].name == "" {
+			tracks[i].name = "audio_" + strconv.Itoa(i)
+			if tracks[i].language != "" {
+				tracks[i].name = "audio_" + tracks[i].language
+			}
+		}
+		tracks[i].name = uniqueVariantName(tracks[i].name, taken)
+	}
+	return tracks, unmatched
+}
+
+// matchAudioTrack finds the source stream of a track selection
+func matchAudioTrack(selection domain.AudioTrack, streams []audioStream, probed bool) (audioTrack, bool) {
+	track := audioTrack{name: selection.Name, isDefault: selection.Default, language: selection.Language}
+
+	if selection.Index != nil {
+		index := *selection.Index
+		if probed {
+			if index >= len(streams) {
+				return audioTrack{}, false
+			}
+			if track.language == "" {
+				track.language = streams[index].language
+			}
+		}
+		track.input = fmt.Sprintf("0:a:%d", index)
+		if !probed {
+			track.input += "?" // Optional, the source layout is unknown
+		}
+		return track, true
+	}
+
+	if !probed {
+		track.input = "0:a:m:language:" + selection.Language + "?"
+		return track, true
+	}
+	for i, stream := range streams {
+		if stream.language == selection.Language {
+			track.input = fmt.Sprintf("0:a:%d", i)
+			return track, true
+		}
+	}
+	return audioTrack{}, false
+}
+
+func describeSelection(i int, selection domain.AudioTrack) string {
+	if selection.Index != nil {
+		return fmt.Sprintf("track %d (index %d)", i, *selection.Index)
+	}
+	return fmt.Sprintf("track %d (language %s)", i, selection.Language)
+}
+
+// uniqueVariantName returns name, suffixed if it is already taken, and marks it as taken
+func uniqueVariantName(name string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// silentInputArgs returns the lavfi input generating silence for sources without audio
+func silentInputArgs(config domain.AudioConfig) []string {
+	layout := config.ChannelLayout
+	if layout == "5.1" {
+		layout = "5.1(side)"
+	}
+	return []string{
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("anullsrc=channel_layout=%s:sample_rate=%d", layout, config.SampleRate),
+	}
+}
+
+// audioEncodeArgs returns the codec arguments applied to every output audio stream
+// Generated silence is always encoded, even in passthrough mode.
+func audioEncodeArgs(config domain.AudioConfig, tracks []audioTrack) []string {
+	if len(tracks) == 0 {
+		return nil
+	}
+	if config.Mode == domain.AudioModePassthrough && !tracks[0].silent {
+		return []string{"-c:a", "copy"}
+	}
+	return []string{
+		"-c:a", "aac",
+		"-b:a", config.Bitrate,
+		"-ar", strconv.Itoa(config.SampleRate),
+		"-ac", strconv.Itoa(domain.AudioLayouts[config.ChannelLayout]),
+	}
+}
+
+// audioMetadataArgs tags output audio streams with their language
+// copies is the number of output streams per track (ladder renditions carry their own copy).
+func audioMetadataArgs(tracks []audioTrack, copies int) []string {
+	var args []string
+	for i, track := range tracks {
+		if track.language == "" {
+			continue
+		}
+		for c := 0; c < copies; c++ {
+			args = append(args, fmt.Sprintf("-metadata:s:a:%d", i*copies+c), "language="+track.language)
+		}
+	}
+	return args
+}
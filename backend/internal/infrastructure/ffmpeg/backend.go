@@ -0,0 +1,168 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+)
+
+// Backend generates the hardware specific part of an FFmpeg command
+// Argument generation must not touch the hardware so it can be exercised without it;
+// only Detect inspects the host.
+type Backend interface {
+	// Name returns the backend identifier (nvenc, vaapi, qsv, software)
+	Name() domain.EncoderBackend
+	// Detect returns the number of usable devices on this host (0 = unavailable)
+	Detect() int
+	// InputArgs returns device initialisation and hwaccel arguments placed before -i
+	InputArgs(device int) []string
+	// ScaleFilter returns the filter chain scaling a composed (system memory) frame for one ladder rendition
+	ScaleFilter(width, height int) string
+	// UploadFilter returns the filter moving a composed frame to the device ("" if not needed)
+	UploadFilter() string
+	// Encoder returns the FFmpeg encoder name for a codec ("" if unsupported)
+	Encoder(codec domain.VideoCodec) string
+	// EncoderArgs returns -c:v and encoder specific arguments
+	EncoderArgs(p encodeParams) ([]string, error)
+}
+
+// encoderSelection is the backend and device chosen for a process
+type encoderSelection struct {
+	backend Backend
+	device  int
+}
+
+// newBackends returns all known backends in auto-selection preference order
+func newBackends() []Backend {
+	return []Backend{
+		&nvencBackend{},
+		newQSVBackend(),
+		newVAAPIBackend(),
+		&softwareBackend{},
+	}
+}
+
+// detectEncoders lists the encoders compiled into the FFmpeg binary
+// Returns nil if the binary cannot be queried (no filtering is applied then)
+func detectEncoders(binaryPath string) map[string]bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binaryPath, "-hide_banner", "-encoders").Output()
+	if err != nil {
+		logger.Debug().Err(err).Msg("Could not list FFmpeg encoders, encoder availability is not checked")
+		return nil
+	}
+
+	encoders := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		// Format: " V....D libx264              libx264 H.264 / AVC ..."
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && len(fields[0]) == 6 && fields[0][0] == 'V' {
+			encoders[fields[1]] = true
+		}
+	}
+	return encoders
+}
+
+// backendSupports checks that a backend has an encoder for the codec that is compiled into FFmpeg
+func (m *ProcessManager) backendSupports(backend Backend, codec domain.VideoCodec) bool {
+	encoder := backend.Encoder(codec)
+	if encoder == "" {
+		return false
+	}
+	return m.encoders == nil || m.encoders[encoder]
+}
+
+// requestedBackend returns the forced backend for a channel (channel overrides node config)
+func (m *ProcessManager) requestedBackend(channel *domain.Channel) (domain.EncoderBackend, error) {
+	if channel.OutputConfig != nil && channel.OutputConfig.EncoderBackend != "" {
+		if backend, err := domain.ParseEncoderBackend(channel.OutputConfig.EncoderBackend); err != nil {
+			return "", err
+		} else if backend != domain.EncoderBackendAuto {
+			return backend, nil
+		}
+	}
+	return domain.ParseEncoderBackend(m.config.EncoderBackend)
+}
+
+// selectBackend picks the backend and device used to encode a channel
+// Forced backends fail if unavailable; auto mode falls back to the next available backend
+func (m *ProcessManager) selectBackend(channel *domain.Channel, codec domain.VideoCodec, peek bool) (encoderSelection, error) {
+	requested, err := m.requestedBackend(channel)
+	if err != nil {
+		return encoderSelection{}, err
+	}
+
+	for _, backend := range m.backends {
+		name := backend.Name()
+		if requested != domain.EncoderBackendAuto && name != requested {
+			continue
+		}
+		if m.deviceCounts[name] == 0 {
+			if requested != domain.EncoderBackendAuto {
+				return encoderSelection{}, fmt.Errorf("encoder backend %s is not available on this node", name)
+			}
+			continue
+		}
+		if !m.backendSupports(backend, codec) {
+			if requested != domain.EncoderBackendAuto {
+				return encoderSelection{}, fmt.Errorf("encoder backend %s does not support %s", name, codec)
+			}
+			continue
+		}
+		return encoderSelection{backend: backend, device: m.nextDeviceIndex(name, peek)}, nil
+	}
+
+	return encoderSelection{}, fmt.Errorf("no encoder backend available for %s", codec)
+}
+
+// nextDeviceIndex returns the next device of a backend for round-robin distribution
+// Devices without a free encoder session are skipped; if all are full the round-robin
+// device is returned and admission control refuses the start.
+// With peek set, the counter is not advanced (used for previews)
+func (m *ProcessManager) nextDeviceIndex(backend domain.EncoderBackend, peek bool) int {
+	m.gpuMu.Lock()
+	defer m.gpuMu.Unlock()
+
+	count := m.deviceCounts[backend]
+	if count <= 1 {
+		return 0
+	}
+
+	device := m.deviceCounters[backend] % count
+	if max := m.sessionLimit(backend); max > 0 {
+		for i := 0; i < count; i++ {
+			if candidate := (device + i) % count; m.deviceSessions[backend][candidate] < max {
+				device = candidate
+				break
+			}
+		}
+	}
+	if !peek {
+		m.deviceCounters[backend] = (device + 1) % count
+	}
+	return device
+}
+
+// pixelFormat returns the software pixel format for a profile
+func pixelFormat(profile string) string {
+	if profile == "main10" {
+		return "yuv420p10le"
+	}
+	return "yuv420p"
+}
+
+// keyframeArgs returns GOP arguments shared by all encoders (aligned keyframes at segment boundaries)
+func keyframeArgs(p encodeParams) []string {
+	return []string{
+		"-g", fmt.Sprint(p.gopSize),
+		"-keyint_min", fmt.Sprint(p.gopSize / 2),
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", p.segmentTime),
+	}
+}
@@ -0,0 +1,78 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// nvencBackend encodes on NVIDIA GPUs (CUDA decode, NVENC encode)
+// Frames stay in system memory between decode and encode so CPU overlays keep working
+type nvencBackend struct{}
+
+func (b *nvencBackend) Name() domain.EncoderBackend {
+	return domain.EncoderBackendNVENC
+}
+
+// Detect counts NVIDIA GPUs via nvidia-smi
+func (b *nvencBackend) Detect() int {
+	return detectGPUCount()
+}
+
+func (b *nvencBackend) InputArgs(device int) []string {
+	// -hwaccel cuda: Use CUDA for hardware acceleration
+	// -hwaccel_device: Specify which GPU to use
+	return []string{
+		"-hwaccel", "cuda",
+		"-hwaccel_device", strconv.Itoa(device),
+	}
+}
+
+func (b *nvencBackend) ScaleFilter(width, height int) string {
+	return fmt.Sprintf("scale=%d:%d", width, height)
+}
+
+func (b *nvencBackend) UploadFilter() string {
+	return "" // NVENC accepts system memory frames
+}
+
+func (b *nvencBackend) Encoder(codec domain.VideoCodec) string {
+	switch codec {
+	case domain.VideoCodecH264:
+		return "h264_nvenc"
+	case domain.VideoCodecHEVC:
+		return "hevc_nvenc"
+	case domain.VideoCodecAV1:
+		return "av1_nvenc" // Ada Lovelace and newer
+	}
+	return ""
+}
+
+func (b *nvencBackend) EncoderArgs(p encodeParams) ([]string, error) {
+	pixFmt := pixelFormat(p.profile)
+	if p.profile == "main10" {
+		pixFmt = "p010le"
+	}
+
+	// NVENC optimized parameters with specific GPU device
+	args := []string{
+		"-c:v", b.Encoder(p.codec),
+		"-preset", nvencPreset(p.preset),
+		"-tune", "ull", // Ultra-low latency
+		"-rc", "vbr", // Variable bitrate
+		"-cq", strconv.Itoa(p.crf), // Quality
+	}
+	args = append(args, p.rateArgs...)
+	args = append(args, "-profile:v", p.profile)
+	if p.level != "" {
+		args = append(args, "-level", p.level)
+	}
+	args = append(args, "-pix_fmt", pixFmt)
+	args = append(args, keyframeArgs(p)...)
+	args = append(args,
+		"-bf", "0",
+		"-gpu", strconv.Itoa(p.device), // Use specific GPU index (load balanced)
+	)
+	return args, nil
+}
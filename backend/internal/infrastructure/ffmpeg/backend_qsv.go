@@ -0,0 +1,87 @@
+package ffmpeg
+
+import (
+	"fmt"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// qsvBackend encodes through Intel Quick Sync Video
+// The QSV device is derived from a VA-API device on the same render node
+type qsvBackend struct {
+	sysfsRoot string
+	devRoot   string
+	nodes     []string // Intel render node paths, filled by Detect
+}
+
+func newQSVBackend() *qsvBackend {
+	return &qsvBackend{sysfsRoot: drmSysfsRoot, devRoot: drmDevRoot}
+}
+
+func (b *qsvBackend) Name() domain.EncoderBackend {
+	return domain.EncoderBackendQSV
+}
+
+// Detect finds Intel render nodes
+func (b *qsvBackend) Detect() int {
+	b.nodes = findRenderNodes(b.sysfsRoot, b.devRoot, pciVendorIntel)
+	return len(b.nodes)
+}
+
+func (b *qsvBackend) InputArgs(device int) []string {
+	return []string{
+		"-init_hw_device", "vaapi=va:" + renderNode(b.nodes, b.devRoot, device),
+		"-init_hw_device", "qsv=qs@va",
+		"-filter_hw_device", "qs",
+	}
+}
+
+func (b *qsvBackend) ScaleFilter(width, height int) string {
+	return fmt.Sprintf("format=nv12,hwupload=extra_hw_frames=64,scale_qsv=w=%d:h=%d", width, height)
+}
+
+func (b *qsvBackend) UploadFilter() string {
+	return "format=nv12,hwupload=extra_hw_frames=64"
+}
+
+func (b *qsvBackend) Encoder(codec domain.VideoCodec) string {
+	switch codec {
+	case domain.VideoCodecH264:
+		return "h264_qsv"
+	case domain.VideoCodecHEVC:
+		return "hevc_qsv"
+	case domain.VideoCodecAV1:
+		return "av1_qsv" // Arc and newer
+	}
+	return ""
+}
+
+func (b *qsvBackend) EncoderArgs(p encodeParams) ([]string, error) {
+	if p.profile == "main10" {
+		return nil, fmt.Errorf("profile main10 is not supported by the qsv backend")
+	}
+
+	args := []string{
+		"-c:v", b.Encoder(p.codec),
+		"-preset", qsvPreset(p.preset),
+		"-look_ahead", "0", // Low latency
+	}
+	args = append(args, p.targetRateArgs...)
+	args = append(args, p.rateArgs...)
+	args = append(args, "-profile:v", p.profile)
+	if p.level != "" {
+		args = append(args, "-level", p.level)
+	}
+	args = append(args, keyframeArgs(p)...)
+	args = append(args, "-bf", "0")
+	return args, nil
+}
+
+// qsvPreset returns a QSV preset name (veryfast is the fastest QSV preset)
+func qsvPreset(preset string) string {
+	index := softwarePresetIndex(preset)
+	if index < 2 {
+		index = 2
+	}
+	return domain.SoftwarePresets[index]
+}
@@ -0,0 +1,118 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// softwareBackend encodes on the CPU (libx264, libx265, libsvtav1)
+type softwareBackend struct{}
+
+func (b *softwareBackend) Name() domain.EncoderBackend {
+	return domain.EncoderBackendSoftware
+}
+
+// Detect always reports one device, the CPU is the fallback for every node
+func (b *softwareBackend) Detect() int {
+	return 1
+}
+
+func (b *softwareBackend) InputArgs(device int) []string {
+	return nil
+}
+
+func (b *softwareBackend) ScaleFilter(width, height int) string {
+	return fmt.Sprintf("scale=%d:%d", width, height)
+}
+
+func (b *softwareBackend) UploadFilter() string {
+	return ""
+}
+
+func (b *softwareBackend) Encoder(codec domain.VideoCodec) string {
+	switch codec {
+	case domain.VideoCodecH264:
+		return "libx264"
+	case domain.VideoCodecHEVC:
+		return "libx265"
+	case domain.VideoCodecAV1:
+		return "libsvtav1"
+	}
+	return ""
+}
+
+func (b *softwareBackend) EncoderArgs(p encodeParams) ([]string, error) {
+	var args []string
+	switch p.codec {
+	case domain.VideoCodecH264:
+		// x264 (CPU) parameters
+		args = append(args,
+			"-c:v", "libx264",
+			"-preset", x26xPreset(p.preset),
+			"-tune", "zerolatency",
+			"-crf", strconv.Itoa(p.crf),
+		)
+		args = append(args, p.rateArgs...)
+		args = append(args,
+			"-profile:v", p.profile,
+			"-level", p.level,
+			"-pix_fmt", pixelFormat(p.profile),
+		)
+		args = append(args, keyframeArgs(p)...)
+		args = append(args,
+			"-sc_threshold", "0",
+			"-threads", p.threadCount,
+			"-x264opts", "nal-hrd=cbr:force-cfr=1",
+			"-bf", "0",
+		)
+	case domain.VideoCodecHEVC:
+		// x265 (CPU) parameters, GOP is also pinned through x265-params
+		x265Params := []string{
+			fmt.Sprintf("keyint=%d", p.gopSize),
+			fmt.Sprintf("min-keyint=%d", p.gopSize/2),
+			"scenecut=0",
+			"bframes=0",
+			"repeat-headers=1",
+		}
+		if p.level != "" {
+			x265Params = append(x265Params, "level-idc="+p.level)
+		}
+		args = append(args,
+			"-c:v", "libx265",
+			"-preset", x26xPreset(p.preset),
+			"-tune", "zerolatency",
+			"-crf", strconv.Itoa(p.crf),
+		)
+		args = append(args, p.rateArgs...)
+		args = append(args,
+			"-profile:v", p.profile,
+			"-pix_fmt", pixelFormat(p.profile),
+		)
+		args = append(args, keyframeArgs(p)...)
+		args = append(args,
+			"-x265-params", strings.Join(x265Params, ":"),
+			"-threads", p.threadCount,
+		)
+	case domain.VideoCodecAV1:
+		// SVT-AV1 (CPU) parameters
+		args = append(args,
+			"-c:v", "libsvtav1",
+			"-preset", svtAV1Preset(p.preset),
+			"-crf", strconv.Itoa(p.crf),
+		)
+		args = append(args, p.rateArgs...)
+		args = append(args, "-profile:v", p.profile)
+		if p.level != "" {
+			args = append(args, "-level", p.level)
+		}
+		args = append(args, "-pix_fmt", pixelFormat(p.profile))
+		args = append(args, keyframeArgs(p)...)
+		args = append(args, "-svtav1-params", "tune=0:scd=0")
+	default:
+		return nil, fmt.Errorf("unsupported codec: %s", p.codec)
+	}
+	return args, nil
+}
@@ -0,0 +1,204 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// testEncodeParams returns 1080p H.264 parameters on device 1, as buildArgs fills them
+func testEncodeParams() encodeParams {
+	return encodeParams{
+		codec:          domain.VideoCodecH264,
+		device:         1,
+		preset:         "veryfast",
+		profile:        "high",
+		level:          "4.1",
+		crf:            23,
+		rateArgs:       []string{"-maxrate", "5000k", "-bufsize", "10000k"},
+		targetRateArgs: []string{"-b:v", "5000k"},
+		gopSize:        60,
+		segmentTime:    2,
+		threadCount:    "4",
+	}
+}
+
+func TestEncoderArgs(t *testing.T) {
+	keyframes := "-g 60 -keyint_min 30 -force_key_frames expr:gte(t,n_forced*2)"
+
+	tests := []struct {
+		name    string
+		backend Backend
+		modify  func(p *encodeParams)
+		want    string
+	}{
+		{
+			name:    "nvenc h264",
+			backend: &nvencBackend{},
+			want: "-c:v h264_nvenc -preset p4 -tune ull -rc vbr -cq 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 -pix_fmt yuv420p " + keyframes + " -bf 0 -gpu 1",
+		},
+		{
+			name:    "nvenc hevc main10 with nvenc preset",
+			backend: &nvencBackend{},
+			modify: func(p *encodeParams) {
+				p.codec, p.profile, p.level, p.preset = domain.VideoCodecHEVC, "main10", "", "p6"
+			},
+			want: "-c:v hevc_nvenc -preset p6 -tune ull -rc vbr -cq 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v main10 -pix_fmt p010le " + keyframes + " -bf 0 -gpu 1 -tag:v hvc1",
+		},
+		{
+			name:    "nvenc h264 closed captions",
+			backend: &nvencBackend{},
+			modify:  func(p *encodeParams) { p.closedCaptions = true },
+			want: "-c:v h264_nvenc -preset p4 -tune ull -rc vbr -cq 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 -pix_fmt yuv420p " + keyframes + " -bf 0 -gpu 1 -a53cc 1",
+		},
+		{
+			name:    "vaapi h264",
+			backend: newVAAPIBackend(),
+			want: "-c:v h264_vaapi -rc_mode VBR -b:v 5000k -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 " + keyframes + " -bf 0",
+		},
+		{
+			name:    "vaapi h264 closed captions",
+			backend: newVAAPIBackend(),
+			modify:  func(p *encodeParams) { p.closedCaptions = true },
+			want: "-c:v h264_vaapi -rc_mode VBR -b:v 5000k -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 " + keyframes + " -bf 0 -sei +a53_cc",
+		},
+		{
+			name:    "qsv h264 clamps ultrafast to veryfast",
+			backend: newQSVBackend(),
+			modify:  func(p *encodeParams) { p.preset = "ultrafast" },
+			want: "-c:v h264_qsv -preset veryfast -look_ahead 0 -b:v 5000k -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 " + keyframes + " -bf 0",
+		},
+		{
+			name:    "qsv hevc",
+			backend: newQSVBackend(),
+			modify: func(p *encodeParams) {
+				p.codec, p.profile, p.level, p.preset = domain.VideoCodecHEVC, "main", "", "fast"
+			},
+			want: "-c:v hevc_qsv -preset fast -look_ahead 0 -b:v 5000k -maxrate 5000k -bufsize 10000k " +
+				"-profile:v main " + keyframes + " -bf 0 -tag:v hvc1",
+		},
+		{
+			name:    "software h264",
+			backend: &softwareBackend{},
+			want: "-c:v libx264 -preset veryfast -tune zerolatency -crf 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v high -level 4.1 -pix_fmt yuv420p " + keyframes +
+				" -sc_threshold 0 -threads 4 -x264opts nal-hrd=cbr:force-cfr=1 -bf 0",
+		},
+		{
+			name:    "software hevc",
+			backend: &softwareBackend{},
+			modify:  func(p *encodeParams) { p.codec, p.profile = domain.VideoCodecHEVC, "main" },
+			want: "-c:v libx265 -preset veryfast -tune zerolatency -crf 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v main -pix_fmt yuv420p " + keyframes +
+				" -x265-params keyint=60:min-keyint=30:scenecut=0:bframes=0:repeat-headers=1:level-idc=4.1 -threads 4 -tag:v hvc1",
+		},
+		{
+			name:    "software av1",
+			backend: &softwareBackend{},
+			modify:  func(p *encodeParams) { p.codec, p.profile, p.level = domain.VideoCodecAV1, "main", "" },
+			want: "-c:v libsvtav1 -preset 10 -crf 23 -maxrate 5000k -bufsize 10000k " +
+				"-profile:v main -pix_fmt yuv420p " + keyframes + " -svtav1-params tune=0:scd=0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testEncodeParams()
+			if tt.modify != nil {
+				tt.modify(&p)
+			}
+			args, err := videoEncoderArgs(tt.backend, p)
+			if err != nil {
+				t.Fatalf("videoEncoderArgs() error = %v", err)
+			}
+			if got := strings.Join(args, " "); got != tt.want {
+				t.Errorf("videoEncoderArgs()\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderArgsRejectUnsupportedSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend Backend
+		modify  func(p *encodeParams)
+	}{
+		{name: "vaapi main10", backend: newVAAPIBackend(), modify: func(p *encodeParams) { p.codec, p.profile = domain.VideoCodecHEVC, "main10" }},
+		{name: "qsv main10", backend: newQSVBackend(), modify: func(p *encodeParams) { p.codec, p.profile = domain.VideoCodecHEVC, "main10" }},
+		{name: "qsv hevc closed captions", backend: newQSVBackend(), modify: func(p *encodeParams) {
+			p.codec, p.profile, p.closedCaptions = domain.VideoCodecHEVC, "main", true
+		}},
+		{name: "software av1 closed captions", backend: &softwareBackend{}, modify: func(p *encodeParams) {
+			p.codec, p.profile, p.closedCaptions = domain.VideoCodecAV1, "main", true
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testEncodeParams()
+			tt.modify(&p)
+			if args, err := videoEncoderArgs(tt.backend, p); err == nil {
+				t.Errorf("videoEncoderArgs() = %v, want error", args)
+			}
+		})
+	}
+}
+
+func TestInputArgs(t *testing.T) {
+	nodes := []string{"/dev/dri/renderD128", "/dev/dri/renderD129"}
+
+	tests := []struct {
+		name    string
+		backend Backend
+		device  int
+		want    []string
+	}{
+		{
+			name:    "nvenc",
+			backend: &nvencBackend{},
+			device:  1,
+			want:    []string{"-hwaccel", "cuda", "-hwaccel_device", "1"},
+		},
+		{
+			name:    "vaapi detected node",
+			backend: &vaapiBackend{devRoot: drmDevRoot, nodes: nodes},
+			device:  1,
+			want:    []string{"-init_hw_device", "vaapi=va:/dev/dri/renderD129", "-hwaccel", "vaapi", "-hwaccel_device", "va", "-filter_hw_device", "va"},
+		},
+		{
+			name:    "vaapi without detected nodes",
+			backend: &vaapiBackend{devRoot: drmDevRoot},
+			device:  2,
+			want:    []string{"-init_hw_device", "vaapi=va:/dev/dri/renderD130", "-hwaccel", "vaapi", "-hwaccel_device", "va", "-filter_hw_device", "va"},
+		},
+		{
+			name:    "qsv",
+			backend: &qsvBackend{devRoot: drmDevRoot, nodes: nodes},
+			device:  0,
+			want:    []string{"-init_hw_device", "vaapi=va:/dev/dri/renderD128", "-init_hw_device", "qsv=qs@va", "-filter_hw_device", "qs"},
+		},
+		{
+			name:    "software",
+			backend: &softwareBackend{},
+			device:  0,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backend.InputArgs(tt.device); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InputArgs(%d) = %q, want %q", tt.device, got, tt.want)
+			}
+		})
+	}
+}
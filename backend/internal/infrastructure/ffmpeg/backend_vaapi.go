@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+const (
+	drmSysfsRoot = "/sys/class/drm"
+	drmDevRoot   = "/dev/dri"
+
+	pciVendorIntel = "0x8086"
+	pciVendorAMD   = "0x1002"
+)
+
+// vaapiBackend encodes through VA-API on Intel and AMD GPUs
+// Composed frames are uploaded to the device and scaled with scale_vaapi
+type vaapiBackend struct {
+	sysfsRoot string
+	devRoot   string
+	nodes     []string // Render node paths, filled by Detect
+}
+
+func newVAAPIBackend() *vaapiBackend {
+	return &vaapiBackend{sysfsRoot: drmSysfsRoot, devRoot: drmDevRoot}
+}
+
+func (b *vaapiBackend) Name() domain.EncoderBackend {
+	return domain.EncoderBackendVAAPI
+}
+
+// Detect finds Intel and AMD render nodes
+func (b *vaapiBackend) Detect() int {
+	b.nodes = findRenderNodes(b.sysfsRoot, b.devRoot, pciVendorIntel, pciVendorAMD)
+	return len(b.nodes)
+}
+
+func (b *vaapiBackend) InputArgs(device int) []string {
+	return []string{
+		"-init_hw_device", "vaapi=va:" + renderNode(b.nodes, b.devRoot, device),
+		"-hwaccel", "vaapi",
+		"-hwaccel_device", "va",
+		"-filter_hw_device", "va",
+	}
+}
+
+func (b *vaapiBackend) ScaleFilter(width, height int) string {
+	return fmt.Sprintf("%s,scale_vaapi=w=%d:h=%d", b.UploadFilter(), width, height)
+}
+
+func (b *vaapiBackend) UploadFilter() string {
+	return "format=nv12,hwupload"
+}
+
+func (b *vaapiBackend) Encoder(codec domain.VideoCodec) string {
+	switch codec {
+	case domain.VideoCodecH264:
+		return "h264_vaapi"
+	case domain.VideoCodecHEVC:
+		return "hevc_vaapi"
+	case domain.VideoCodecAV1:
+		return "av1_vaapi"
+	}
+	return ""
+}
+
+func (b *vaapiBackend) EncoderArgs(p encodeParams) ([]string, error) {
+	if p.profile == "main10" {
+		return nil, fmt.Errorf("profile main10 is not supported by the vaapi backend")
+	}
+
+	args := []string{
+		"-c:v", b.Encoder(p.codec),
+		"-rc_mode", "VBR",
+	}
+	args = append(args, p.targetRateArgs...)
+	args = append(args, p.rateArgs...)
+	args = append(args, "-profile:v", p.profile)
+	if p.level != "" {
+		args = append(args, "-level", p.level)
+	}
+	args = append(args, keyframeArgs(p)...)
+	args = append(args, "-bf", "0")
+	return args, nil
+}
+
+// findRenderNodes returns the /dev/dri render nodes whose PCI vendor matches one of vendors
+func findRenderNodes(sysfsRoot, devRoot string, vendors ...string) []string {
+	matches, err := filepath.Glob(filepath.Join(sysfsRoot, "renderD*"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+
+	var nodes []string
+	for _, match := range matches {
+		vendor, err := os.ReadFile(filepath.Join(match, "device", "vendor"))
+		if err != nil {
+			continue
+		}
+		if contains(vendors, strings.TrimSpace(string(vendor))) {
+			nodes = append(nodes, filepath.Join(devRoot, filepath.Base(match)))
+		}
+	}
+	return nodes
+}
+
+// renderNode returns the render node for a device index
+// Falls back to the first render node (renderD128) so args can be generated without detection
+func renderNode(nodes []string, devRoot string, device int) string {
+	if device >= 0 && device < len(nodes) {
+		return nodes[device]
+	}
+	return filepath.Join(devRoot, "renderD"+strconv.Itoa(128+device))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+const (
+	subtitleGroup  = "subs" // HLS subtitle group shared by all video variants
+	captionGroup   = "cc"   // HLS closed caption group
+	captionChannel = "CC1"  // CEA-608 channel carried in the video stream
+)
+
+// textSubtitleCodecs are the subtitle codecs that can be converted to WebVTT
+// Bitmap subtitles (dvb_subtitle, hdmv_pgs_subtitle, dvd_subtitle) would need OCR.
+var textSubtitleCodecs = map[string]bool{
+	"subrip":   true,
+	"srt":      true,
+	"ass":      true,
+	"ssa":      true,
+	"mov_text": true,
+	"webvtt":   true,
+	"text":     true,
+}
+
+// subtitleStream is a source subtitle stream as reported by ffprobe
+type subtitleStream struct {
+	codec    string
+	language string
+}
+
+// subtitleTrack is a resolved WebVTT output track
+type subtitleTrack struct {
+	input    string // -map specifier (e.g. 0:s:1)
+	language string
+	name     string // HLS rendition name
+}
+
+// resolveSubtitleTracks selects the text subtitle streams converted to WebVTT renditions
+// FFmpeg's HLS muxer writes one WebVTT playlist per variant and a variant cannot hold subtitles
+// alone, so every rendition rides on its own video variant: at most maxTracks (the number of
+// video variants) are kept, the remaining ones are returned as skipped.
+func resolveSubtitleTracks(config *domain.CaptionConfig, streams []subtitleStream, probed bool, maxTracks int, taken map[string]bool) ([]subtitleTrack, []string) {
+	if config == nil || !config.Subtitles {
+		return nil, nil
+	}
+	if !probed {
+		// Text and bitmap subtitles cannot be told apart without a probe
+		return nil, []string{"all (source not probed)"}
+	}
+
+	var candidates []subtitleTrack
+	var skipped []string
+	for i, stream := range streams {
+		if !textSubtitleCodecs[stream.codec] {
+			skipped = append(skipped, fmt.Sprintf("stream %d (%s is not a text subtitle)", i, stream.codec))
+			continue
+		}
+		candidates = append(candidates, subtitleTrack{input: fmt.Sprintf("0:s:%d", i), language: stream.language})
+	}
+
+	if len(config.Languages) > 0 {
+		var selected []subtitleTrack
+		for _, language := range config.Languages {
+			found := false
+			for _, candidate := range candidates {
+				if candidate.language == language {
+					selected = append(selected, candidate)
+					found = true
+					break
+				}
+			}
+			if !found {
+				skipped = append(skipped, "language "+language+" (not in source)")
+			}
+		}
+		candidates = selected
+	}
+
+	if len(candidates) > maxTracks {
+		for _, extra := range candidates[maxTracks:] {
+			skipped = append(skipped, fmt.Sprintf("%s (only %d subtitle renditions fit the video variants)", extra.input, maxTracks))
+		}
+		candidates = candidates[:maxTracks]
+	}
+
+	for i := range candidates {
+		name := fmt.Sprintf("subtitles_%d", i)
+		if candidates[i].language != "" {
+			name = "subtitles_" + candidates[i].language
+		}
+		candidates[i].name = uniqueVariantName(name, taken)
+	}
+	return candidates, skipped
+}
+
+// subtitleArgs maps the subtitle tracks and converts them to WebVTT
+func subtitleArgs(tracks []subtitleTrack) []string {
+	if len(tracks) == 0 {
+		return nil
+	}
+	var args []string
+	for _, track := range tracks {
+		args = append(args, "-map", track.input)
+	}
+	return append(args, "-c:s", "webvtt")
+}
+
+// captionStreamMapArgs announces the CEA-608 captions of the video variants in the master playlist
+func captionStreamMapArgs(config *domain.CaptionConfig) []string {
+	entry := fmt.Sprintf("ccgroup:%s,instreamid:%s", captionGroup, captionChannel)
+	if config.CCLanguage != "" {
+		entry += ",language:" + config.CCLanguage
+	}
+	return []string{"-cc_stream_map", entry}
+}
+
+// variantLayout describes the HLS variants of a channel published with a master playlist
+type variantLayout struct {
+	videoNames  []string
+	muxedAudio  bool            // Each video variant carries its own copy of the single audio track
+	audioTracks []audioTrack    // Several tracks, published once as an audio group
+	subtitles   []subtitleTrack // Attached to the video variants in order
+	captions    bool            // Video variants reference the closed caption group
+}
+
+// varStreamMap builds the hls var_stream_map value
+func (l variantLayout) varStreamMap() string {
+	entries := make([]string, 0, len(l.videoNames)+len(l.audioTracks))
+	for i, name := range l.videoNames {
+		entry := fmt.Sprintf("v:%d", i)
+		if l.muxedAudio {
+			entry += fmt.Sprintf(",a:%d", i)
+		}
+		if len(l.audioTracks) > 0 {
+			entry += ",agroup:" + audioGroup
+		}
+		if i < len(l.subtitles) {
+			subtitle := l.subtitles[i]
+			entry += fmt.Sprintf(",s:%d,sgroup:%s,sname:%s", i, subtitleGroup, subtitle.name)
+			if subtitle.language != "" {
+				entry += ",language:" + subtitle.language
+			}
+		}
+		if l.captions {
+			entry += ",ccgroup:" + captionGroup
+		}
+		entries = append(entries, entry+",name:"+name)
+	}
+	for i, track := range l.audioTracks {
+		entry := fmt.Sprintf("a:%d,agroup:%s,name:%s", i, audioGroup, track.name)
+		if track.language != "" {
+			entry += ",language:" + track.language
+		}
+		if track.isDefault {
+			entry += ",default:yes"
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, " ")
+}
+
+// variants returns the variant directories to create (subtitle playlists live in their video variant)
+func (l variantLayout) variants() []string {
+	names := append([]string(nil), l.videoNames...)
+	for _, track := range l.audioTracks {
+		names = append(names, track.name)
+	}
+	return names
+}
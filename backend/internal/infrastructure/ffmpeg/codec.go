@@ -0,0 +1,138 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// encodeParams holds the resolved values used to build video encoder arguments
+type encodeParams struct {
+	codec          domain.VideoCodec
+	device         int // Device index within the selected backend
+	preset         string
+	profile        string
+	level          string
+	crf            int
+	rateArgs       []string // -maxrate/-bufsize (or per-rendition variants)
+	targetRateArgs []string // -b:v (or per-rendition variants), used by backends without CRF/CQ
+	gopSize        int
+	segmentTime    int
+	threadCount    string
+	closedCaptions bool // Keep EIA-608/708 captions of the source as CEA-608 (A/53) in the video stream
+}
+
+// hlsSegmentFormat describes the HLS muxer settings matching a codec
+type hlsSegmentFormat struct {
+	segmentType string // mpegts or fmp4
+	extension   string // Segment file extension
+}
+
+// segmentFormatFor returns the HLS muxer settings of a container (CMAF segments are fMP4)
+func segmentFormatFor(container domain.Container) hlsSegmentFormat {
+	if container == domain.ContainerMPEGTS {
+		return hlsSegmentFormat{segmentType: "mpegts", extension: "ts"}
+	}
+	return hlsSegmentFormat{segmentType: "fmp4", extension: "m4s"}
+}
+
+// resolveProfile returns the channel profile if set (validated) or the fallback if valid for the codec
+// Settings defaults such as "high" are H.264 specific, so they fall back to the codec default
+func resolveProfile(codec domain.VideoCodec, channelProfile, fallback string) (string, error) {
+	if channelProfile != "" {
+		if err := codec.ValidateProfile(channelProfile); err != nil {
+			return "", err
+		}
+		return channelProfile, nil
+	}
+	if fallback != "" && codec.ValidateProfile(fallback) == nil {
+		return fallback, nil
+	}
+	return codec.Capabilities().DefaultProfile, nil
+}
+
+// resolveLevel returns the channel level if set (validated) or the codec default
+func resolveLevel(codec domain.VideoCodec, channelLevel string) (string, error) {
+	if channelLevel != "" {
+		if err := codec.ValidateLevel(channelLevel); err != nil {
+			return "", err
+		}
+		return channelLevel, nil
+	}
+	return codec.Capabilities().DefaultLevel, nil
+}
+
+// softwarePresetIndex returns the position of a preset in domain.SoftwarePresets (0 = ultrafast)
+// NVENC presets are mapped onto the same scale (p1 = fastest)
+func softwarePresetIndex(preset string) int {
+	for i, p := range domain.SoftwarePresets {
+		if p == preset {
+			return i
+		}
+	}
+	if n, ok := domain.NVENCPresetNumber(preset); ok && n >= 1 && n <= 7 {
+		return (n - 1) * (len(domain.SoftwarePresets) - 1) / 6
+	}
+	return 2 // veryfast
+}
+
+// x26xPreset returns a libx264/libx265 preset name
+func x26xPreset(preset string) string {
+	return domain.SoftwarePresets[softwarePresetIndex(preset)]
+}
+
+// nvencPreset returns an NVENC preset (p1-p7), p4 unless an NVENC preset is requested
+func nvencPreset(preset string) string {
+	if n, ok := domain.NVENCPresetNumber(preset); ok && n >= 1 && n <= 7 {
+		return preset
+	}
+	return "p4" // Medium quality/speed for newer NVENC
+}
+
+// svtAV1Preset returns a SVT-AV1 numeric preset (0 = slowest, 13 = fastest)
+func svtAV1Preset(preset string) string {
+	if n, err := strconv.Atoi(preset); err == nil && n >= 0 && n <= 13 {
+		return preset
+	}
+	// ultrafast -> 12 ... veryslow -> 4
+	return strconv.Itoa(12 - softwarePresetIndex(preset))
+}
+
+// videoEncoderArgs builds the -c:v and encoder specific arguments for a codec on the selected backend
+func videoEncoderArgs(backend Backend, p encodeParams) ([]string, error) {
+	if err := p.codec.ValidatePreset(p.preset); err != nil {
+		return nil, err
+	}
+
+	args, err := backend.EncoderArgs(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apple players require the hvc1 sample entry for HEVC in fMP4
+	if p.codec == domain.VideoCodecHEVC {
+		args = append(args, "-tag:v", "hvc1")
+	}
+
+	if p.closedCaptions {
+		ccArgs, err := closedCaptionArgs(backend.Encoder(p.codec))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, ccArgs...)
+	}
+
+	return args, nil
+}
+
+// closedCaptionArgs returns the encoder option writing the decoded A/53 captions back as SEI
+func closedCaptionArgs(encoder string) ([]string, error) {
+	switch encoder {
+	case "libx264", "libx265", "h264_nvenc", "hevc_nvenc", "h264_qsv":
+		return []string{"-a53cc", "1"}, nil
+	case "h264_vaapi":
+		return []string{"-sei", "+a53_cc"}, nil
+	}
+	return nil, fmt.Errorf("closed captions are not supported by encoder %s", encoder)
+}
@@ -0,0 +1,127 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/dash"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+)
+
+// commandPlan is the result of buildArgs: FFmpeg arguments, the selected encoder
+// and the effective encoding values with their source
+type commandPlan struct {
+	args       []string
+	encoder    encoderSelection
+	values     map[string]domain.EffectiveValue
+	variants   []string                 // HLS variant directories; empty when a single index.m3u8 is written
+	lowLatency *hls.Marker              // Part layout of Low-Latency HLS channels
+	dash       *dash.Layout             // DASH presentation of CMAF channels
+	encryption *domain.EncryptionConfig // Normalized encryption of encrypted channels
+	dvr        *hls.DVRMarker           // Time-shift window of DVR channels
+	timezone   string                   // TZ of the clock overlays ("" = node local time)
+}
+
+// env returns the environment of the FFmpeg process (nil inherits the backend environment)
+func (p *commandPlan) env() []string {
+	if p.timezone == "" {
+		return nil
+	}
+	return append(os.Environ(), "TZ="+p.timezone)
+}
+
+func newCommandPlan() *commandPlan {
+	return &commandPlan{values: make(map[string]domain.EffectiveValue)}
+}
+
+// set records an effective value, later calls override earlier ones (higher priority source)
+func (p *commandPlan) set(name, value, source string) {
+	p.values[name] = domain.EffectiveValue{Value: value, Source: source}
+}
+
+// encoderBackendSource reports where the requested encoder backend comes from
+func (m *ProcessManager) encoderBackendSource(channel *domain.Channel) string {
+	if channel.OutputConfig != nil && channel.OutputConfig.EncoderBackend != "" {
+		if backend, err := domain.ParseEncoderBackend(channel.OutputConfig.EncoderBackend); err == nil && backend != domain.EncoderBackendAuto {
+			return domain.ValueSourceChannel
+		}
+	}
+	if backend, err := domain.ParseEncoderBackend(m.config.EncoderBackend); err == nil && backend != domain.EncoderBackendAuto {
+		return domain.ValueSourceConfig
+	}
+	return domain.ValueSourceDetected
+}
+
+// commandArgv returns the full argv (binary first) for FFmpeg arguments
+// On multi-node NUMA systems the command is wrapped with numactl when available;
+// numaNode is -1 if no binding is applied. With peek set, the NUMA counter is not advanced.
+func (m *ProcessManager) commandArgv(args []string, peek bool) ([]string, int) {
+	if m.numaNodeCount > 1 && runtime.GOOS == "linux" {
+		// Safely check if numactl is available (must not block FFmpeg startup)
+		if isNumactlAvailable() {
+			numaNode := m.getNextNUMANode(peek)
+			// Wrap FFmpeg command with numactl for NUMA binding
+			// --cpunodebind: bind to CPUs on this NUMA node
+			// --membind: prefer memory from this NUMA node
+			argv := []string{
+				"numactl",
+				fmt.Sprintf("--cpunodebind=%d", numaNode),
+				fmt.Sprintf("--membind=%d", numaNode),
+				m.config.BinaryPath,
+			}
+			return append(argv, args...), numaNode
+		}
+	}
+
+	return append([]string{m.config.BinaryPath}, args...), -1
+}
+
+// PreviewCommand returns the command Start would execute for a channel without launching it
+// Load balancing counters (GPU, NUMA) are not advanced, so the preview shows the next assignment.
+// The source is not probed (a dry run must not open it), the audio and subtitle mapping use the
+// streams cached by the last start and SourceProbed reports whether there were any.
+func (m *ProcessManager) PreviewCommand(channel *domain.Channel) (*domain.CommandPreview, error) {
+	_, probed := m.probedStreams(channel.SourceURL)
+
+	m.mu.RLock()
+	activeProcessCount := len(m.processes)
+	m.mu.RUnlock()
+
+	outputDir := filepath.Join(m.hlsPath, channel.ID.String())
+	plan, err := m.buildArgs(channel, outputDir, activeProcessCount, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	argv, numaNode := m.commandArgv(plan.args, true)
+	preview := &domain.CommandPreview{
+		ChannelID:      channel.ID,
+		Argv:           argv,
+		Command:        shellJoin(argv),
+		EncoderBackend: string(plan.encoder.backend.Name()),
+		Device:         plan.encoder.device,
+		Values:         plan.values,
+		SourceProbed:   probed,
+	}
+	if numaNode >= 0 {
+		preview.NUMANode = &numaNode
+	}
+	return preview, nil
+}
+
+// shellJoin joins argv into a copy/paste friendly shell command
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()[]*?!#~{}") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,112 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/dash"
+)
+
+// h264ProfileCodecs are the RFC 6381 profile_idc and constraint bytes of the H.264 profiles
+var h264ProfileCodecs = map[string]string{
+	"baseline": "42E0",
+	"main":     "4D40",
+	"high":     "6400",
+}
+
+// passthroughAudioCodecs are the RFC 6381 codec strings of audio copied from the source
+var passthroughAudioCodecs = map[string]string{
+	"aac":  "mp4a.40.2",
+	"mp3":  "mp4a.40.34",
+	"ac3":  "ac-3",
+	"eac3": "ec-3",
+	"opus": "Opus",
+	"flac": "fLaC",
+}
+
+// videoCodecString returns the RFC 6381 codecs value of the encoded video
+func videoCodecString(codec domain.VideoCodec, profile, level string) string {
+	if level == "" {
+		level = codec.Capabilities().DefaultLevel
+	}
+	major, minor := 4, 0
+	if _, err := fmt.Sscanf(level, "%d.%d", &major, &minor); err != nil {
+		major, minor = 4, 0
+	}
+
+	switch codec {
+	case domain.VideoCodecHEVC:
+		if profile == "main10" {
+			return fmt.Sprintf("hvc1.2.4.L%d.90", (major*10+minor)*3)
+		}
+		return fmt.Sprintf("hvc1.1.6.L%d.90", (major*10+minor)*3)
+	case domain.VideoCodecAV1:
+		return fmt.Sprintf("av01.0.%02dM.08", (major-2)*4+minor)
+	default:
+		profileCodec, ok := h264ProfileCodecs[profile]
+		if !ok {
+			profileCodec = h264ProfileCodecs["high"]
+		}
+		return fmt.Sprintf("avc1.%s%02X", profileCodec, major*10+minor)
+	}
+}
+
+// audioCodecString returns the RFC 6381 codecs value of an output audio track ("" if unknown)
+func audioCodecString(config domain.AudioConfig, track audioTrack, streams []audioStream) string {
+	if config.Mode != domain.AudioModePassthrough || track.silent {
+		return "mp4a.40.2"
+	}
+	var index int
+	if _, err := fmt.Sscanf(track.input, "0:a:%d", &index); err != nil || index >= len(streams) {
+		return ""
+	}
+	return passthroughAudioCodecs[streams[index].codec]
+}
+
+// rateBits converts an FFmpeg rate ("4000k", "5M") to bits per second
+func rateBits(rate string) int {
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(rate, "k"):
+		multiplier = 1000
+	case strings.HasSuffix(rate, "M"):
+		multiplier = 1000000
+	}
+	value, err := strconv.Atoi(strings.TrimRight(rate, "kM"))
+	if err != nil {
+		return 0
+	}
+	return value * multiplier
+}
+
+// dashAudioRepresentations describes the audio tracks of a CMAF channel
+func dashAudioRepresentations(config domain.AudioConfig, tracks []audioTrack, streams []audioStream) []dash.Representation {
+	representations := make([]dash.Representation, 0, len(tracks))
+	for _, track := range tracks {
+		representation := dash.Representation{
+			ID:        track.name,
+			Codecs:    audioCodecString(config, track, streams),
+			Bandwidth: rateBits(config.Bitrate), // Estimate for copied audio
+			Language:  track.language,
+			Default:   track.isDefault,
+		}
+		// Copied audio keeps the source sample rate and layout, which are not probed
+		if config.Mode != domain.AudioModePassthrough || track.silent {
+			representation.SampleRate = config.SampleRate
+			representation.Channels = domain.AudioLayouts[config.ChannelLayout]
+		}
+		representations = append(representations, representation)
+	}
+	return representations
+}
+
+// fmp4InitName returns the init segment FFmpeg writes for variant index
+// With several variants the HLS muxer suffixes -hls_fmp4_init_filename with the variant index.
+func fmp4InitName(index, variants int) string {
+	if variants > 1 {
+		return fmt.Sprintf("init_%d.mp4", index)
+	}
+	return "init.mp4"
+}
@@ -0,0 +1,169 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	dvrJanitorInterval = 15 * time.Second
+	dvrTrimTarget      = 0.9 // Trimming frees space down to this share of the limit
+	minDVRSegments     = 3   // Segments kept at the live edge even when the disk limit is exceeded
+)
+
+// dvrSegmentGroup is one segment number across all variant directories of a channel
+type dvrSegmentGroup struct {
+	number int
+	files  []string
+	size   int64
+}
+
+// dvrUsage is the disk usage of one DVR channel
+type dvrUsage struct {
+	channelID uuid.UUID
+	dir       string
+	total     int64
+	groups    []dvrSegmentGroup // Oldest first
+}
+
+// RunDVRJanitor enforces the disk limit of DVR windows every dvrJanitorInterval until ctx is cancelled
+// Returns immediately when no limit is configured.
+func (m *ProcessManager) RunDVRJanitor(ctx context.Context) {
+	if m.config.DVRMaxDiskMB <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(dvrJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.enforceDVRLimit()
+		}
+	}
+}
+
+// enforceDVRLimit trims the oldest segments of the largest DVR windows while the limit is exceeded
+// FFmpeg still lists trimmed segments, the marker floor tells the stream handler to drop them.
+func (m *ProcessManager) enforceDVRLimit() {
+	limit := int64(m.config.DVRMaxDiskMB) * 1024 * 1024
+	if limit <= 0 {
+		return
+	}
+
+	m.mu.RLock()
+	channelIDs := make([]uuid.UUID, 0, len(m.processes))
+	for channelID := range m.processes {
+		channelIDs = append(channelIDs, channelID)
+	}
+	m.mu.RUnlock()
+
+	var usages []*dvrUsage
+	var total int64
+	for _, channelID := range channelIDs {
+		dir := filepath.Join(m.hlsPath, channelID.String())
+		if _, ok := hls.ReadDVRMarker(dir); !ok {
+			continue
+		}
+		usage := measureDVR(channelID, dir)
+		usages = append(usages, usage)
+		total += usage.total
+	}
+	if total <= limit {
+		return
+	}
+
+	// Free space one segment at a time from whichever window is currently the largest
+	excess := total - int64(float64(limit)*dvrTrimTarget)
+	keep := m.config.PlaylistSize
+	if keep < minDVRSegments {
+		keep = minDVRSegments
+	}
+	trimmed := make(map[*dvrUsage]int)
+	for excess > 0 {
+		sort.Slice(usages, func(i, j int) bool { return usages[i].total > usages[j].total })
+		var largest *dvrUsage
+		for _, usage := range usages {
+			if len(usage.groups)-trimmed[usage] > keep {
+				largest = usage
+				break
+			}
+		}
+		if largest == nil {
+			break // Every window is down to the live edge
+		}
+		group := largest.groups[trimmed[largest]]
+		for _, file := range group.files {
+			os.Remove(file)
+		}
+		trimmed[largest]++
+		largest.total -= group.size
+		excess -= group.size
+	}
+
+	for usage, count := range trimmed {
+		marker, ok := hls.ReadDVRMarker(usage.dir)
+		if !ok {
+			continue // Channel stopped in the meantime
+		}
+		marker.Floor = usage.groups[count].number
+		if err := hls.WriteDVRMarker(usage.dir, *marker); err != nil {
+			logger.Error().Err(err).Str("channel_id", usage.channelID.String()).Msg("Failed to update DVR marker")
+			continue
+		}
+		kept := len(usage.groups) - count
+		logger.Warn().
+			Str("channel_id", usage.channelID.String()).
+			Int("trimmed_segments", count).
+			Int("kept_segments", kept).
+			Int("limit_mb", m.config.DVRMaxDiskMB).
+			Msg("DVR disk limit exceeded, trimmed the oldest segments")
+		m.persistLog(usage.channelID, domain.LogLevelWarning,
+			fmt.Sprintf("DVR disk limit (%d MB) exceeded: trimmed %d old segments, window now holds %d segments", m.config.DVRMaxDiskMB, count, kept))
+	}
+}
+
+// measureDVR returns the disk usage of a DVR output directory with its segments grouped by number
+func measureDVR(channelID uuid.UUID, dir string) *dvrUsage {
+	usage := &dvrUsage{channelID: channelID, dir: dir}
+	groups := make(map[int]*dvrSegmentGroup)
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		usage.total += info.Size()
+		if number, ok := hls.ParseDVRSegmentName(entry.Name()); ok {
+			group, exists := groups[number]
+			if !exists {
+				group = &dvrSegmentGroup{number: number}
+				groups[number] = group
+			}
+			group.files = append(group.files, path)
+			group.size += info.Size()
+		}
+		return nil
+	})
+
+	for _, group := range groups {
+		usage.groups = append(usage.groups, *group)
+	}
+	sort.Slice(usage.groups, func(i, j int) bool { return usage.groups[i].number < usage.groups[j].number })
+	return usage
+}
@@ -0,0 +1,187 @@
+package ffmpeg
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	keyInfoName  = "keyinfo"       // hls_key_info_file, re-read by FFmpeg at every segment (periodic_rekey)
+	keyURIPrefix = "/api/v1/keys/" // Authenticated key endpoint referenced by EXT-X-KEY
+	keptKeyFiles = 2               // Key files kept on disk, FFmpeg only needs the current one
+)
+
+// segmentNumberRegex extracts the sequence number of an opened segment
+var segmentNumberRegex = regexp.MustCompile(`segment_(\d+)`)
+
+// keyRotator rotates the content key of an encrypted channel every N segments
+// Keys are stored in the database before FFmpeg can reference them, the key files FFmpeg
+// encrypts with live outside the HLS directory so they are never served statically.
+type keyRotator struct {
+	channelID    uuid.UUID
+	dir          string
+	every        int
+	mu           sync.Mutex
+	lastRotation int      // Segment number that triggered the last rotation
+	files        []string // Key files on disk, oldest first
+}
+
+// SetKeyRepository sets the repository content keys are stored in
+func (m *ProcessManager) SetKeyRepository(repo domain.ChannelKeyRepository) {
+	m.keyRepo = repo
+}
+
+// keyDir returns the directory holding the key files of a channel
+func (m *ProcessManager) keyDir(channelID uuid.UUID) string {
+	return filepath.Join(m.config.KeyPath, channelID.String())
+}
+
+// removeKeys deletes the key files of a stopped channel (the database keeps the keys)
+func (m *ProcessManager) removeKeys(channelID uuid.UUID) {
+	if m.config.KeyPath == "" {
+		return
+	}
+	os.RemoveAll(m.keyDir(channelID))
+}
+
+// newKeyRotator prepares the first key of a channel run
+func (m *ProcessManager) newKeyRotator(channelID uuid.UUID, every int) (*keyRotator, error) {
+	if m.keyRepo == nil || m.config.KeyPath == "" {
+		return nil, fmt.Errorf("key storage is not configured")
+	}
+	dir := m.keyDir(channelID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	rotator := &keyRotator{channelID: channelID, dir: dir, every: every}
+	if err := m.rotateKey(rotator); err != nil {
+		return nil, err
+	}
+	return rotator, nil
+}
+
+// resumeKeyRotator takes over the key rotation of an adopted process and switches it to a fresh key
+// FFmpeg re-reads the key info file at every segment, so the process picks the new key up on its own.
+// lastSegment is the newest segment already written, rotations continue after it.
+func (m *ProcessManager) resumeKeyRotator(channelID uuid.UUID, every, lastSegment int) (*keyRotator, error) {
+	if m.keyRepo == nil || m.config.KeyPath == "" {
+		return nil, fmt.Errorf("key storage is not configured")
+	}
+	dir := m.keyDir(channelID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	// Key files of the previous instance, oldest first, are pruned as new keys are written
+	files, _ := filepath.Glob(filepath.Join(dir, "*.key"))
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return modTimes[files[i]].Before(modTimes[files[j]])
+	})
+
+	rotator := &keyRotator{channelID: channelID, dir: dir, every: every, lastRotation: lastSegment, files: files}
+	if err := m.rotateKey(rotator); err != nil {
+		return nil, err
+	}
+	return rotator, nil
+}
+
+// segmentNumber returns the sequence number of a segment path (0 if it has none)
+func segmentNumber(path string) int {
+	matches := segmentNumberRegex.FindStringSubmatch(filepath.Base(path))
+	if len(matches) < 2 {
+		return 0
+	}
+	number, _ := strconv.Atoi(matches[1])
+	return number
+}
+
+// rotateKey generates a new key, stores it and points the key info file at it
+// Must be called with rotator.mu held (or before the rotator is shared).
+func (m *ProcessManager) rotateKey(rotator *keyRotator) error {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	channelKey := &domain.ChannelKey{
+		ID:        uuid.New(),
+		ChannelID: rotator.channelID,
+		Key:       key,
+		CreatedAt: time.Now(),
+	}
+	if err := m.keyRepo.Create(channelKey); err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+
+	keyFile := filepath.Join(rotator.dir, channelKey.ID.String()+".key")
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return err
+	}
+
+	// Key URI, key file (no IV line: the IV is the media sequence number)
+	info := fmt.Sprintf("%s%s/%s\n%s\n", keyURIPrefix, rotator.channelID, channelKey.ID, keyFile)
+	tmp := filepath.Join(rotator.dir, keyInfoName+".tmp")
+	if err := os.WriteFile(tmp, []byte(info), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(rotator.dir, keyInfoName)); err != nil {
+		return err
+	}
+
+	rotator.files = append(rotator.files, keyFile)
+	for len(rotator.files) > keptKeyFiles {
+		os.Remove(rotator.files[0])
+		rotator.files = rotator.files[1:]
+	}
+	return nil
+}
+
+// segmentOpened rotates the key once every N segments
+// Adopted processes have no stderr, watchAdopted reports the segments of their playlist instead.
+func (m *ProcessManager) segmentOpened(process *Process, path string) {
+	rotator := process.keys
+	if rotator == nil {
+		return
+	}
+	number := segmentNumber(path)
+	if number == 0 || number%rotator.every != 0 {
+		return
+	}
+
+	// Ladder variants open the same segment number once each
+	rotator.mu.Lock()
+	if number <= rotator.lastRotation {
+		rotator.mu.Unlock()
+		return
+	}
+	rotator.lastRotation = number
+	rotator.mu.Unlock()
+
+	go func() {
+		rotator.mu.Lock()
+		defer rotator.mu.Unlock()
+		if err := m.rotateKey(rotator); err != nil {
+			logger.Error().
+				Err(err).
+				Str("channel_id", process.ChannelID.String()).
+				Msg("Failed to rotate encryption key, keeping the current key")
+			m.persistLog(process.ChannelID, domain.LogLevelError, fmt.Sprintf("Encryption key rotation failed: %v", err))
+		}
+	}()
+}
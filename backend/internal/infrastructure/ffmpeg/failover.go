@@ -0,0 +1,199 @@
+package ffmpeg
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	sourceFailoverAfter  = 3                // Failed runs in a row before switching to the next source
+	primaryProbeInterval = 30 * time.Second // How often the primary is probed while a backup is live
+	primaryProbeTimeout  = 15 * time.Second
+	primaryHealthyProbes = 2               // Successful probes in a row before returning to the primary
+	sourceSwitchTimeout  = 3 * time.Second // Grace period of the old run when switching back to the primary
+)
+
+// sourceState tracks input failover for a channel with backup sources
+// It lives from the first failed run until the channel is started or stopped manually.
+type sourceState struct {
+	index    int  // Index into channel.Sources() (0 = primary)
+	failures int  // Failed runs in a row on the current source
+	probing  bool // A primary probe goroutine is running
+}
+
+// activeSourceLocked returns the source the next run should use (must be called with m.mu held)
+func (m *ProcessManager) activeSourceLocked(channel *domain.Channel) (string, int) {
+	sources := channel.Sources()
+	if state, exists := m.sources[channel.ID]; exists && state.index < len(sources) {
+		return sources[state.index], state.index
+	}
+	return channel.SourceURL, 0
+}
+
+// recordSourceResultLocked counts a finished run against the current source and
+// moves to the next source after sourceFailoverAfter failed runs (must be called with m.mu held)
+func (m *ProcessManager) recordSourceResultLocked(channel *domain.Channel, failed bool) (switched bool, from, to string) {
+	sources := channel.Sources()
+	if len(sources) < 2 {
+		return false, "", ""
+	}
+
+	state, exists := m.sources[channel.ID]
+	if !exists {
+		if !failed {
+			return false, "", ""
+		}
+		state = &sourceState{}
+		m.sources[channel.ID] = state
+	}
+	if !failed {
+		state.failures = 0
+		return false, "", ""
+	}
+
+	state.failures++
+	if state.failures < sourceFailoverAfter {
+		return false, "", ""
+	}
+
+	from = sources[state.index%len(sources)]
+	state.index = (state.index + 1) % len(sources)
+	state.failures = 0
+	if state.index != 0 && !state.probing {
+		state.probing = true
+		go m.probePrimary(channel.ID, channel.SourceURL, state)
+	}
+	return true, from, sources[state.index]
+}
+
+// probePrimary periodically probes the primary source while a backup is live
+// and switches back once the primary answered primaryHealthyProbes times in a row.
+func (m *ProcessManager) probePrimary(channelID uuid.UUID, primary string, state *sourceState) {
+	ticker := time.NewTicker(primaryProbeInterval)
+	defer ticker.Stop()
+
+	healthy := 0
+	for range ticker.C {
+		m.mu.RLock()
+		current := m.sources[channelID] == state && state.index != 0
+		m.mu.RUnlock()
+		if !current {
+			m.mu.Lock()
+			state.probing = false
+			m.mu.Unlock()
+			return
+		}
+
+		if probeSource(m.config.BinaryPath, primary) {
+			healthy++
+		} else {
+			healthy = 0
+		}
+		if healthy < primaryHealthyProbes {
+			continue
+		}
+
+		m.mu.Lock()
+		if m.sources[channelID] != state {
+			state.probing = false
+			m.mu.Unlock()
+			return
+		}
+		// The next run picks the primary up
+		state.index = 0
+		state.failures = 0
+		state.probing = false
+		process, running := m.processes[channelID]
+		if !running {
+			// Waiting for an auto-restart
+			m.mu.Unlock()
+			m.persistLog(channelID, domain.LogLevelInfo, "Primary source is healthy again, next restart uses it")
+			return
+		}
+		process.switchingSource = true
+		m.mu.Unlock()
+
+		logger.Info().
+			Str("channel_id", channelID.String()).
+			Str("source_url", primary).
+			Msg("Primary source is healthy again, switching back")
+		m.persistLog(channelID, domain.LogLevelInfo, "Primary source is healthy again, switching back")
+		m.refreshSourceProbe(primary)
+		m.interruptForSwitch(process)
+		return
+	}
+}
+
+// interruptForSwitch stops a process so handleExit restarts it on the next source
+// Unlike Stop the process stays in the map, so the output directory and DVR window are kept.
+// Channels with backup sources run with append_list: the new run appends to the playlist
+// behind an EXT-X-DISCONTINUITY, continuing the media sequence and segment numbers, and
+// deletes the segments of the previous run as they leave the playlist.
+func (m *ProcessManager) interruptForSwitch(process *Process) {
+	if process.Cmd == nil || process.Cmd.Process == nil {
+		return
+	}
+	pid := process.Cmd.Process.Pid
+	signal := func(sig syscall.Signal) {
+		if pgid, err := syscall.Getpgid(pid); err == nil {
+			syscall.Kill(-pgid, sig)
+		} else {
+			process.Cmd.Process.Signal(sig)
+		}
+	}
+
+	// SIGTERM lets FFmpeg finish the segment in progress
+	signal(syscall.SIGTERM)
+	select {
+	case <-process.done:
+	case <-time.After(sourceSwitchTimeout):
+		logger.Warn().
+			Str("channel_id", process.ChannelID.String()).
+			Int("pid", pid).
+			Msg("Process did not exit for the source switch, forcing kill with SIGKILL")
+		signal(syscall.SIGKILL)
+	}
+}
+
+// ActiveSource returns the source currently fed to FFmpeg for a running channel
+func (m *ProcessManager) ActiveSource(channelID uuid.UUID) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	process, exists := m.processes[channelID]
+	if !exists {
+		return "", false
+	}
+	return process.SourceURL, true
+}
+
+// probeSource checks that a source answers with a video stream
+func probeSource(ffmpegPath, url string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), primaryProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, ffprobePath(ffmpegPath),
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_type",
+		"-of", "csv=p=0",
+		url,
+	).Output()
+	return err == nil && strings.Contains(string(output), "video")
+}
+
+// ffprobePath returns the ffprobe binary shipped next to the FFmpeg binary
+func ffprobePath(ffmpegPath string) string {
+	if dir := filepath.Dir(ffmpegPath); dir != "." && filepath.Base(ffmpegPath) == "ffmpeg" {
+		return filepath.Join(dir, "ffprobe")
+	}
+	return "ffprobe"
+}
@@ -0,0 +1,151 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// masterPlaylistName is the HLS master playlist written for ladder and multi-audio channels
+const masterPlaylistName = "master.m3u8"
+
+// rendition is a resolved ladder entry with parsed dimensions and rate control values
+type rendition struct {
+	name    string
+	width   int
+	height  int
+	bitrate string
+	maxrate string
+	bufsize string
+}
+
+// doubleRate returns twice the given rate (e.g. "4000k" -> "8000k"), used as default bufsize
+func doubleRate(rate string) (string, bool) {
+	isMB := strings.HasSuffix(rate, "M")
+	num := strings.TrimSuffix(strings.TrimSuffix(rate, "k"), "M")
+	val, err := strconv.Atoi(num)
+	if err != nil {
+		return "", false
+	}
+	if isMB {
+		return fmt.Sprintf("%dM", val*2), true
+	}
+	return fmt.Sprintf("%dk", val*2), true
+}
+
+// resolveLadder converts the channel ladder into renditions
+// Returns nil if the channel has no ladder configured
+func resolveLadder(channel *domain.Channel) ([]rendition, error) {
+	if channel.OutputConfig == nil || len(channel.OutputConfig.Ladder) == 0 {
+		return nil, nil
+	}
+
+	renditions := make([]rendition, 0, len(channel.OutputConfig.Ladder))
+	for i, entry := range channel.OutputConfig.Ladder {
+		width, height, ok := domain.ParseResolution(entry.Resolution)
+		if !ok {
+			return nil, fmt.Errorf("invalid resolution for rendition %d: %q", i, entry.Resolution)
+		}
+		name := entry.Name
+		if name == "" {
+			name = fmt.Sprintf("%dp", height)
+		}
+		bitrate := entry.Bitrate
+		if bitrate == "" {
+			bitrate = entry.Maxrate
+		}
+		maxrate := entry.Maxrate
+		if maxrate == "" {
+			maxrate = bitrate
+		}
+		bufsize := entry.Bufsize
+		if bufsize == "" {
+			if doubled, ok := doubleRate(maxrate); ok {
+				bufsize = doubled
+			}
+		}
+		renditions = append(renditions, rendition{
+			name:    name,
+			width:   width,
+			height:  height,
+			bitrate: bitrate,
+			maxrate: maxrate,
+			bufsize: bufsize,
+		})
+	}
+	return renditions, nil
+}
+
+// largestRendition returns the index of the rendition with the most pixels
+// The source is scaled (and overlaid) at this size once before being split
+func largestRendition(renditions []rendition) int {
+	largest := 0
+	for i, r := range renditions {
+		if r.width*r.height > renditions[largest].width*renditions[largest].height {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// ladderSplitFilters splits the composed [base] video into one scaled output per rendition
+// Outputs are labelled [v0], [v1], ... in ladder order; scaleFilter comes from the encoder backend
+func ladderSplitFilters(renditions []rendition, scaleFilter func(width, height int) string) []string {
+	splitLabels := make([]string, len(renditions))
+	for i := range renditions {
+		splitLabels[i] = fmt.Sprintf("[s%d]", i)
+	}
+	filters := []string{fmt.Sprintf("[base]split=%d%s", len(renditions), strings.Join(splitLabels, ""))}
+	for i, r := range renditions {
+		filters = append(filters, fmt.Sprintf("[s%d]%s[v%d]", i, scaleFilter(r.width, r.height), i))
+	}
+	return filters
+}
+
+// ladderMapArgs maps every rendition with its own copy of the audio track ("" = video only)
+func ladderMapArgs(renditions []rendition, audioInput string) []string {
+	var args []string
+	for i := range renditions {
+		args = append(args, "-map", fmt.Sprintf("[v%d]", i))
+		if audioInput != "" {
+			args = append(args, "-map", audioInput)
+		}
+	}
+	return args
+}
+
+// ladderRateArgs returns per-stream rate control arguments for each rendition
+func ladderRateArgs(renditions []rendition) []string {
+	var args []string
+	for i, r := range renditions {
+		args = append(args,
+			fmt.Sprintf("-maxrate:v:%d", i), r.maxrate,
+			fmt.Sprintf("-bufsize:v:%d", i), r.bufsize,
+		)
+	}
+	return args
+}
+
+// ladderTargetRateArgs returns per-stream target bitrates for each rendition
+func ladderTargetRateArgs(renditions []rendition) []string {
+	var args []string
+	for i, r := range renditions {
+		args = append(args, fmt.Sprintf("-b:v:%d", i), r.bitrate)
+	}
+	return args
+}
+
+// prepareVariantDirs creates one sub-directory per HLS variant (ladder rendition or audio track)
+// inside the channel output directory
+func prepareVariantDirs(outputDir string, variants []string) error {
+	for _, name := range variants {
+		if err := os.MkdirAll(filepath.Join(outputDir, name), 0755); err != nil {
+			return fmt.Errorf("failed to create variant directory %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ffmpeg
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	logSinkBufferSize    = 10000           // Lines buffered before new lines are dropped
+	logSinkBatchSize     = 500             // Lines written per INSERT
+	logSinkFlushInterval = 2 * time.Second // Maximum delay before buffered lines are written
+	maxLogMessageLength  = 4096
+)
+
+var (
+	// FFmpeg level prefix enabled by "-loglevel level+...", e.g. "[hls @ 0x55d0] [warning] ..."
+	logLevelPrefixRegex = regexp.MustCompile(`\[(trace|debug|verbose|info|warning|error|fatal|panic)\] `)
+	logErrorRegex       = regexp.MustCompile(`(?i)(error|failed|cannot|could not|unable|invalid)`)
+	// -progress key=value lines (frame=, fps=, out_time=, progress=continue, ...)
+	progressLineRegex = regexp.MustCompile(`^[a-z_0-9]+=\S*$`)
+)
+
+// logSink buffers channel log lines and writes them to the repository in batches
+// Writes never block the FFmpeg stderr reader: lines are dropped when the buffer is full.
+type logSink struct {
+	repo    domain.ChannelLogRepository
+	entries chan *domain.ChannelLog
+	dropped atomic.Int64
+}
+
+func newLogSink(repo domain.ChannelLogRepository) *logSink {
+	sink := &logSink{
+		repo:    repo,
+		entries: make(chan *domain.ChannelLog, logSinkBufferSize),
+	}
+	go sink.run()
+	return sink
+}
+
+// Write queues a log line for persistence
+func (s *logSink) Write(channelID uuid.UUID, level domain.LogLevel, message string) {
+	entry := &domain.ChannelLog{
+		ChannelID: channelID,
+		Level:     level,
+		Message:   sanitizeLogMessage(message),
+		CreatedAt: time.Now(),
+	}
+	select {
+	case s.entries <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run flushes buffered lines when a batch is full or the flush interval elapses
+func (s *logSink) run() {
+	ticker := time.NewTicker(logSinkFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.ChannelLog, 0, logSinkBatchSize)
+	for {
+		select {
+		case entry := <-s.entries:
+			batch = append(batch, entry)
+			if len(batch) >= logSinkBatchSize {
+				batch = s.flush(batch)
+			}
+		case <-ticker.C:
+			batch = s.flush(batch)
+		}
+	}
+}
+
+func (s *logSink) flush(batch []*domain.ChannelLog) []*domain.ChannelLog {
+	if dropped := s.dropped.Swap(0); dropped > 0 {
+		logger.Warn().
+			Int64("dropped", dropped).
+			Msg("Channel log buffer full, log lines were dropped")
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := s.repo.InsertBatch(batch); err != nil {
+		logger.Error().
+			Err(err).
+			Int("lines", len(batch)).
+			Msg("Failed to persist channel logs")
+	}
+	return batch[:0]
+}
+
+// classifyLogLine returns the level of an FFmpeg stderr line and whether it should be persisted
+// Progress key=value lines are metrics, not log output, and are not persisted.
+func classifyLogLine(line string) (domain.LogLevel, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || progressLineRegex.MatchString(trimmed) || segmentOpenRegex.MatchString(trimmed) {
+		return "", false
+	}
+
+	if matches := logLevelPrefixRegex.FindStringSubmatch(line); len(matches) > 1 {
+		switch matches[1] {
+		case "fatal", "panic", "error":
+			return domain.LogLevelError, true
+		case "warning":
+			return domain.LogLevelWarning, true
+		case "info":
+			return domain.LogLevelInfo, true
+		default:
+			return domain.LogLevelDebug, true
+		}
+	}
+
+	// Lines without a level prefix (raw stderr output)
+	if logErrorRegex.MatchString(line) {
+		return domain.LogLevelError, true
+	}
+	return domain.LogLevelInfo, true
+}
+
+// sanitizeLogMessage makes a line safe for a TEXT column (valid UTF-8, no NUL, bounded length)
+func sanitizeLogMessage(message string) string {
+	message = strings.ToValidUTF8(strings.ReplaceAll(message, "\x00", ""), "?")
+	if len(message) > maxLogMessageLength {
+		message = strings.ToValidUTF8(message[:maxLogMessageLength], "")
+	}
+	return message
+}
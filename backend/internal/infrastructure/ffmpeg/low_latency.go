@@ -0,0 +1,25 @@
+package ffmpeg
+
+import (
+	"math"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+)
+
+// lowLatencyMarker returns how a low-latency channel splits its segments into parts
+// FFmpeg writes every part as its own segment; the part duration is rounded so a
+// segment (one GOP, keyframes stay at segmentTime) holds a whole number of parts.
+func lowLatencyMarker(config *domain.LowLatencyConfig, segmentTime int) *hls.Marker {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	partsPerSegment := int(math.Round(float64(segmentTime) / config.PartTarget()))
+	if partsPerSegment < 1 {
+		partsPerSegment = 1
+	}
+	return &hls.Marker{
+		PartTarget:      float64(segmentTime) / float64(partsPerSegment),
+		PartsPerSegment: partsPerSegment,
+	}
+}
@@ -0,0 +1,134 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+// overlayChain is the part of the filter graph composing the overlay layers over the scaled input
+type overlayChain struct {
+	inputs  []string // -i arguments of the image layers, read as inputs 1..n
+	filters []string
+	images  int
+}
+
+// buildOverlayChain composes the enabled layers over [base] in drawing order, ending in [output]
+// Image layers become additional inputs starting at input 1, text and clock layers are drawn.
+// Percent sizes and margins resolve against the composed frame (the largest ladder rendition),
+// the renditions scaled from it keep the same layout. Without enabled layers the chain is empty.
+func (m *ProcessManager) buildOverlayChain(layers []domain.OverlayLayer, base, output string, frameWidth, frameHeight int) (*overlayChain, error) {
+	chain := &overlayChain{}
+	active := domain.ActiveOverlays(layers)
+	previous := base
+	for i, layer := range active {
+		next := fmt.Sprintf("ov%d", i)
+		if i == len(active)-1 {
+			next = output
+		}
+
+		switch layer.Type {
+		case domain.OverlayImage:
+			path, err := m.overlayFile(layer.Path)
+			if err != nil {
+				return nil, fmt.Errorf("overlay image not found: %s", path)
+			}
+			chain.images++
+			chain.inputs = append(chain.inputs, "-i", path)
+			width, height := layer.ImageSize(frameWidth, frameHeight)
+			x, y := overlayPosition(layer, frameWidth, frameHeight, "main_w", "main_h", "overlay_w", "overlay_h")
+			chain.filters = append(chain.filters,
+				fmt.Sprintf("[%d:v]scale=%d:%d,format=rgba,colorchannelmixer=aa=%f[img%d]",
+					chain.images, width, height, layer.Opacity, i),
+				fmt.Sprintf("[%s][img%d]overlay=x=%s:y=%s[%s]", previous, i, x, y, next),
+			)
+		default:
+			drawtext, err := m.drawtextFilter(layer, frameWidth, frameHeight)
+			if err != nil {
+				return nil, err
+			}
+			chain.filters = append(chain.filters, fmt.Sprintf("[%s]%s[%s]", previous, drawtext, next))
+		}
+		previous = next
+	}
+	return chain, nil
+}
+
+// drawtextFilter returns the drawtext filter of a text or clock layer
+func (m *ProcessManager) drawtextFilter(layer domain.OverlayLayer, frameWidth, frameHeight int) (string, error) {
+	options := []string{}
+	if layer.Font != "" {
+		path, err := m.overlayFile(layer.Font)
+		if err != nil {
+			return "", fmt.Errorf("overlay font not found: %s", path)
+		}
+		options = append(options, "fontfile="+escapeFilterValue(path))
+	}
+
+	if layer.Type == domain.OverlayClock {
+		// Colons separate the arguments of %{localtime}, so the format escapes its own
+		format := strings.ReplaceAll(layer.Format, ":", `\:`)
+		options = append(options, "text="+escapeFilterValue("%{localtime:"+format+"}"))
+	} else {
+		options = append(options, "expansion=none", "text="+escapeFilterValue(layer.Text))
+	}
+
+	x, y := overlayPosition(layer, frameWidth, frameHeight, "w", "h", "text_w", "text_h")
+	options = append(options,
+		"x="+x,
+		"y="+y,
+		fmt.Sprintf("fontsize=%d", layer.TextSize(frameHeight)),
+		"fontcolor="+layer.FontColor,
+		fmt.Sprintf("alpha=%g", layer.Opacity),
+	)
+	if layer.Box {
+		options = append(options,
+			"box=1",
+			"boxcolor="+layer.BoxColor,
+			fmt.Sprintf("boxborderw=%d", layer.BoxBorder),
+		)
+	}
+	return "drawtext=" + strings.Join(options, ":"), nil
+}
+
+// overlayPosition returns the x and y expressions placing a layer in the frame
+// frameW/frameH and layerW/layerH are the filter's names for the frame and layer size, so
+// right and bottom anchors follow the rendered size (auto height images, clock text).
+func overlayPosition(layer domain.OverlayLayer, frameWidth, frameHeight int, frameW, frameH, layerW, layerH string) (string, string) {
+	if layer.Anchor == "" {
+		return strconv.Itoa(layer.X), strconv.Itoa(layer.Y)
+	}
+	marginX, marginY := layer.Margins(frameWidth, frameHeight)
+	x, y := strconv.Itoa(marginX), strconv.Itoa(marginY)
+	switch layer.Anchor {
+	case domain.AnchorCenter:
+		x = fmt.Sprintf("(%s-%s)/2", frameW, layerW)
+		y = fmt.Sprintf("(%s-%s)/2", frameH, layerH)
+	case domain.AnchorTopRight, domain.AnchorBottomRight:
+		x = fmt.Sprintf("%s-%s-%d", frameW, layerW, marginX)
+	}
+	if layer.Anchor == domain.AnchorBottomLeft || layer.Anchor == domain.AnchorBottomRight {
+		y = fmt.Sprintf("%s-%s-%d", frameH, layerH, marginY)
+	}
+	return x, y
+}
+
+// overlayFile resolves an overlay file relative to the logo directory and checks it exists
+func (m *ProcessManager) overlayFile(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.logoPath, path)
+	}
+	_, err := os.Stat(path)
+	return path, err
+}
+
+// escapeFilterValue escapes a filter option value for use inside -filter_complex
+// The value is escaped for the option parser first and for the filter graph parser second.
+func escapeFilterValue(value string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
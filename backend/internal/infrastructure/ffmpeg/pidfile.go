@@ -0,0 +1,301 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const adoptedPollInterval = 2 * time.Second
+
+// pidFilePath returns the pidfile of a channel ("" when pidfiles are disabled)
+func (m *ProcessManager) pidFilePath(channelID uuid.UUID) string {
+	if m.config.RunPath == "" {
+		return ""
+	}
+	return filepath.Join(m.config.RunPath, channelID.String()+".pid")
+}
+
+// writePidFile records the FFmpeg pid of a channel so a later instance can find the process
+func (m *ProcessManager) writePidFile(channelID uuid.UUID, pid int) {
+	path := m.pidFilePath(channelID)
+	if path == "" {
+		return
+	}
+	err := os.MkdirAll(m.config.RunPath, 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0644)
+	}
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Msg("Failed to write FFmpeg pidfile")
+	}
+}
+
+// removePidFile deletes the pidfile of a channel if it still belongs to pid
+// (a restarted process may already have replaced it)
+func (m *ProcessManager) removePidFile(channelID uuid.UUID, pid int) {
+	path := m.pidFilePath(channelID)
+	if path == "" {
+		return
+	}
+	if current, err := readPidFile(path); err == nil && current == pid {
+		os.Remove(path)
+	}
+}
+
+func readPidFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// RecoverProcesses handles FFmpeg processes left behind by a previous backend instance
+// With adopt set, processes of channels that should be running are taken over and returned;
+// every other leftover process is killed so it does not write into a cleaned HLS directory.
+func (m *ProcessManager) RecoverProcesses(channels []*domain.Channel, adopt bool) []uuid.UUID {
+	if m.config.RunPath == "" {
+		return nil
+	}
+	files, _ := filepath.Glob(filepath.Join(m.config.RunPath, "*.pid"))
+
+	byID := make(map[uuid.UUID]*domain.Channel, len(channels))
+	for _, channel := range channels {
+		byID[channel.ID] = channel
+	}
+
+	var adopted []uuid.UUID
+	for _, file := range files {
+		channelID, err := uuid.Parse(strings.TrimSuffix(filepath.Base(file), ".pid"))
+		if err != nil {
+			continue
+		}
+		pid, err := readPidFile(file)
+		if err != nil || !isChannelProcess(pid, channelID) {
+			os.Remove(file) // Stale pidfile
+			continue
+		}
+
+		channel, exists := byID[channelID]
+		if adopt && exists && channel.DesiredRunning {
+			if info, err := os.Stat(file); err == nil {
+				m.adopt(channel, pid, info.ModTime())
+				adopted = append(adopted, channelID)
+				continue
+			}
+		}
+
+		logger.Info().
+			Str("channel_id", channelID.String()).
+			Int("pid", pid).
+			Msg("Killing FFmpeg process left by previous instance")
+		if pgid, err := syscall.Getpgid(pid); err == nil {
+			syscall.Kill(-pgid, syscall.SIGKILL)
+		} else {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+		reapProcess(pid)
+		os.Remove(file)
+	}
+	return adopted
+}
+
+// adopt registers a running FFmpeg process of a previous instance as the channel's process
+func (m *ProcessManager) adopt(channel *domain.Channel, pid int, startedAt time.Time) {
+	proc, _ := os.FindProcess(pid) // Always succeeds on Unix
+	process := &Process{
+		ChannelID: channel.ID,
+		Channel:   channel,
+		Cmd:       &exec.Cmd{Process: proc},
+		Cancel:    func() {},
+		StartedAt: startedAt,
+		Metrics:   &domain.ProcessMetrics{},
+		Logs:      []string{"[INFO] Process adopted from previous backend instance"},
+		SourceURL: channel.SourceURL,
+		Adopted:   true,
+		done:      make(chan struct{}),
+		// The watchdog gives the adopted process a full stall timeout to list its next segment
+		lastSegmentAt: time.Now(),
+	}
+
+	// Rebuild what the previous instance knew about the process: its encoder device
+	// and, for encrypted channels, the key rotation (driven by the playlist instead of stderr)
+	process.Backend, process.GPUIndex = m.adoptedEncoder(pid)
+	outputDir := filepath.Join(m.hlsPath, channel.ID.String())
+	playlist := mediaPlaylistPath(outputDir)
+	if channel.OutputConfig != nil && channel.OutputConfig.Encryption != nil && channel.OutputConfig.Encryption.Enabled {
+		encryption := channel.OutputConfig.Encryption.Normalized()
+		segment, _ := lastPlaylistSegment(playlist)
+		keys, err := m.resumeKeyRotator(channel.ID, encryption.RotateSegments, segmentNumber(segment))
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("channel_id", channel.ID.String()).
+				Msg("Failed to resume key rotation of adopted process")
+			m.persistLog(channel.ID, domain.LogLevelError, fmt.Sprintf("Encryption keys of the adopted process are not rotated: %v", err))
+		}
+		process.keys = keys
+	}
+	m.startRelays(process, outputDir)
+
+	m.mu.Lock()
+	m.processes[channel.ID] = process
+	m.mu.Unlock()
+	m.acquireSession(process.Backend, process.GPUIndex)
+
+	go m.watchAdopted(process, playlist)
+	if m.config.StallTimeout > 0 {
+		go m.watchStall(process)
+	}
+
+	logger.Info().
+		Str("channel_id", channel.ID.String()).
+		Str("channel_name", channel.Name).
+		Int("pid", pid).
+		Msg("Adopted FFmpeg process from previous instance")
+	m.persistLog(channel.ID, domain.LogLevelInfo, fmt.Sprintf("Adopted FFmpeg process %d from previous backend instance", pid))
+}
+
+// watchAdopted polls an adopted process (it is not our child, so it cannot be waited for)
+// The newest segment of its playlist stands in for the segment opens FFmpeg reports on stderr.
+func (m *ProcessManager) watchAdopted(process *Process, playlist string) {
+	pid := process.Cmd.Process.Pid
+	ticker := time.NewTicker(adoptedPollInterval)
+	defer ticker.Stop()
+
+	last, _ := lastPlaylistSegment(playlist)
+	for range ticker.C {
+		if !isProcessAlive(pid) {
+			m.handleExit(process, fmt.Errorf("adopted process %d exited", pid))
+			return
+		}
+		segment, ok := lastPlaylistSegment(playlist)
+		if !ok || segment == last {
+			continue
+		}
+		last = segment
+		m.segmentOpened(process, segment)
+		process.mu.Lock()
+		process.lastSegmentAt = time.Now()
+		process.mu.Unlock()
+	}
+}
+
+// adoptedEncoder identifies the encoder backend and device of an adopted process from its command line
+// Returns "" when the command line cannot be read (the process is then not counted against a device).
+func (m *ProcessManager) adoptedEncoder(pid int) (domain.EncoderBackend, int) {
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return "", 0
+	}
+	argv := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	for _, backend := range m.backends {
+		name := backend.Name()
+		for device := 0; device < m.deviceCounts[name]; device++ {
+			if args := backend.InputArgs(device); len(args) > 0 && containsArgs(argv, args) {
+				return name, device
+			}
+		}
+	}
+	return domain.EncoderBackendSoftware, 0 // No device initialisation arguments
+}
+
+// containsArgs reports whether args appear in argv as a contiguous sequence
+func containsArgs(argv, args []string) bool {
+	for i := 0; i+len(args) <= len(argv); i++ {
+		match := true
+		for j, arg := range args {
+			if argv[i+j] != arg {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaPlaylistPath returns the playlist of a channel directory that lists every new segment
+// (index.m3u8, or the first variant playlist of ladder and multi-track channels; "" if none)
+func mediaPlaylistPath(outputDir string) string {
+	index := filepath.Join(outputDir, "index.m3u8")
+	if _, err := os.Stat(index); err == nil {
+		return index
+	}
+	variants, _ := filepath.Glob(filepath.Join(outputDir, "*", "index.m3u8"))
+	if len(variants) == 0 {
+		return ""
+	}
+	return variants[0]
+}
+
+// lastPlaylistSegment returns the URI of the last segment listed in a media playlist
+func lastPlaylistSegment(path string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+// isChannelProcess checks that pid is alive and was started for the channel
+// (its command line contains the channel's output directory)
+func isChannelProcess(pid int, channelID uuid.UUID) bool {
+	if pid <= 0 || !isProcessAlive(pid) {
+		return false
+	}
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(cmdline, []byte(channelID.String()))
+}
+
+// isProcessAlive reports whether pid exists and is not a zombie
+func isProcessAlive(pid int) bool {
+	reapProcess(pid)
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true // No procfs, trust the signal check
+	}
+	// Format: pid (comm) state ...; comm may contain spaces
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
+
+// reapProcess collects an exited process if it was re-parented to us (backend running as PID 1)
+func reapProcess(pid int) {
+	var status syscall.WaitStatus
+	syscall.Wait4(pid, &status, syscall.WNOHANG, nil)
+}
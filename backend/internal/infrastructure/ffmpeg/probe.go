@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const sourceProbeTimeout = 10 * time.Second
+
+// sourceStreams are the audio and subtitle streams of a source, in ffprobe order per type
+type sourceStreams struct {
+	audio     []audioStream
+	subtitles []subtitleStream
+}
+
+// probeSourceStreams lists the audio and subtitle streams of a source
+func probeSourceStreams(ffmpegPath, url string) (*sourceStreams, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sourceProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, ffprobePath(ffmpegPath),
+		"-v", "error",
+		"-show_entries", "stream=codec_type,codec_name,channels:stream_tags=language",
+		"-of", "json",
+		url,
+	).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+			Channels  int    `json:"channels"`
+			Tags      struct {
+				Language string `json:"language"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	streams := &sourceStreams{}
+	for _, s := range result.Streams {
+		language := strings.ToLower(s.Tags.Language)
+		if language == "und" {
+			language = ""
+		}
+		switch s.CodecType {
+		case "audio":
+			streams.audio = append(streams.audio, audioStream{codec: s.CodecName, channels: s.Channels, language: language})
+		case "subtitle":
+			streams.subtitles = append(streams.subtitles, subtitleStream{codec: s.CodecName, language: language})
+		}
+	}
+	return streams, nil
+}
+
+// refreshSourceProbe probes the streams of the source the next run uses
+// Called without m.mu held, probing a remote source can take seconds. If the probe fails the
+// cached result is dropped and the start falls back to mapping the selected tracks blindly.
+func (m *ProcessManager) refreshSourceProbe(source string) {
+	streams, err := probeSourceStreams(m.config.BinaryPath, source)
+
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+	if err != nil {
+		delete(m.sourceProbes, source)
+		return
+	}
+	m.sourceProbes[source] = streams
+}
+
+// probedStreams returns the cached streams of a source
+func (m *ProcessManager) probedStreams(source string) (*sourceStreams, bool) {
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+	streams, ok := m.sourceProbes[source]
+	return streams, ok
+}
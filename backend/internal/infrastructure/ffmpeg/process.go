@@ -8,7 +8,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -17,6 +16,8 @@ import (
 	"time"
 
 	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/dash"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
 	"github.com/cashbacktv/backend/internal/pkg/logger"
 	"github.com/google/uuid"
 )
@@ -42,9 +43,19 @@ type ProcessManager struct {
 	numaNodeCount    int    // Number of NUMA nodes available
 	numaNodeCounter  int    // Counter for round-robin NUMA node assignment
 	numaMu           sync.Mutex // Mutex for NUMA node counter
-	gpuCount         int    // Number of available GPUs
-	gpuIndexCounter  int    // Counter for round-robin GPU assignment
-	gpuMu            sync.Mutex // Mutex for GPU index counter
+	backends         []Backend // Encoder backends in auto-selection order
+	encoders         map[string]bool // Encoders compiled into FFmpeg (nil = unknown)
+	deviceCounts     map[domain.EncoderBackend]int // Detected devices per backend
+	deviceCounters   map[domain.EncoderBackend]int // Round-robin device counters per backend
+	deviceSessions   map[domain.EncoderBackend]map[int]int // Running encoder sessions per backend device
+	gpuMu            sync.Mutex // Mutex for device counters and sessions
+	logSink          *logSink // Persists FFmpeg log lines (nil = in-memory only)
+	restarts         map[uuid.UUID]*restartState // Auto-restart state per channel (guarded by mu)
+	sources          map[uuid.UUID]*sourceState  // Input failover state per channel (guarded by mu)
+	slates           map[uuid.UUID]*slateProcess // Slates on air while the channel source is down (guarded by mu)
+	sourceProbes     map[string]*sourceStreams // Audio and subtitle streams per source URL, probed before each start
+	probeMu          sync.Mutex // Mutex for source probes
+	keyRepo          domain.ChannelKeyRepository // Content keys of encrypted channels (nil = encryption unavailable)
 }
 
 // Config holds FFmpeg configuration
@@ -54,6 +65,13 @@ type Config struct {
 	PlaylistSize  int
 	DefaultPreset string
 	DefaultBitrate string
+	EncoderBackend string // auto, nvenc, vaapi, qsv or software
+	StallTimeout   int    // Seconds without frame or segment progress before the watchdog restarts a process (0 = disabled)
+	RunPath        string // Directory for pidfiles used to recover processes after a backend restart ("" = disabled)
+	MaxSessionsPerGPU int    // Encoder sessions allowed per hardware device (0 = unlimited)
+	MinFreeMemoryMB   int    // Available memory required to start another process (0 = not checked)
+	KeyPath           string // Key files of encrypted channels, outside the HLS path ("" = encryption unavailable)
+	DVRMaxDiskMB      int    // Disk space shared by all DVR windows, the oldest segments are trimmed beyond it (0 = unlimited)
 }
 
 // Process represents a running FFmpeg process
@@ -65,7 +83,20 @@ type Process struct {
 	StartedAt time.Time
 	Metrics   *domain.ProcessMetrics
 	Logs      []string
+	InputBitrate  int    // Source bitrate in kbps (from the input header)
+	OutputBitrate int    // Output bitrate in kbps (measured from completed segments)
+	LastError     string // Most recent error line
 	GPUIndex  int // GPU index used by this process (for load balancing)
+	Backend   domain.EncoderBackend // Encoder backend used by this process
+	SourceURL   string // Input fed to FFmpeg (primary or backup source)
+	SourceIndex int    // 0 = primary, 1+ = backup sources
+	StallReason string // Set when the watchdog killed the process
+	Adopted     bool   // Started by a previous backend instance (no stderr, not a child process)
+	done        chan struct{} // Closed once the process has exited
+	relays      []*pushRelay  // Push destination relays (set before the process is published)
+	keys        *keyRotator   // Content key rotation of encrypted channels
+	lastSegmentAt time.Time   // Newest segment opened (stderr) or listed (adopted playlist), read by the watchdog
+	switchingSource bool      // Stopped to switch back to the primary source, restarted at once on exit (guarded by m.mu)
 	mu        sync.RWMutex
 	logMu     sync.Mutex
 	// CPU tracking for accurate percentage calculation
@@ -124,12 +155,20 @@ func NewProcessManagerWithCallback(config *Config, hlsPath, logoPath string, set
 		numaNodeCount = 1 // Fallback to single node if detection fails
 	}
 	
-	// Detect GPU count for load balancing
-	gpuCount := detectGPUCount()
+	// Detect encoder backends and their devices for load balancing
+	backends := newBackends()
+	deviceCounts := make(map[domain.EncoderBackend]int, len(backends))
+	for _, backend := range backends {
+		deviceCounts[backend.Name()] = backend.Detect()
+	}
+	encoders := detectEncoders(config.BinaryPath)
 	
 	logger.Info().
-		Int("gpu_count", gpuCount).
-		Msg("GPU detection completed")
+		Int("gpu_count", deviceCounts[domain.EncoderBackendNVENC]).
+		Int("vaapi_devices", deviceCounts[domain.EncoderBackendVAAPI]).
+		Int("qsv_devices", deviceCounts[domain.EncoderBackendQSV]).
+		Str("encoder_backend", config.EncoderBackend).
+		Msg("Encoder backend detection completed")
 	
 	return &ProcessManager{
 		processes:            make(map[uuid.UUID]*Process),
@@ -141,8 +180,15 @@ func NewProcessManagerWithCallback(config *Config, hlsPath, logoPath string, set
 		statusCallback:       statusCallback,
 		numaNodeCount:        numaNodeCount,
 		numaNodeCounter:      0,
-		gpuCount:             gpuCount,
-		gpuIndexCounter:      0,
+		backends:             backends,
+		encoders:             encoders,
+		deviceCounts:         deviceCounts,
+		deviceCounters:       make(map[domain.EncoderBackend]int),
+		deviceSessions:       make(map[domain.EncoderBackend]map[int]int),
+		restarts:             make(map[uuid.UUID]*restartState),
+		sources:              make(map[uuid.UUID]*sourceState),
+		slates:               make(map[uuid.UUID]*slateProcess),
+		sourceProbes:         make(map[string]*sourceStreams),
 	}
 }
 
@@ -153,15 +199,46 @@ func (m *ProcessManager) SetStatusCallback(callback StatusUpdateCallback) {
 	m.statusCallback = callback
 }
 
+// SetLogRepository enables persisting FFmpeg log lines to the channel log repository
+func (m *ProcessManager) SetLogRepository(repo domain.ChannelLogRepository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logSink = newLogSink(repo)
+}
+
+// persistLog queues a log line for the channel log history (no-op without a log repository)
+func (m *ProcessManager) persistLog(channelID uuid.UUID, level domain.LogLevel, message string) {
+	if m.logSink != nil {
+		m.logSink.Write(channelID, level, message)
+	}
+}
+
 // Start starts transcoding for a channel
+// A manual start cancels a pending auto-restart, resets the restart counters and returns to the primary source.
 func (m *ProcessManager) Start(channel *domain.Channel) error {
+	// A manual start always begins on the primary source
+	m.refreshSourceProbe(channel.SourceURL)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.cancelRestartLocked(channel.ID)
+	delete(m.sources, channel.ID)
+	m.takeSlateLocked(channel.ID).stop()
+	return m.startLocked(channel)
+}
+
+// startLocked launches the FFmpeg process for a channel (must be called with m.mu held)
+func (m *ProcessManager) startLocked(channel *domain.Channel) error {
 	if _, exists := m.processes[channel.ID]; exists {
 		return fmt.Errorf("channel %s is already running", channel.ID)
 	}
 
+	// Refuse the start if the node is out of capacity
+	if err := m.admitLocked(); err != nil {
+		return err
+	}
+
 	// Get active process count before building args (for thread calculation)
 	activeProcessCount := len(m.processes)
 	
@@ -173,49 +250,81 @@ func (m *ProcessManager) Start(channel *domain.Channel) error {
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
+
 	
-	// Build FFmpeg command and get GPU index
-	args, gpuIndex, err := m.buildArgs(channel, outputDir, activeProcessCount)
+	// Feed the active source (a backup after failover) to FFmpeg
+	source, sourceIndex := m.activeSourceLocked(channel)
+	input := *channel
+	input.SourceURL = source
+	
+	// Build FFmpeg command and get the selected encoder backend/device
+	plan, err := m.buildArgs(&input, outputDir, activeProcessCount, false, false)
 	if err != nil {
 		return fmt.Errorf("failed to build FFmpeg args: %w", err)
 	}
-	
-	ctx, cancel := context.WithCancel(context.Background())
-	
-	// Use numactl to bind to NUMA node if available and multiple nodes detected
-	// Fallback to normal FFmpeg if numactl is not available (safe default)
-	var cmd *exec.Cmd
-	useNumactl := false
-	if m.numaNodeCount > 1 && runtime.GOOS == "linux" {
-		// Safely check if numactl is available (must not block FFmpeg startup)
-		if isNumactlAvailable() {
-			useNumactl = true
-			numaNode := m.getNextNUMANode()
-			// Wrap FFmpeg command with numactl for NUMA binding
-			// --cpunodebind: bind to CPUs on this NUMA node
-			// --membind: prefer memory from this NUMA node
-			numactlArgs := []string{
-				fmt.Sprintf("--cpunodebind=%d", numaNode),
-				fmt.Sprintf("--membind=%d", numaNode),
-				m.config.BinaryPath,
-			}
-			numactlArgs = append(numactlArgs, args...)
-			cmd = exec.CommandContext(ctx, "numactl", numactlArgs...)
-			logger.Debug().
-				Str("channel_id", channel.ID.String()).
-				Int("numa_node", numaNode).
-				Msg("Using numactl for NUMA binding")
+	args := plan.args
+	if err := m.admitSession(plan.encoder); err != nil {
+		return err
+	}
+
+	// Encrypted channels start with a fresh key
+	var keys *keyRotator
+	if plan.encryption != nil {
+		keys, err = m.newKeyRotator(channel.ID, plan.encryption.RotateSegments)
+		if err != nil {
+			return fmt.Errorf("failed to prepare encryption keys: %w", err)
 		}
 	}
-	
-	// Fallback to normal FFmpeg if numactl not available or not needed
-	if !useNumactl {
-		cmd = exec.CommandContext(ctx, m.config.BinaryPath, args...)
-		if m.numaNodeCount > 1 {
-			logger.Debug().
-				Str("channel_id", channel.ID.String()).
-				Msg("NUMA nodes detected but numactl not available, using normal FFmpeg")
+
+	// Create variant directories for ladder and multi-audio channels,
+	// single playlist channels must not leave a stale master playlist behind
+	if len(plan.variants) > 0 {
+		if err := prepareVariantDirs(outputDir, plan.variants); err != nil {
+			return err
 		}
+	} else {
+		os.Remove(filepath.Join(outputDir, masterPlaylistName))
+	}
+
+	// The low-latency marker tells the stream handler to publish the output as LL-HLS
+	if plan.lowLatency != nil {
+		if err := hls.WriteMarker(outputDir, *plan.lowLatency); err != nil {
+			return fmt.Errorf("failed to write low-latency marker: %w", err)
+		}
+	} else {
+		hls.RemoveMarker(outputDir)
+	}
+	// The DVR marker enables the time-shift playlist endpoint (and carries the disk limit floor)
+	if plan.dvr != nil {
+		if err := hls.WriteDVRMarker(outputDir, *plan.dvr); err != nil {
+			return fmt.Errorf("failed to write DVR marker: %w", err)
+		}
+	} else {
+		hls.RemoveDVRMarker(outputDir)
+	}
+	if plan.dash != nil {
+		if err := dash.WriteLayout(outputDir, *plan.dash); err != nil {
+			return fmt.Errorf("failed to write DASH layout: %w", err)
+		}
+	} else {
+		dash.RemoveLayout(outputDir)
+	}
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	
+	// Wrap with numactl for NUMA binding when available (falls back to plain FFmpeg)
+	argv, numaNode := m.commandArgv(args, false)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = plan.env() // Clock overlays render local time in their timezone
+	if numaNode >= 0 {
+		logger.Debug().
+			Str("channel_id", channel.ID.String()).
+			Int("numa_node", numaNode).
+			Msg("Using numactl for NUMA binding")
+	} else if m.numaNodeCount > 1 {
+		logger.Debug().
+			Str("channel_id", channel.ID.String()).
+			Msg("NUMA nodes detected but numactl not available, using normal FFmpeg")
 	}
 	
 	// Set process attributes to create a new process group
@@ -241,13 +350,19 @@ func (m *ProcessManager) Start(channel *domain.Channel) error {
 		StartedAt: time.Now(),
 		Metrics:   &domain.ProcessMetrics{},
 		Logs:      make([]string, 0, 1000), // Pre-allocate for 1000 log lines
-		GPUIndex:  gpuIndex, // Store GPU index for load balancing
+		GPUIndex:  plan.encoder.device, // Store device index for load balancing
+		Backend:   plan.encoder.backend.Name(),
+		SourceURL:   source,
+		SourceIndex: sourceIndex,
+		done:        make(chan struct{}),
+		keys:        keys,
 	}
 
 	if err := cmd.Start(); err != nil {
 		cancel()
 		return fmt.Errorf("failed to start FFmpeg: %w", err)
 	}
+	m.acquireSession(process.Backend, process.GPUIndex)
 
 	// Set process priority, CPU affinity, and NUMA node binding for optimal performance
 	if cmd.Process != nil {
@@ -277,6 +392,7 @@ func (m *ProcessManager) Start(channel *domain.Channel) error {
 	}
 
 	m.processes[channel.ID] = process
+	m.writePidFile(channel.ID, cmd.Process.Pid)
 
 	// Start progress monitoring goroutine
 	go m.monitorProgress(process, stderr)
@@ -284,15 +400,24 @@ func (m *ProcessManager) Start(channel *domain.Channel) error {
 	// Start process watcher goroutine
 	go m.watchProcess(process)
 
+	// Start stall watchdog goroutine
+	if m.config.StallTimeout > 0 {
+		go m.watchStall(process)
+	}
+
+	// Push destinations are fed from the HLS output by their own relays
+	m.startRelays(process, outputDir)
+
 	// Log FFmpeg command for debugging
 	logger.Info().
 		Str("channel_id", channel.ID.String()).
 		Str("channel_name", channel.Name).
-		Str("source_url", channel.SourceURL).
+		Str("source_url", source).
+		Int("source_index", sourceIndex).
 		Int("pid", cmd.Process.Pid).
 		Int("active_processes", activeProcessCount).
 		Str("output_dir", outputDir).
-		Str("ffmpeg_command", strings.Join(append([]string{m.config.BinaryPath}, args...), " ")).
+		Str("ffmpeg_command", strings.Join(argv, " ")).
 		Msg("Started FFmpeg process")
 
 	return nil
@@ -301,12 +426,21 @@ func (m *ProcessManager) Start(channel *domain.Channel) error {
 // Stop stops transcoding for a channel
 func (m *ProcessManager) Stop(channelID uuid.UUID) error {
 	m.mu.Lock()
+	if m.cancelRestartLocked(channelID) {
+		logger.Info().
+			Str("channel_id", channelID.String()).
+			Msg("Cancelled pending auto-restart")
+	}
+	delete(m.sources, channelID)
+	slate := m.takeSlateLocked(channelID)
 	process, exists := m.processes[channelID]
 	if !exists {
 		m.mu.Unlock()
+		slate.stop()
 		// Channel directory might still exist even if process is not in map
 		// Clean it up anyway
 		outputDir := filepath.Join(m.hlsPath, channelID.String())
+		m.removeKeys(channelID)
 		if err := os.RemoveAll(outputDir); err != nil {
 			logger.Warn().
 				Err(err).
@@ -332,6 +466,7 @@ func (m *ProcessManager) Stop(channelID uuid.UUID) error {
 	// Remove from map first to prevent auto-restart
 	delete(m.processes, channelID)
 	m.mu.Unlock()
+	slate.stop()
 
 	logger.Info().
 		Str("channel_id", channelID.String()).
@@ -366,13 +501,11 @@ func (m *ProcessManager) Stop(channelID uuid.UUID) error {
 	}
 
 	// Step 3: Wait for graceful shutdown, then force kill if needed
+	// watchProcess (or watchAdopted) closes process.done once the process has exited
 	done := make(chan error, 1)
 	go func() {
-		if process.Cmd != nil {
-			done <- process.Cmd.Wait()
-		} else {
-			done <- nil
-		}
+		<-process.done
+		done <- nil
 	}()
 
 	select {
@@ -428,6 +561,7 @@ func (m *ProcessManager) Stop(channelID uuid.UUID) error {
 	}
 
 	// Step 5: Clean up channel directory completely
+	m.removeKeys(channelID)
 	if err := os.RemoveAll(outputDir); err != nil {
 		logger.Error().
 			Err(err).
@@ -471,12 +605,18 @@ func (m *ProcessManager) Restart(channelID uuid.UUID) error {
 }
 
 // GetProcess returns process info for a channel
+// While an auto-restart is pending (or was given up) only the restart counters are returned.
 func (m *ProcessManager) GetProcess(channelID uuid.UUID) (*domain.TranscoderProcess, error) {
 	m.mu.RLock()
 	process, exists := m.processes[channelID]
+	restarts := m.restartStatusLocked(channelID)
+	slateSince := m.slateStatusLocked(channelID)
 	m.mu.RUnlock()
 
 	if !exists {
+		if restarts != nil {
+			return &domain.TranscoderProcess{ChannelID: channelID, Restarts: restarts, SlateSince: slateSince}, nil
+		}
 		return nil, fmt.Errorf("channel %s is not running", channelID)
 	}
 
@@ -488,25 +628,21 @@ func (m *ProcessManager) GetProcess(channelID uuid.UUID) (*domain.TranscoderProc
 	}
 	pid := process.Cmd.Process.Pid
 	lastCPUStat := process.lastCPUStat
-	bitrate := process.Metrics.Bitrate
 	startedAt := process.StartedAt
-	dropFrames := process.Metrics.DropFrames
-	fps := process.Metrics.FPS
-	speed := process.Metrics.Speed
+	metrics := *process.Metrics
+	inputBitrate := process.InputBitrate
+	outputBitrate := process.OutputBitrate
+	lastError := process.LastError
+	sourceURL := process.SourceURL
+	sourceIndex := process.SourceIndex
 	process.mu.RUnlock()
 
 	// Get CPU and memory usage (pass process for tracking, but don't lock here)
 	cpuUsage, memoryUsage := m.getProcessStats(pid, process, &lastCPUStat)
 
-	// Parse bitrate for output
-	outputBitrate := 0
-	if bitrate != "" {
-		// Extract numeric value from bitrate string (e.g., "4000k" -> 4000)
-		bitrateStr := strings.TrimSuffix(bitrate, "k")
-		bitrateStr = strings.TrimSuffix(bitrateStr, "M")
-		if val, err := strconv.Atoi(bitrateStr); err == nil {
-			outputBitrate = val
-		}
+	// Fall back to the progress bitrate until the first segment is complete
+	if outputBitrate == 0 {
+		outputBitrate = parseProgressBitrate(metrics.Bitrate)
 	}
 
 	return &domain.TranscoderProcess{
@@ -515,12 +651,18 @@ func (m *ProcessManager) GetProcess(channelID uuid.UUID) (*domain.TranscoderProc
 		StartedAt:     startedAt,
 		CPUUsage:      cpuUsage,
 		MemoryUsage:   memoryUsage,
-		InputBitrate:  0, // Will be parsed from input if available
+		InputBitrate:  inputBitrate,
 		OutputBitrate: outputBitrate,
-		DroppedFrames: dropFrames,
-		FPS:           fps,
-		Speed:         parseSpeed(speed),
+		DroppedFrames: metrics.DropFrames,
+		FPS:           metrics.FPS,
+		Speed:         parseSpeed(metrics.Speed),
+		LastError:     lastError,
 		Uptime:        int64(time.Since(startedAt).Seconds()),
+		Metrics:       &metrics,
+		Restarts:      restarts,
+		SourceURL:     sourceURL,
+		SourceIndex:   sourceIndex,
+		Push:          relayStatuses(process),
 	}, nil
 }
 
@@ -539,21 +681,18 @@ func (m *ProcessManager) GetAllProcesses() ([]*domain.TranscoderProcess, error)
 		pid := process.Cmd.Process.Pid
 		lastCPUStat := process.lastCPUStat
 		startedAt := process.StartedAt
-		bitrate := process.Metrics.Bitrate
-		dropFrames := process.Metrics.DropFrames
-		fps := process.Metrics.FPS
-		speed := process.Metrics.Speed
+		metrics := *process.Metrics
+		inputBitrate := process.InputBitrate
+		outputBitrate := process.OutputBitrate
+		lastError := process.LastError
+		sourceURL := process.SourceURL
+		sourceIndex := process.SourceIndex
 		process.mu.RUnlock()
 		
 		cpuUsage, memoryUsage := m.getProcessStats(pid, process, &lastCPUStat)
 		
-		outputBitrate := 0
-		if bitrate != "" {
-			bitrateStr := strings.TrimSuffix(bitrate, "k")
-			bitrateStr = strings.TrimSuffix(bitrateStr, "M")
-			if val, err := strconv.Atoi(bitrateStr); err == nil {
-				outputBitrate = val
-			}
+		if outputBitrate == 0 {
+			outputBitrate = parseProgressBitrate(metrics.Bitrate)
 		}
 
 		processes = append(processes, &domain.TranscoderProcess{
@@ -562,12 +701,18 @@ func (m *ProcessManager) GetAllProcesses() ([]*domain.TranscoderProcess, error)
 			StartedAt:     startedAt,
 			CPUUsage:      cpuUsage,
 			MemoryUsage:   memoryUsage,
-			InputBitrate:  0,
+			InputBitrate:  inputBitrate,
 			OutputBitrate: outputBitrate,
-			DroppedFrames: dropFrames,
-			FPS:           fps,
-			Speed:         parseSpeed(speed),
+			DroppedFrames: metrics.DropFrames,
+			FPS:           metrics.FPS,
+			Speed:         parseSpeed(metrics.Speed),
+			LastError:     lastError,
 			Uptime:        int64(time.Since(startedAt).Seconds()),
+			Metrics:       &metrics,
+			Restarts:      m.restartStatusLocked(channelID),
+			SourceURL:     sourceURL,
+			SourceIndex:   sourceIndex,
+			Push:          relayStatuses(process),
 		})
 	}
 
@@ -582,14 +727,22 @@ func (m *ProcessManager) IsRunning(channelID uuid.UUID) bool {
 	return exists
 }
 
-// buildArgs builds FFmpeg command arguments and returns GPU index used
-func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, activeProcessCount int) ([]string, int, error) {
+// buildArgs builds FFmpeg command arguments together with the encoder backend/device used
+// and the effective encoding values. With peek set, load balancing counters are not advanced.
+// With slate set, the channel slate replaces the source but the output layout stays the same.
+func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, activeProcessCount int, peek, slate bool) (*commandPlan, error) {
+	plan := newCommandPlan()
+	
 	// Start with basic FFmpeg arguments with reconnect and stability options
 	// Optimized for 70 simultaneous streams with stability and performance
 	args := []string{
 		"-hide_banner",
-		"-loglevel", "warning", // Reduced logging for performance
+		"-loglevel", "level+info", // Info is needed for input bitrate and segment opens, level prefix for log classification
+		"-nostats", // Progress is read from -progress blocks, not the status line
 		"-progress", "pipe:2",
+	}
+	if !slate {
+		args = append(args,
 		// Reconnect options for network streams (optimized)
 		"-reconnect", "1",
 		"-reconnect_streamed", "1",
@@ -601,52 +754,54 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 		"-analyzeduration", "2000000", // 2 seconds (reduced for faster startup)
 		"-probesize", "2000000", // 2MB (reduced for faster startup)
 		"-thread_queue_size", "512", // Balanced queue size (reduced memory per stream)
+		)
 	}
 	
-	// Check for NVIDIA GPU availability and get GPU index for load balancing
-	useNVENC := m.gpuCount > 0
-	var gpuIndex int = 0
-	var hwaccelParams []string
-	
-	if useNVENC {
-		// Get next GPU index for round-robin distribution
-		var err error
-		gpuIndex, err = m.getNextGPUIndex()
-		if err != nil {
-			logger.Warn().
-				Err(err).
-				Str("channel_id", channel.ID.String()).
-				Msg("Failed to get GPU index, falling back to CPU encoding")
-			useNVENC = false
-		} else {
-			// Add hardware acceleration parameters before input
-			// -hwaccel cuda: Use CUDA for hardware acceleration
-			// -hwaccel_device: Specify which GPU to use
-			hwaccelParams = []string{
-				"-hwaccel", "cuda",
-				"-hwaccel_device", strconv.Itoa(gpuIndex),
-			}
-			logger.Info().
-				Str("channel_id", channel.ID.String()).
-				Int("gpu_index", gpuIndex).
-				Int("gpu_count", m.gpuCount).
-				Msg("NVIDIA GPU detected, using NVENC for encoding with load balancing")
-		}
+	// Resolve codec and pick the encoder backend (forced per channel/node or auto-detected)
+	var channelCodec string
+	if channel.OutputConfig != nil {
+		channelCodec = channel.OutputConfig.Codec
 	}
-	
-	if !useNVENC {
-		logger.Debug().
+	codec, err := domain.ParseVideoCodec(channelCodec)
+	if err != nil {
+		return nil, err
+	}
+	if channelCodec != "" {
+		plan.set("codec", string(codec), domain.ValueSourceChannel)
+	} else {
+		plan.set("codec", string(codec), domain.ValueSourceDefault)
+	}
+	encoder, err := m.selectBackend(channel, codec, peek)
+	if err != nil {
+		return nil, err
+	}
+	plan.encoder = encoder
+	plan.set("encoder_backend", string(encoder.backend.Name()), m.encoderBackendSource(channel))
+	plan.set("device", strconv.Itoa(encoder.device), domain.ValueSourceDetected)
+	if !peek {
+		logger.Info().
 			Str("channel_id", channel.ID.String()).
-			Msg("NVIDIA GPU not detected, falling back to libx264 (CPU)")
+			Str("encoder_backend", string(encoder.backend.Name())).
+			Int("device", encoder.device).
+			Int("device_count", m.deviceCounts[encoder.backend.Name()]).
+				Msg("Encoder backend selected")
 	}
 	
-	// Add hardware acceleration parameters before input (if using GPU)
-	if len(hwaccelParams) > 0 {
-		args = append(args, hwaccelParams...)
-	}
+	// Add hardware initialisation/acceleration parameters before input
+	args = append(args, encoder.backend.InputArgs(encoder.device)...)
 	
-	// Add input source
-	args = append(args, "-i", channel.SourceURL)
+	// Add input source (the slate loops a local file instead)
+	var slateConfig domain.SlateConfig
+	if slate {
+		slateConfig = channel.OutputConfig.Slate.Normalized()
+		slateArgs, err := m.slateInputArgs(slateConfig)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, slateArgs...)
+	} else {
+		args = append(args, "-i", channel.SourceURL)
+	}
 
 	// Get settings from database first (this is the source of truth)
 	preset := m.config.DefaultPreset
@@ -655,6 +810,12 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 	playlistSize := m.config.PlaylistSize
 	resolution := "1920x1080"
 	profile := "high"
+	plan.set("preset", preset, domain.ValueSourceConfig)
+	plan.set("bitrate", bitrate, domain.ValueSourceConfig)
+	plan.set("segment_time", strconv.Itoa(segmentTime), domain.ValueSourceConfig)
+	plan.set("playlist_size", strconv.Itoa(playlistSize), domain.ValueSourceConfig)
+	plan.set("resolution", resolution, domain.ValueSourceDefault)
+	plan.set("profile", profile, domain.ValueSourceDefault)
 	
 	// Load settings from database (these override config defaults)
 	if m.settingsRepo != nil {
@@ -664,35 +825,43 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 			if val, ok := dbSettings["default_preset"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					preset = v
+					plan.set("preset", v, domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["default_bitrate"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					bitrate = v
+					plan.set("bitrate", v, domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["segment_time"]; ok {
 				if v, ok := val.(float64); ok {
 					segmentTime = int(v)
+					plan.set("segment_time", strconv.Itoa(segmentTime), domain.ValueSourceSettings)
 				} else if v, ok := val.(int); ok {
 					segmentTime = v
+					plan.set("segment_time", strconv.Itoa(segmentTime), domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["playlist_size"]; ok {
 				if v, ok := val.(float64); ok {
 					playlistSize = int(v)
+					plan.set("playlist_size", strconv.Itoa(playlistSize), domain.ValueSourceSettings)
 				} else if v, ok := val.(int); ok {
 					playlistSize = v
+					plan.set("playlist_size", strconv.Itoa(playlistSize), domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["default_resolution"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					resolution = v
+					plan.set("resolution", v, domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["default_profile"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					profile = v
+					plan.set("profile", v, domain.ValueSourceSettings)
 				}
 			}
 		}
@@ -702,81 +871,230 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 	if channel.OutputConfig != nil {
 		if channel.OutputConfig.Preset != "" {
 			preset = channel.OutputConfig.Preset
+			plan.set("preset", preset, domain.ValueSourceChannel)
 		}
 		if channel.OutputConfig.Bitrate != "" {
 			bitrate = channel.OutputConfig.Bitrate
+			plan.set("bitrate", bitrate, domain.ValueSourceChannel)
 		}
 		if channel.OutputConfig.Resolution != "" {
 			resolution = channel.OutputConfig.Resolution
-		}
-		if channel.OutputConfig.Profile != "" {
-			profile = channel.OutputConfig.Profile
+			plan.set("resolution", resolution, domain.ValueSourceChannel)
 		}
 	}
 
-	// Parse resolution string (e.g., "1920x1080")
-	var outputWidth, outputHeight int
-	resParts := strings.Split(resolution, "x")
-	if len(resParts) == 2 {
-		if w, err := strconv.Atoi(resParts[0]); err == nil {
-			outputWidth = w
-		}
-		if h, err := strconv.Atoi(resParts[1]); err == nil {
-			outputHeight = h
+	// Validate codec specific profile/level
+	// (settings defaults like "high" only apply when valid for the codec)
+	var channelProfile, channelLevel string
+	if channel.OutputConfig != nil {
+		channelProfile = channel.OutputConfig.Profile
+		channelLevel = channel.OutputConfig.Level
+	}
+	fallbackProfile := profile
+	profile, err = resolveProfile(codec, channelProfile, profile)
+	if err != nil {
+		return nil, err
+	}
+	if channelProfile != "" {
+		plan.set("profile", profile, domain.ValueSourceChannel)
+	} else if profile != fallbackProfile {
+		plan.set("profile", profile, domain.ValueSourceDefault) // Settings profile not valid for the codec
+	}
+	level, err := resolveLevel(codec, channelLevel)
+	if err != nil {
+		return nil, err
+	}
+	if channelLevel != "" {
+		plan.set("level", level, domain.ValueSourceChannel)
+	} else {
+		plan.set("level", level, domain.ValueSourceDefault)
+	}
+
+	// Segment container: channel choice (validated on save) or picked by codec
+	container := domain.DefaultContainer(codec)
+	if channel.OutputConfig != nil && channel.OutputConfig.Container != "" {
+		container = domain.Container(channel.OutputConfig.Container)
+		plan.set("container", string(container), domain.ValueSourceChannel)
+	} else {
+		plan.set("container", string(container), domain.ValueSourceDefault)
+	}
+
+	// Low-Latency HLS: FFmpeg writes parts, the stream handler groups them into segments
+	if channel.OutputConfig != nil {
+		plan.lowLatency = lowLatencyMarker(channel.OutputConfig.LowLatency, segmentTime)
+	}
+	if plan.lowLatency != nil {
+		if container == domain.ContainerMPEGTS {
+			container = domain.ContainerFMP4 // Parts are CMAF chunks
+			plan.set("container", string(container), domain.ValueSourceChannel)
 		}
+		plan.set("low_latency", "true", domain.ValueSourceChannel)
+		plan.set("part_duration", strconv.FormatFloat(plan.lowLatency.PartTarget, 'f', 3, 64), domain.ValueSourceChannel)
 	}
+
+	// DVR: FFmpeg keeps the whole time-shift window listed (validated against low-latency HLS)
+	if channel.OutputConfig != nil && channel.OutputConfig.DVR != nil && channel.OutputConfig.DVR.Enabled && plan.lowLatency == nil {
+		plan.dvr = &hls.DVRMarker{WindowSegments: channel.OutputConfig.DVR.WindowSegments(segmentTime)}
+		plan.set("dvr_window_segments", strconv.Itoa(plan.dvr.WindowSegments), domain.ValueSourceChannel)
+	}
+	segmentFormat := segmentFormatFor(container)
+	publishDASH := container == domain.ContainerCMAF
+
+	// AES-128 encryption with keys rotated by the backend (validated against DASH, LL-HLS and push)
+	if channel.OutputConfig != nil && channel.OutputConfig.Encryption != nil && channel.OutputConfig.Encryption.Enabled {
+		encryption := channel.OutputConfig.Encryption.Normalized()
+		plan.encryption = &encryption
+		plan.set("encryption", string(encryption.Method), domain.ValueSourceChannel)
+		plan.set("key_rotation_segments", strconv.Itoa(encryption.RotateSegments), domain.ValueSourceChannel)
+	}
+
+	// Parse resolution string (e.g., "1920x1080")
+	outputWidth, outputHeight, ok := domain.ParseResolution(resolution)
 	
 	// If resolution not parsed, use defaults
-	if outputWidth == 0 || outputHeight == 0 {
+	if !ok {
 		outputWidth = 1920
 		outputHeight = 1080
 	}
 
+	// Resolve ABR ladder (if configured). The source is composed once at the largest
+	// rendition size and then split, so every rendition shares the same keyframes and overlay.
+	renditions, err := resolveLadder(channel)
+	if err != nil {
+		return nil, err
+	}
+	useLadder := len(renditions) > 0
+	composedLabel := "vout"
+	uploadFilter := encoder.backend.UploadFilter()
+	if !useLadder && uploadFilter != "" {
+		composedLabel = "composed" // Uploaded to the device afterwards
+	}
+	if useLadder {
+		largest := renditions[largestRendition(renditions)]
+		outputWidth = largest.width
+		outputHeight = largest.height
+		plan.set("resolution", fmt.Sprintf("%dx%d", outputWidth, outputHeight), domain.ValueSourceChannel) // Largest ladder rendition
+		composedLabel = "base"
+	}
+
 	// Build video filter complex
 	var videoFilters []string
-	hasLogo := channel.Logo != nil && channel.Logo.Path != ""
-	
-	if hasLogo {
-		// Handle logo path
-		var logoPath string
-		if filepath.IsAbs(channel.Logo.Path) {
-			logoPath = channel.Logo.Path
-		} else {
-			logoPath = filepath.Join(m.logoPath, channel.Logo.Path)
-		}
-
-		// Check if logo file exists
-		if _, err := os.Stat(logoPath); os.IsNotExist(err) {
-			return nil, -1, fmt.Errorf("logo file not found: %s", logoPath)
-		}
+	overlays, err := m.buildOverlayChain(channel.Overlays, "scaled", composedLabel, outputWidth, outputHeight)
+	if err != nil {
+		return nil, err
+	}
 
-		// Add logo as second input
-		args = append(args, "-i", logoPath)
-		
-		// Build filter: scale input video, prepare logo, overlay
-		// Format: [0:v]scale=WxH[scaled];[1:v]scale=WxH,format=rgba,colorchannelmixer=aa=OPACITY[logo];[scaled][logo]overlay=X:Y[vout]
+	if len(overlays.filters) > 0 {
+		// Overlay images are read as inputs 1..n, after the source
+		// Format: [0:v]scale=WxH[scaled];[1:v]scale=WxH,format=rgba,...[img0];[scaled][img0]overlay=X:Y[ov0];[ov0]drawtext=...[vout]
+		args = append(args, overlays.inputs...)
 		videoFilters = append(videoFilters, fmt.Sprintf(
-			"[0:v]scale=%d:%d[scaled]",
-			outputWidth, outputHeight,
-		))
-		videoFilters = append(videoFilters, fmt.Sprintf(
-			"[1:v]scale=%d:%d,format=rgba,colorchannelmixer=aa=%f[logo]",
-			channel.Logo.Width, channel.Logo.Height, channel.Logo.Opacity,
-		))
-		videoFilters = append(videoFilters, fmt.Sprintf(
-			"[scaled][logo]overlay=%d:%d[vout]",
-			channel.Logo.X, channel.Logo.Y,
+			"[0:v]%s[scaled]",
+			inputScaleFilter(outputWidth, outputHeight, slate),
 		))
+		videoFilters = append(videoFilters, overlays.filters...)
+		plan.set("overlays", strconv.Itoa(len(domain.ActiveOverlays(channel.Overlays))), domain.ValueSourceChannel)
+		plan.timezone = domain.ClockTimezone(channel.Overlays)
 	} else {
-		// No logo, just scale video
+		// No overlays, just scale video
 		videoFilters = append(videoFilters, fmt.Sprintf(
-			"[0:v]scale=%d:%d[vout]",
-			outputWidth, outputHeight,
+			"[0:v]%s[%s]",
+			inputScaleFilter(outputWidth, outputHeight, slate), composedLabel,
 		))
 	}
 
+	// Split composed video into ladder renditions (scaled on the encoder device)
+	// or move the single output to the device
+	if useLadder {
+		videoFilters = append(videoFilters, ladderSplitFilters(renditions, encoder.backend.ScaleFilter)...)
+	} else if uploadFilter != "" {
+		videoFilters = append(videoFilters, fmt.Sprintf("[%s]%s[vout]", composedLabel, uploadFilter))
+	}
+
+	// Resolve the audio tracks against the source streams probed before the start
+	var channelAudio *domain.AudioConfig
+	if channel.OutputConfig != nil {
+		channelAudio = channel.OutputConfig.Audio
+	}
+	audio := channelAudio.Normalized()
+	silentInput := 1 + overlays.images
+	taken := map[string]bool{videoVariantName: true}
+	for _, r := range renditions {
+		taken[r.name] = true
+	}
+	streams, probed := m.probedStreams(channel.SourceURL)
+	if !probed {
+		streams = &sourceStreams{}
+	}
+	audioTracks, unmatched := resolveAudioTracks(audio, streams.audio, probed, silentInput, taken)
+	if len(unmatched) > 0 && !peek {
+		logger.Warn().
+			Str("channel_id", channel.ID.String()).
+			Strs("tracks", unmatched).
+			Msg("Audio track selection does not match the source, skipped")
+	}
+	if slate {
+		// Every track plays the slate audio, so the variants match the source layout
+		audioTracks = slateAudioTracks(audioTracks, slateConfig, silentInput)
+		args = append(args, slateAudioInputArgs(slateConfig, audio, audioTracks)...)
+	} else if len(audioTracks) > 0 && audioTracks[0].silent {
+		// Source has no audio, add a generated silent track as the next input
+		args = append(args, silentInputArgs(audio)...)
+	}
+	multiAudio := len(audioTracks) > 1
+	// DASH needs single-track CMAF segments, so audio is never muxed into the video variants
+	separateAudio := multiAudio || (publishDASH && len(audioTracks) > 0)
+	audioSource := domain.ValueSourceDefault
+	if channelAudio != nil {
+		audioSource = domain.ValueSourceChannel
+	}
+	plan.set("audio_mode", string(audio.Mode), audioSource)
+	plan.set("audio_bitrate", audio.Bitrate, audioSource)
+	plan.set("audio_layout", audio.ChannelLayout, audioSource)
+	if probed {
+		plan.set("audio_tracks", strconv.Itoa(len(audioTracks)), domain.ValueSourceDetected)
+	} else {
+		plan.set("audio_tracks", strconv.Itoa(len(audioTracks)), audioSource)
+	}
+
+	// Captions: CEA-608 stays in the video stream, text subtitles become WebVTT renditions
+	// attached to the video variants
+	var captions *domain.CaptionConfig
+	if channel.OutputConfig != nil {
+		captions = channel.OutputConfig.Captions
+	}
+	closedCaptions := captions != nil && captions.ClosedCaptions
+	videoVariants := 1
+	if useLadder {
+		videoVariants = len(renditions)
+	}
+	subtitleTracks, skippedSubtitles := resolveSubtitleTracks(captions, streams.subtitles, probed, videoVariants, taken)
+	if len(skippedSubtitles) > 0 && !peek {
+		logger.Warn().
+			Str("channel_id", channel.ID.String()).
+			Strs("subtitles", skippedSubtitles).
+			Msg("Subtitle streams not converted to WebVTT")
+	}
+	if captions != nil {
+		plan.set("closed_captions", strconv.FormatBool(closedCaptions), domain.ValueSourceChannel)
+		plan.set("subtitle_tracks", strconv.Itoa(len(subtitleTracks)), domain.ValueSourceDetected)
+	}
+	useMaster := useLadder || separateAudio || len(subtitleTracks) > 0 || publishDASH
+	if slate {
+		subtitleTracks = nil // The slate has no subtitles, the playlists stay where they are
+	}
+
 	// Add filter_complex for video processing
-	if len(videoFilters) > 0 {
+	if useLadder {
+		args = append(args, "-filter_complex", strings.Join(videoFilters, ";"))
+		// Map each rendition ([v0], [v1], ...); a single audio track is copied into every rendition,
+		// separate tracks (several, or CMAF) are mapped once below and published as an audio group
+		if len(audioTracks) == 1 && !separateAudio {
+			args = append(args, ladderMapArgs(renditions, audioTracks[0].input)...)
+		} else {
+			args = append(args, ladderMapArgs(renditions, "")...)
+		}
+	} else if len(videoFilters) > 0 {
 		filterComplex := strings.Join(videoFilters, ";")
 		args = append(args, "-filter_complex", filterComplex)
 		// Map the filtered video output (vout is the final video output from filter_complex)
@@ -786,10 +1104,13 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 		args = append(args, "-map", "0:v")
 	}
 
-	// Map audio from first input
-	// Note: FFmpeg will handle missing audio gracefully - if no audio stream exists,
-	// it will continue without audio (we'll encode audio only if present)
-	args = append(args, "-map", "0:a")
+	// Map the audio tracks (a single track of a ladder channel is mapped per rendition above)
+	if !useLadder || separateAudio {
+		for _, track := range audioTracks {
+			args = append(args, "-map", track.input)
+		}
+	}
+	args = append(args, subtitleArgs(subtitleTracks)...)
 
 	// Get encoding parameters from database settings (with defaults)
 	// Note: Using -threads 0 (auto threads) for better stability and automatic thread management
@@ -797,6 +1118,9 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 	maxrate := "5000k"
 	bufsize := "10000k"
 	gopSize := segmentTime * 30 // GOP size (segment_time seconds at 30fps, e.g., 6 seconds = 180 frames)
+	plan.set("crf", strconv.Itoa(crf), domain.ValueSourceDefault)
+	plan.set("maxrate", maxrate, domain.ValueSourceDefault)
+	plan.set("bufsize", bufsize, domain.ValueSourceDefault)
 	
 	// Load additional encoding settings from database
 	if m.settingsRepo != nil {
@@ -805,18 +1129,22 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 			if val, ok := dbSettings["default_crf"]; ok {
 				if v, ok := val.(float64); ok {
 					crf = int(v)
+					plan.set("crf", strconv.Itoa(crf), domain.ValueSourceSettings)
 				} else if v, ok := val.(int); ok {
 					crf = v
+					plan.set("crf", strconv.Itoa(crf), domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["default_maxrate"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					maxrate = v
+					plan.set("maxrate", v, domain.ValueSourceSettings)
 				}
 			}
 			if val, ok := dbSettings["default_bufsize"]; ok {
 				if v, ok := val.(string); ok && v != "" {
 					bufsize = v
+					plan.set("bufsize", v, domain.ValueSourceSettings)
 				}
 			}
 		}
@@ -828,134 +1156,240 @@ func (m *ProcessManager) buildArgs(channel *domain.Channel, outputDir string, ac
 		if channel.OutputConfig.Bitrate != "" {
 			bitrate = channel.OutputConfig.Bitrate
 			maxrate = bitrate
+			plan.set("maxrate", maxrate, domain.ValueSourceChannel)
 		}
 	}
 	
 	// Calculate bufsize from maxrate if not set explicitly
 	if bufsize == "10000k" && maxrate != "" {
 		// Default: 2x maxrate for bufsize
-		maxrateNum := strings.TrimSuffix(maxrate, "k")
-		isMB := strings.HasSuffix(maxrate, "M")
-		if isMB {
-			maxrateNum = strings.TrimSuffix(maxrateNum, "M")
-		}
-		if val, err := strconv.Atoi(maxrateNum); err == nil {
-			multiplier := 2
-			if isMB {
-				bufsize = fmt.Sprintf("%dM", val*multiplier)
-			} else {
-				bufsize = fmt.Sprintf("%dk", val*multiplier)
-			}
+		if doubled, ok := doubleRate(maxrate); ok {
+			bufsize = doubled
+			plan.set("bufsize", bufsize, plan.values["maxrate"].Source) // Derived from maxrate
 		}
 	}
+
+	// Rate control: a single output uses the resolved maxrate/bufsize,
+	// ladder renditions each get their own per-stream values
+	rateArgs := []string{"-maxrate", maxrate, "-bufsize", bufsize}
+	targetRateArgs := []string{"-b:v", bitrate}
+	if useLadder {
+		rateArgs = ladderRateArgs(renditions)
+		targetRateArgs = ladderTargetRateArgs(renditions)
+	}
 	
 	// Video encoding parameters (optimized for stability, quality, and 70 streams performance)
 	// Use optimized thread count from settings or auto-detect
 	threadCount := "0" // Auto-detect threads
+	plan.set("threads", threadCount, domain.ValueSourceDefault)
 	if m.settingsRepo != nil {
 		dbSettings, err := m.settingsRepo.GetSystemSettings()
 		if err == nil {
 			if val, ok := dbSettings["threads_per_process"]; ok {
 				if v, ok := val.(float64); ok && v > 0 {
 					threadCount = strconv.Itoa(int(v))
+					plan.set("threads", threadCount, domain.ValueSourceSettings)
 				} else if v, ok := val.(int); ok && v > 0 {
 					threadCount = strconv.Itoa(v)
+					plan.set("threads", threadCount, domain.ValueSourceSettings)
 				}
 			}
 		}
 	}
 	
-	// Video encoding parameters
-	if useNVENC {
-		// NVENC optimized parameters with specific GPU device
-		args = append(args,
-			"-c:v", "h264_nvenc",
-			"-preset", "p4", // Medium quality/speed for newer NVENC
-			"-tune", "ull", // Ultra-low latency
-			"-rc", "vbr", // Variable bitrate
-			"-cq", strconv.Itoa(crf), // Quality
-			"-maxrate", maxrate,
-			"-bufsize", bufsize,
-			"-profile:v", profile,
-			"-level", "4.1",
-			"-pix_fmt", "yuv420p",
-			"-g", strconv.Itoa(gopSize),
-			"-keyint_min", strconv.Itoa(gopSize/2),
-			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segmentTime),
-			"-bf", "0",
-			"-gpu", strconv.Itoa(gpuIndex), // Use specific GPU index (load balanced)
-		)
-	} else {
-		// x264 (CPU) parameters
-		args = append(args,
-			"-c:v", "libx264",
-			"-preset", preset,
-			"-tune", "zerolatency",
-			"-crf", strconv.Itoa(crf),
-			"-maxrate", maxrate,
-			"-bufsize", bufsize,
-			"-profile:v", profile,
-			"-level", "4.1",
-			"-pix_fmt", "yuv420p",
-			"-g", strconv.Itoa(gopSize),
-			"-keyint_min", strconv.Itoa(gopSize/2),
-			"-sc_threshold", "0",
-			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segmentTime),
-			"-threads", threadCount,
-			"-x264opts", "nal-hrd=cbr:force-cfr=1",
-			"-bf", "0",
-		)
+	// Video encoding parameters (codec specific)
+	encoderArgs, err := videoEncoderArgs(encoder.backend, encodeParams{
+		codec:          codec,
+		device:         encoder.device,
+		preset:         preset,
+		profile:        profile,
+		level:          level,
+		crf:            crf,
+		rateArgs:       rateArgs,
+		targetRateArgs: targetRateArgs,
+		gopSize:        gopSize,
+		segmentTime:    segmentTime,
+		threadCount:    threadCount,
+		closedCaptions: closedCaptions,
+	})
+	if err != nil {
+		return nil, err
 	}
+	args = append(args, encoderArgs...)
 	
-	// Audio encoding parameters
-	args = append(args,
-		"-c:a", "aac",
-		"-b:a", "128k", // Good quality audio
-		"-ar", "48000",
-		"-ac", "2", // Stereo
-	)
+	// Audio encoding parameters and language tags
+	audioCopies := 1
+	if useLadder && !separateAudio {
+		audioCopies = len(renditions)
+	}
+	args = append(args, audioEncodeArgs(audio, audioTracks)...)
+	args = append(args, audioMetadataArgs(audioTracks, audioCopies)...)
 	
+	// Ladder, multi-audio and subtitle output: one sub-directory per variant (%v) plus a master playlist
+	segmentName := "segment_%05d." + segmentFormat.extension
+	segmentPattern := filepath.Join(outputDir, segmentName)
+	playlistPath := filepath.Join(outputDir, "index.m3u8")
+	if useMaster {
+		layout := variantLayout{
+			videoNames: []string{videoVariantName},
+			muxedAudio: len(audioTracks) == 1 && !separateAudio,
+			subtitles:  subtitleTracks,
+			captions:   closedCaptions,
+		}
+		if useLadder {
+			layout.videoNames = make([]string, len(renditions))
+			for i, r := range renditions {
+				layout.videoNames[i] = r.name
+			}
+		}
+		if separateAudio {
+			layout.audioTracks = audioTracks
+		}
+		plan.variants = layout.variants()
+		segmentPattern = filepath.Join(outputDir, "%v", segmentName)
+		playlistPath = filepath.Join(outputDir, "%v", "index.m3u8")
+		args = append(args,
+			"-var_stream_map", layout.varStreamMap(),
+			"-master_pl_name", masterPlaylistName, // Written to outputDir (parent of the %v directories)
+		)
+		if closedCaptions {
+			args = append(args, captionStreamMapArgs(captions)...)
+		}
+	}
+
+	// Segments kept listed (the DVR window replaces the live playlist size)
+	windowSegments := playlistSize
+	if plan.dvr != nil {
+		windowSegments = plan.dvr.WindowSegments
+	}
+
+	// CMAF channels also publish a DASH manifest over the same segments
+	if publishDASH {
+		layout := &dash.Layout{
+			SegmentDuration: segmentTime,
+			WindowSegments:  windowSegments,
+			Audio:           dashAudioRepresentations(audio, audioTracks, streams.audio),
+		}
+		videoCodecs := videoCodecString(codec, profile, level)
+		if useLadder {
+			for _, r := range renditions {
+				layout.Video = append(layout.Video, dash.Representation{ID: r.name, Codecs: videoCodecs, Bandwidth: rateBits(r.bitrate), Width: r.width, Height: r.height})
+			}
+		} else {
+			layout.Video = []dash.Representation{{ID: videoVariantName, Codecs: videoCodecs, Bandwidth: rateBits(bitrate), Width: outputWidth, Height: outputHeight}}
+		}
+		for i := range layout.Video {
+			layout.Video[i].Init = fmp4InitName(i, len(plan.variants))
+		}
+		for i := range layout.Audio {
+			layout.Audio[i].Init = fmp4InitName(len(layout.Video)+i, len(plan.variants))
+		}
+		if closedCaptions {
+			layout.ClosedCaptions = captionChannel
+			if captions.CCLanguage != "" {
+				layout.ClosedCaptions += "=" + captions.CCLanguage
+			}
+		}
+		plan.dash = layout
+	}
+
 	// HLS output parameters (optimized for stability and performance with 70 streams)
+	hlsTime := strconv.Itoa(segmentTime)
+	hlsListSize := strconv.Itoa(windowSegments)
+	hlsFlags := "delete_segments+independent_segments+program_date_time"
+	hlsDeleteThreshold := "1"
+	if plan.lowLatency != nil {
+		// Parts are cut by time (not every part starts on a keyframe) and the
+		// playlist keeps as many whole segments as a regular channel
+		parts := plan.lowLatency.PartsPerSegment
+		hlsTime = strconv.FormatFloat(plan.lowLatency.PartTarget, 'f', 3, 64)
+		hlsListSize = strconv.Itoa(playlistSize * parts)
+		hlsFlags = "delete_segments+split_by_time+program_date_time"
+		hlsDeleteThreshold = strconv.Itoa(parts)
+	}
+	if channel.HasSlate() || len(channel.BackupSources) > 0 {
+		// Source, backup and slate runs append to the playlist of the previous run behind a
+		// discontinuity, continuing its media sequence and segment numbers
+		hlsFlags += "+append_list+omit_endlist+discont_start"
+	}
+	if channel.HasSlate() {
+		plan.set("slate", channel.OutputConfig.Slate.Path, domain.ValueSourceChannel)
+	}
+	if plan.encryption != nil {
+		hlsFlags += "+periodic_rekey" // Re-read the key info file at every segment
+		args = append(args, "-hls_key_info_file", filepath.Join(m.keyDir(channel.ID), keyInfoName))
+	}
 	args = append(args,
 		"-f", "hls",
-		"-hls_time", strconv.Itoa(segmentTime), // 3 second segments (optimal for stability)
-		"-hls_list_size", strconv.Itoa(playlistSize), // Keep 6 segments in playlist (18 seconds)
-		"-hls_flags", "delete_segments+independent_segments+program_date_time", // Auto-delete + independent segments + timestamps
-		"-hls_delete_threshold", "1", // Delete old segments immediately
-		"-hls_segment_filename", filepath.Join(outputDir, "segment_%05d.ts"),
-		"-hls_segment_type", "mpegts",
+		"-hls_time", hlsTime, // 3 second segments (optimal for stability)
+		"-hls_list_size", hlsListSize, // Keep 6 segments in playlist (18 seconds)
+		"-hls_flags", hlsFlags, // Auto-delete + independent segments + timestamps
+		"-hls_delete_threshold", hlsDeleteThreshold, // Delete old segments immediately
+		"-hls_segment_filename", segmentPattern,
+		"-hls_segment_type", segmentFormat.segmentType, // mpegts or fmp4 (channel container, fMP4 for HEVC/AV1)
+	)
+	if segmentFormat.segmentType == "fmp4" {
+		args = append(args, "-hls_fmp4_init_filename", "init.mp4")
+	}
+	args = append(args,
 		"-start_number", "0",
 		"-avoid_negative_ts", "make_zero",
 		"-max_muxing_queue_size", "1024", // Reasonable queue (reduced from 9999 for memory efficiency with 70 streams)
 		"-muxdelay", "0", // No delay
 		"-muxpreload", "0", // No preload
-		filepath.Join(outputDir, "index.m3u8"),
+		playlistPath,
 	)
 
-	return args, gpuIndex, nil
+	plan.args = args
+	return plan, nil
 }
 
 // monitorProgress parses FFmpeg progress output and collects logs
 func (m *ProcessManager) monitorProgress(process *Process, stderr io.ReadCloser) {
 	scanner := bufio.NewScanner(stderr)
-	
-	frameRegex := regexp.MustCompile(`frame=\s*(\d+)`)
-	fpsRegex := regexp.MustCompile(`fps=\s*([\d.]+)`)
-	bitrateRegex := regexp.MustCompile(`bitrate=\s*([\d.]+\w+)`)
-	speedRegex := regexp.MustCompile(`speed=\s*([\d.]+x)`)
-	dropRegex := regexp.MustCompile(`drop=\s*(\d+)`)
-	errorRegex := regexp.MustCompile(`(?i)(error|failed|cannot|unable|invalid)`)
-
-	// Optimize parsing: only parse every N lines to reduce CPU usage
-	// For high-performance systems, we can parse more frequently without much overhead
-	lineCount := 0
-	parseInterval := 3 // Parse metrics every 3 lines (slightly more frequent for better monitoring)
+
+	var progress progressParser
+	var input inputBitrateParser
+	var segments segmentRateTracker
+	var outTimeMs int64
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		lineCount++
-		
-		// Store all log lines (limit to last 500 lines to reduce memory usage)
+
+		// -progress key=value blocks are applied atomically once complete (kept out of the logs)
+		if isProgress, block := progress.Feed(line); isProgress {
+			if block != nil {
+				outTimeMs = block.OutTimeMs
+				process.mu.Lock()
+				*process.Metrics = *block
+				process.mu.Unlock()
+			}
+			continue
+		}
+
+		// Segment opens drive the output bitrate measurement, key rotation and the stall watchdog
+		if matches := segmentOpenRegex.FindStringSubmatch(line); len(matches) > 1 {
+			m.segmentOpened(process, matches[1])
+			outputBitrate := segments.Opened(matches[1], outTimeMs)
+			process.mu.Lock()
+			if outputBitrate > 0 {
+				process.OutputBitrate = outputBitrate
+			}
+			if isSegmentFile(matches[1]) {
+				process.lastSegmentAt = time.Now()
+			}
+			process.mu.Unlock()
+			continue
+		}
+
+		// Input dump (printed once at startup) carries the source bitrate
+		if inputBitrate := input.Feed(line); inputBitrate > 0 {
+			process.mu.Lock()
+			process.InputBitrate = inputBitrate
+			process.mu.Unlock()
+		}
+
+		// Store log lines (limit to last 500 lines to reduce memory usage)
 		process.logMu.Lock()
 		process.Logs = append(process.Logs, line)
 		if len(process.Logs) > 500 {
@@ -963,37 +1397,24 @@ func (m *ProcessManager) monitorProgress(process *Process, stderr io.ReadCloser)
 		}
 		process.logMu.Unlock()
 
-		// Only parse metrics periodically to reduce CPU usage
-		shouldParse := lineCount%parseInterval == 0 || errorRegex.MatchString(line)
-
-		// Check for errors/warnings (always check these)
-		if errorRegex.MatchString(line) {
+		level, ok := classifyLogLine(line)
+		if !ok {
+			continue
+		}
+		if level == domain.LogLevelError || level == domain.LogLevelWarning {
 			logger.Warn().
 				Str("channel_id", process.ChannelID.String()).
 				Str("line", line).
 				Msg("FFmpeg warning/error detected")
 		}
-
-		// Only parse metrics if we should (reduces CPU usage)
-		if shouldParse {
+		if level == domain.LogLevelError {
 			process.mu.Lock()
-			if matches := frameRegex.FindStringSubmatch(line); len(matches) > 1 {
-				process.Metrics.Frame, _ = strconv.ParseInt(matches[1], 10, 64)
-			}
-			if matches := fpsRegex.FindStringSubmatch(line); len(matches) > 1 {
-				process.Metrics.FPS, _ = strconv.ParseFloat(matches[1], 64)
-			}
-			if matches := bitrateRegex.FindStringSubmatch(line); len(matches) > 1 {
-				process.Metrics.Bitrate = matches[1]
-			}
-			if matches := speedRegex.FindStringSubmatch(line); len(matches) > 1 {
-				process.Metrics.Speed = matches[1]
-			}
-			if matches := dropRegex.FindStringSubmatch(line); len(matches) > 1 {
-				process.Metrics.DropFrames, _ = strconv.Atoi(matches[1])
-			}
+			process.LastError = line
 			process.mu.Unlock()
 		}
+
+		// Persist log output
+		m.persistLog(process.ChannelID, level, line)
 	}
 	
 	// Log scanner errors
@@ -1007,21 +1428,39 @@ func (m *ProcessManager) monitorProgress(process *Process, stderr io.ReadCloser)
 
 // watchProcess monitors process health and handles auto-restart
 func (m *ProcessManager) watchProcess(process *Process) {
-	err := process.Cmd.Wait()
+	m.handleExit(process, process.Cmd.Wait())
+}
+
+// handleExit cleans up after a process exited and schedules its auto-restart
+func (m *ProcessManager) handleExit(process *Process, err error) {
+	close(process.done)
+	m.removePidFile(process.ChannelID, process.Cmd.Process.Pid)
+	m.releaseSession(process)
 	
-	// Calculate process uptime to determine if it failed to start
+	// Calculate process uptime, short runs count as failed starts for the restart backoff
 	uptime := time.Since(process.StartedAt)
-	const minUptimeForRestart = 10 * time.Second // If process runs less than 10 seconds, don't auto-restart
 	
 	// Add exit message to logs
 	process.logMu.Lock()
 	if err != nil {
 		process.Logs = append(process.Logs, fmt.Sprintf("[ERROR] Process exited with error: %v (uptime: %v)", err, uptime))
+		m.persistLog(process.ChannelID, domain.LogLevelError, fmt.Sprintf("Process exited with error: %v (uptime: %v)", err, uptime))
 	} else {
 		process.Logs = append(process.Logs, fmt.Sprintf("[INFO] Process exited normally (uptime: %v)", uptime))
+		m.persistLog(process.ChannelID, domain.LogLevelInfo, fmt.Sprintf("Process exited normally (uptime: %v)", uptime))
 	}
 	process.logMu.Unlock()
 	
+	process.mu.RLock()
+	frames := process.Metrics.Frame
+	stallReason := process.StallReason
+	process.mu.RUnlock()
+	
+	// A process killed by the stall watchdog is restarted even without auto-restart
+	if stallReason != "" {
+		err = fmt.Errorf("stalled: %s", stallReason)
+	}
+	
 	// Check if process is still in map (might have been stopped manually)
 	m.mu.Lock()
 	_, stillInMap := m.processes[process.ChannelID]
@@ -1038,10 +1477,46 @@ func (m *ProcessManager) watchProcess(process *Process) {
 			Msg("Process already removed from map (likely stopped manually)")
 	}
 	
+	// Switching back to the primary source starts the next run at once on the same output,
+	// without counting as a restart attempt. If the start fails the exit is handled as usual.
+	if stillInMap && process.switchingSource {
+		startErr := m.startLocked(process.Channel)
+		if startErr == nil {
+			m.mu.Unlock()
+			logger.Info().
+				Str("channel_id", process.ChannelID.String()).
+				Msg("Switched back to primary source")
+			m.persistLog(process.ChannelID, domain.LogLevelInfo, "Switched back to the primary source")
+			return
+		}
+		err = fmt.Errorf("switch back to primary source failed: %w", startErr)
+	}
+
 	// Check if auto-restart is enabled and channel is still supposed to be running
+	// Channels with a slate always return to their source once it answers again, channels
+	// with backup sources fail over to them even without auto-restart
 	autoRestart := false
 	if process.Channel != nil && stillInMap {
-		autoRestart = process.Channel.AutoRestart
+		autoRestart = process.Channel.AutoRestart || stallReason != "" || process.Channel.HasSlate() ||
+			len(process.Channel.BackupSources) > 0
+	}
+	
+	// Schedule the restart while holding the lock so a concurrent Stop can cancel it
+	// A run that exited quickly or never produced a frame counts against the current source
+	var decision restartDecision
+	var switched bool
+	var fromSource, toSource string
+	if autoRestart {
+		minUptime := time.Duration(process.Channel.RestartPolicy.Normalized().MinUptimeSeconds) * time.Second
+		sourceFailed := uptime < minUptime || frames == 0 || stallReason != ""
+		switched, fromSource, toSource = m.recordSourceResultLocked(process.Channel, sourceFailed)
+		decision = m.scheduleRestart(process.Channel, uptime, err)
+		if decision.giveUp == "" {
+			m.startSlateLocked(process.Channel)
+		}
+	} else {
+		delete(m.restarts, process.ChannelID)
+		delete(m.sources, process.ChannelID)
 	}
 	
 	// Get output directory for cleanup
@@ -1086,124 +1561,40 @@ func (m *ProcessManager) watchProcess(process *Process) {
 			Msg("FFmpeg process exited")
 	}
 	
-	// If process ran for less than minUptimeForRestart, it likely failed to start
-	// Don't auto-restart, update channel status to stopped and clean up
-	if uptime < minUptimeForRestart {
+	if switched {
 		logger.Warn().
 			Str("channel_id", process.ChannelID.String()).
-			Dur("uptime", uptime).
-			Msg("FFmpeg process exited too quickly, likely failed to start. Stopping channel instead of auto-restart.")
-		
-		// Clean up channel directory (process failed to start properly)
-		if err := os.RemoveAll(outputDir); err != nil {
-			logger.Warn().
-				Err(err).
-				Str("channel_id", process.ChannelID.String()).
-				Str("output_dir", outputDir).
-				Msg("Failed to remove channel directory after start failure")
-		} else {
-			logger.Info().
-				Str("channel_id", process.ChannelID.String()).
-				Str("output_dir", outputDir).
-				Msg("Cleaned up channel directory after start failure")
-		}
-		
-		// Update channel status to stopped if callback is available
-		if m.statusCallback != nil && process.Channel != nil {
-			if updateErr := m.statusCallback(process.ChannelID, domain.ChannelStatusStopped); updateErr != nil {
-				logger.Error().
-					Err(updateErr).
-					Str("channel_id", process.ChannelID.String()).
-					Msg("Failed to update channel status to stopped")
-			} else {
-				logger.Info().
-					Str("channel_id", process.ChannelID.String()).
-					Str("channel_name", process.Channel.Name).
-					Msg("Channel status updated to stopped due to FFmpeg start failure")
-			}
-		}
-		return // Don't attempt auto-restart
+			Str("from_source", fromSource).
+			Str("to_source", toSource).
+			Msg("Source failed repeatedly, failing over to next source")
+		m.persistLog(process.ChannelID, domain.LogLevelWarning, fmt.Sprintf("Source %s failed %d times, failing over to %s", fromSource, sourceFailoverAfter, toSource))
+	}
+	
+	// Auto-restart is scheduled according to the channel restart policy
+	if autoRestart {
+		m.applyRestartDecision(process.Channel, decision)
+		return
 	}
 	
-	// Auto-restart if enabled and process ran for sufficient time
-	// Double-check that process is still supposed to be running (check map again)
-	if autoRestart && process.Channel != nil {
+	// Process exited but auto-restart is disabled or channel is nil
+	// Clean up directory and mark the channel as stopped (error if FFmpeg failed)
+	m.removeKeys(process.ChannelID)
+	if err := os.RemoveAll(outputDir); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("channel_id", process.ChannelID.String()).
+			Str("output_dir", outputDir).
+			Msg("Failed to remove channel directory after process exit")
+	} else {
 		logger.Info().
 			Str("channel_id", process.ChannelID.String()).
-			Str("channel_name", process.Channel.Name).
-			Msg("Auto-restart enabled, restarting FFmpeg process in 2 seconds...")
-		
-		// Wait 2 seconds before restart to avoid rapid restart loops
-		time.Sleep(2 * time.Second)
-		
-		// Check again if process is still supposed to be running (might have been stopped during wait)
-		m.mu.RLock()
-		_, shouldRestart := m.processes[process.ChannelID]
-		m.mu.RUnlock()
-		
-		if !shouldRestart {
-			logger.Info().
-				Str("channel_id", process.ChannelID.String()).
-				Msg("Process was stopped during restart wait, skipping auto-restart")
-			
-			// Clean up directory if process was stopped
-			if err := os.RemoveAll(outputDir); err != nil {
-				logger.Warn().
-					Err(err).
-					Str("channel_id", process.ChannelID.String()).
-					Str("output_dir", outputDir).
-					Msg("Failed to remove channel directory after restart skip")
-			}
-			return
-		}
-		
-		// Try to restart the process
-		restartErr := m.Start(process.Channel)
-		if restartErr != nil {
-			logger.Error().
-				Err(restartErr).
-				Str("channel_id", process.ChannelID.String()).
-				Msg("Failed to auto-restart FFmpeg process")
-			
-			// Clean up directory on restart failure
-			if err := os.RemoveAll(outputDir); err != nil {
-				logger.Warn().
-					Err(err).
-					Str("channel_id", process.ChannelID.String()).
-					Str("output_dir", outputDir).
-					Msg("Failed to remove channel directory after restart failure")
-			}
-			
-			// If restart fails, update channel status to error/stopped
-			if m.statusCallback != nil {
-				if updateErr := m.statusCallback(process.ChannelID, domain.ChannelStatusError); updateErr != nil {
-					logger.Error().
-						Err(updateErr).
-						Str("channel_id", process.ChannelID.String()).
-						Msg("Failed to update channel status to error")
-				}
-			}
-		} else {
-			logger.Info().
-				Str("channel_id", process.ChannelID.String()).
-				Str("channel_name", process.Channel.Name).
-				Msg("FFmpeg process auto-restarted successfully")
-		}
+			Str("output_dir", outputDir).
+			Msg("Cleaned up channel directory after process exit (no auto-restart)")
+	}
+	if err != nil {
+		m.updateStatus(process.ChannelID, domain.ChannelStatusError)
 	} else {
-		// Process exited but auto-restart is disabled or channel is nil
-		// Clean up directory
-		if err := os.RemoveAll(outputDir); err != nil {
-			logger.Warn().
-				Err(err).
-				Str("channel_id", process.ChannelID.String()).
-				Str("output_dir", outputDir).
-				Msg("Failed to remove channel directory after process exit")
-		} else {
-			logger.Info().
-				Str("channel_id", process.ChannelID.String()).
-				Str("output_dir", outputDir).
-				Msg("Cleaned up channel directory after process exit (no auto-restart)")
-		}
+		m.updateStatus(process.ChannelID, domain.ChannelStatusStopped)
 	}
 }
 
@@ -1412,7 +1803,8 @@ func detectNUMANodes() int {
 }
 
 // getNextNUMANode returns the next NUMA node for round-robin distribution
-func (m *ProcessManager) getNextNUMANode() int {
+// With peek set, the counter is not advanced (used for previews)
+func (m *ProcessManager) getNextNUMANode(peek bool) int {
 	m.numaMu.Lock()
 	defer m.numaMu.Unlock()
 	
@@ -1421,16 +1813,13 @@ func (m *ProcessManager) getNextNUMANode() int {
 	}
 	
 	node := m.numaNodeCounter % m.numaNodeCount
-	m.numaNodeCounter++
+	if !peek {
+		m.numaNodeCounter++
+	}
 	
 	return node
 }
 
-// isNvidiaAvailable checks if NVIDIA GPU is available via nvidia-smi
-func isNvidiaAvailable() bool {
-	return detectGPUCount() > 0
-}
-
 // detectGPUCount detects the number of available NVIDIA GPUs
 func detectGPUCount() int {
 	// Try multiple nvidia-smi locations (container and host mount paths)
@@ -1471,24 +1860,3 @@ func detectGPUCount() int {
 	logger.Debug().Msg("No NVIDIA GPUs detected (nvidia-smi not found or failed)")
 	return 0
 }
-
-// getNextGPUIndex returns the next GPU index for round-robin distribution
-func (m *ProcessManager) getNextGPUIndex() (int, error) {
-	if m.gpuCount == 0 {
-		return 0, fmt.Errorf("no GPUs available")
-	}
-	
-	m.gpuMu.Lock()
-	defer m.gpuMu.Unlock()
-	
-	// Simple round-robin distribution across available GPUs
-	gpuIndex := m.gpuIndexCounter % m.gpuCount
-	
-	// Increment counter for next assignment
-	m.gpuIndexCounter++
-	if m.gpuIndexCounter >= m.gpuCount*1000 {
-		m.gpuIndexCounter = 0 // Reset to prevent overflow
-	}
-	
-	return gpuIndex, nil
-}
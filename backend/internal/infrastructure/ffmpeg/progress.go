@@ -0,0 +1,167 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/domain"
+)
+
+var (
+	// Output segment opened by the HLS muxer (info level), e.g. "Opening '/streams/<id>/segment_00012.ts' for writing"
+	segmentOpenRegex = regexp.MustCompile(`Opening '([^']+)' for writing`)
+	// Input header bitrate, e.g. "Duration: N/A, start: 1.4, bitrate: 5120 kb/s"
+	inputHeaderBitrateRegex = regexp.MustCompile(`Duration: .*bitrate: (\d+) kb/s`)
+	// Input stream bitrate, e.g. "Stream #0:0[0x100]: Video: h264 ..., 4500 kb/s"
+	inputStreamBitrateRegex = regexp.MustCompile(`Stream #0:\d+.*?, (\d+) kb/s`)
+	// Per-stream quality keys of -progress blocks (stream_<file>_<stream>_q)
+	progressStreamKeyRegex = regexp.MustCompile(`^stream_\d+_\d+_q$`)
+)
+
+// progressKeys are the keys FFmpeg writes in a -progress block
+var progressKeys = map[string]bool{
+	"frame": true, "fps": true, "bitrate": true, "total_size": true,
+	"out_time_us": true, "out_time_ms": true, "out_time": true,
+	"dup_frames": true, "drop_frames": true, "speed": true, "progress": true,
+}
+
+// progressParser assembles -progress key=value lines into complete blocks
+// A block ends with a "progress=continue" (or "progress=end") line.
+type progressParser struct {
+	block domain.ProcessMetrics
+}
+
+// Feed consumes one stderr line
+// isProgress reports whether the line belonged to a progress block; a completed block is returned once "progress" is seen.
+func (p *progressParser) Feed(line string) (isProgress bool, completed *domain.ProcessMetrics) {
+	key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+	if !ok || strings.ContainsAny(key, " \t") {
+		return false, nil
+	}
+	if !progressKeys[key] && !progressStreamKeyRegex.MatchString(key) {
+		return false, nil
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "frame":
+		p.block.Frame, _ = strconv.ParseInt(value, 10, 64)
+	case "fps":
+		p.block.FPS, _ = strconv.ParseFloat(value, 64)
+	case "bitrate":
+		p.block.Bitrate = value
+	case "total_size":
+		p.block.TotalSize, _ = strconv.ParseInt(value, 10, 64)
+	case "out_time_us":
+		// Microseconds; out_time_ms carries the same value (historical FFmpeg naming)
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+			p.block.OutTimeMs = us / 1000
+		}
+	case "dup_frames":
+		p.block.DupFrames, _ = strconv.Atoi(value)
+	case "drop_frames":
+		p.block.DropFrames, _ = strconv.Atoi(value)
+	case "speed":
+		p.block.Speed = value
+	case "progress":
+		p.block.Progress = value
+		block := p.block
+		p.block = domain.ProcessMetrics{}
+		return true, &block
+	}
+	return true, nil
+}
+
+// parseProgressBitrate converts a progress bitrate value ("2048.5kbits/s") to kbps
+func parseProgressBitrate(value string) int {
+	value = strings.TrimSuffix(value, "kbits/s")
+	kbps, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int(kbps + 0.5)
+}
+
+// inputBitrateParser extracts the input bitrate from the input dump printed at startup
+// The header bitrate is preferred; live sources often report N/A there, so stream bitrates are summed instead.
+type inputBitrateParser struct {
+	inInput       bool
+	headerKbps    int
+	streamKbpsSum int
+}
+
+// Feed consumes one stderr line and returns the input bitrate in kbps (0 = unknown so far)
+func (p *inputBitrateParser) Feed(line string) int {
+	switch {
+	case strings.Contains(line, "Input #0,"):
+		p.inInput = true
+	case strings.Contains(line, "Output #") || strings.Contains(line, "Stream mapping:"):
+		p.inInput = false
+	case p.inInput:
+		if matches := inputHeaderBitrateRegex.FindStringSubmatch(line); len(matches) > 1 {
+			p.headerKbps, _ = strconv.Atoi(matches[1])
+		} else if matches := inputStreamBitrateRegex.FindStringSubmatch(line); len(matches) > 1 {
+			kbps, _ := strconv.Atoi(matches[1])
+			p.streamKbpsSum += kbps
+		}
+	}
+	if p.headerKbps > 0 {
+		return p.headerKbps
+	}
+	return p.streamKbpsSum
+}
+
+// segmentRateTracker measures the output bitrate from completed HLS segments
+// When the muxer opens a new segment, the previous segment of the same rendition is complete:
+// its size divided by the output time elapsed between both opens gives the rendition bitrate.
+type segmentRateTracker struct {
+	renditions map[string]*segmentState // Keyed by segment directory (one per ladder rendition)
+}
+
+type segmentState struct {
+	path     string
+	openedAt int64 // Output time (ms) when the segment was opened
+	kbps     int
+}
+
+// Opened records a segment open line and returns the total output bitrate in kbps (0 = unknown so far)
+func (t *segmentRateTracker) Opened(path string, outTimeMs int64) int {
+	if !isSegmentFile(path) {
+		return t.total()
+	}
+	if t.renditions == nil {
+		t.renditions = make(map[string]*segmentState)
+	}
+
+	dir := filepath.Dir(path)
+	state, ok := t.renditions[dir]
+	if ok && outTimeMs > state.openedAt {
+		if info, err := os.Stat(state.path); err == nil {
+			state.kbps = int(info.Size() * 8 / (outTimeMs - state.openedAt))
+		}
+	}
+	if !ok {
+		state = &segmentState{}
+		t.renditions[dir] = state
+	}
+	state.path = path
+	state.openedAt = outTimeMs
+	return t.total()
+}
+
+func (t *segmentRateTracker) total() int {
+	total := 0
+	for _, state := range t.renditions {
+		total += state.kbps
+	}
+	return total
+}
+
+// isSegmentFile excludes playlists and fMP4 init segments
+func isSegmentFile(path string) bool {
+	name := filepath.Base(path)
+	return !strings.Contains(name, ".m3u8") && !strings.HasPrefix(name, "init")
+}
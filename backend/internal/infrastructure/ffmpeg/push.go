@@ -0,0 +1,302 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+)
+
+const (
+	relayWaitInterval = time.Second      // Poll interval while the channel playlist does not exist yet
+	relayStallTimeout = 30 * time.Second // A relay that sends nothing for this long is restarted
+	relayStableUptime = 60 * time.Second // Relay runs at least this long reset the retry backoff
+)
+
+// pushRelay copies the HLS output of a channel to one push destination
+// Every destination runs its own FFmpeg reading the local playlist with -c copy, so the
+// channel is encoded once and a failing destination never affects the HLS output or the
+// other destinations. Relays live as long as the channel process (process.done).
+type pushRelay struct {
+	destination domain.PushDestination
+	protocol    domain.PushProtocol
+	mu          sync.Mutex
+	status      domain.PushStatus
+	lastSentAt  time.Time
+}
+
+// startRelays starts one relay per enabled push destination of the process channel
+func (m *ProcessManager) startRelays(process *Process, outputDir string) {
+	if process.Channel == nil || process.Channel.OutputConfig == nil {
+		return
+	}
+	for _, destination := range process.Channel.OutputConfig.Push {
+		if destination.Disabled {
+			continue
+		}
+		protocol, err := destination.Protocol()
+		if err != nil {
+			continue // Rejected on save
+		}
+		relay := &pushRelay{
+			destination: destination,
+			protocol:    protocol,
+			status: domain.PushStatus{
+				Name:     destination.Name,
+				Protocol: protocol,
+				State:    domain.PushStateWaiting,
+				Since:    time.Now(),
+			},
+		}
+		process.relays = append(process.relays, relay)
+		go m.runRelay(process, relay, outputDir)
+	}
+}
+
+// relayStatuses returns the health of the push destinations of a process
+func relayStatuses(process *Process) []domain.PushStatus {
+	if len(process.relays) == 0 {
+		return nil
+	}
+	statuses := make([]domain.PushStatus, len(process.relays))
+	for i, relay := range process.relays {
+		relay.mu.Lock()
+		statuses[i] = relay.status
+		relay.mu.Unlock()
+	}
+	return statuses
+}
+
+// runRelay runs the relay of a destination until the channel process exits, retrying failures with backoff
+func (m *ProcessManager) runRelay(process *Process, relay *pushRelay, outputDir string) {
+	policy := domain.DefaultRestartPolicy()
+	consecutiveFailures := 0
+	for {
+		// The playlist appears once FFmpeg finished its first segment
+		input, videoIndex, ok := relayInput(process.Channel, outputDir)
+		if !ok {
+			select {
+			case <-process.done:
+				return
+			case <-time.After(relayWaitInterval):
+				continue
+			}
+		}
+
+		startedAt := time.Now()
+		err := m.runRelayOnce(process, relay, input, videoIndex)
+		select {
+		case <-process.done:
+			return
+		default:
+		}
+
+		if time.Since(startedAt) >= relayStableUptime {
+			consecutiveFailures = 0
+		} else {
+			consecutiveFailures++
+		}
+		delay := policy.Backoff(consecutiveFailures, rand.Float64())
+		nextRetryAt := time.Now().Add(delay)
+
+		relay.mu.Lock()
+		if err == nil {
+			err = fmt.Errorf("relay exited")
+		}
+		relay.status.State = domain.PushStateRetrying
+		relay.status.Since = time.Now()
+		relay.status.Failures++
+		relay.status.Bitrate = 0
+		relay.status.NextRetryAt = &nextRetryAt
+		if relay.status.LastError == "" {
+			relay.status.LastError = err.Error()
+		}
+		lastError := relay.status.LastError
+		relay.mu.Unlock()
+
+		logger.Warn().
+			Err(err).
+			Str("channel_id", process.ChannelID.String()).
+			Str("destination", relay.destination.Name).
+			Dur("retry_in", delay).
+			Msg("Push relay failed, retrying")
+		m.persistLog(process.ChannelID, domain.LogLevelWarning, fmt.Sprintf("Push %s failed (%s), retrying in %v", relay.destination.Name, lastError, delay.Round(time.Second)))
+
+		select {
+		case <-process.done:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runRelayOnce runs one relay FFmpeg until it exits, stalls or the channel process exits
+func (m *ProcessManager) runRelayOnce(process *Process, relay *pushRelay, input string, videoIndex int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, m.config.BinaryPath, relayArgs(input, videoIndex, relay.destination.URL, relay.protocol)...)
+	cmd.SysProcAttr = relaySysProcAttr()
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start relay: %w", err)
+	}
+
+	relay.mu.Lock()
+	relay.status.State = domain.PushStateConnecting
+	relay.status.Since = time.Now()
+	relay.status.LastError = ""
+	relay.status.NextRetryAt = nil
+	relay.status.BytesSent = 0
+	relay.lastSentAt = time.Now()
+	relay.mu.Unlock()
+
+	// Stop with the channel and restart relays that stopped sending
+	stalled := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(stallCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-process.done:
+				cancel()
+				return
+			case now := <-ticker.C:
+				relay.mu.Lock()
+				idle := now.Sub(relay.lastSentAt)
+				relay.mu.Unlock()
+				if idle > relayStallTimeout {
+					close(stalled)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	m.monitorRelay(process, relay, bufio.NewScanner(stderr))
+	err = cmd.Wait()
+	select {
+	case <-stalled:
+		return fmt.Errorf("nothing sent for %v", relayStallTimeout)
+	default:
+	}
+	return err
+}
+
+// monitorRelay applies relay progress blocks to the destination status and keeps its last error
+func (m *ProcessManager) monitorRelay(process *Process, relay *pushRelay, scanner *bufio.Scanner) {
+	var progress progressParser
+	for scanner.Scan() {
+		line := scanner.Text()
+		if isProgress, block := progress.Feed(line); isProgress {
+			if block == nil {
+				continue
+			}
+			relay.mu.Lock()
+			if block.TotalSize > relay.status.BytesSent {
+				relay.lastSentAt = time.Now()
+			}
+			relay.status.BytesSent = block.TotalSize
+			relay.status.Bitrate = parseProgressBitrate(block.Bitrate)
+			recovered := false
+			if relay.status.State == domain.PushStateConnecting && block.TotalSize > 0 {
+				relay.status.State = domain.PushStateRunning
+				relay.status.Since = time.Now()
+				recovered = relay.status.Failures > 0
+			}
+			relay.mu.Unlock()
+			if recovered {
+				m.persistLog(process.ChannelID, domain.LogLevelInfo, fmt.Sprintf("Push %s recovered", relay.destination.Name))
+			}
+			continue
+		}
+
+		if level, ok := classifyLogLine(line); ok && level == domain.LogLevelError {
+			relay.mu.Lock()
+			relay.status.LastError = line
+			relay.mu.Unlock()
+		}
+	}
+}
+
+// relayInput returns the playlist a relay reads and the video stream it forwards
+// Channels with a master playlist forward their largest ladder rendition.
+func relayInput(channel *domain.Channel, outputDir string) (string, int, bool) {
+	master := filepath.Join(outputDir, masterPlaylistName)
+	if _, err := os.Stat(master); err == nil {
+		videoIndex := 0
+		if renditions, err := resolveLadder(channel); err == nil && len(renditions) > 0 {
+			videoIndex = largestRendition(renditions)
+		}
+		return master, videoIndex, true
+	}
+	index := filepath.Join(outputDir, "index.m3u8")
+	if _, err := os.Stat(index); err == nil {
+		return index, 0, true
+	}
+	return "", 0, false
+}
+
+// relayArgs builds the FFmpeg arguments copying the channel output to a destination
+func relayArgs(input string, videoIndex int, destination string, protocol domain.PushProtocol) []string {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "level+warning",
+		"-nostats",
+		"-progress", "pipe:2",
+		"-re",                     // Send at the native rate instead of one burst per segment
+		"-live_start_index", "-1", // Start at the newest segment
+		"-i", input,
+		"-map", fmt.Sprintf("0:v:%d", videoIndex),
+		"-map", "0:a:0?",
+		"-c", "copy",
+	}
+	switch protocol {
+	case domain.PushProtocolRTMP, domain.PushProtocolRTMPS:
+		args = append(args, "-f", "flv")
+	case domain.PushProtocolRTP:
+		args = append(args, "-f", "rtp_mpegts")
+	default:
+		args = append(args, "-f", "mpegts")
+	}
+	return append(args, relayURL(destination, protocol))
+}
+
+// relayURL adds transport defaults the destination URL does not set
+// UDP datagrams carry 7 TS packets, SRT destinations connect as caller.
+func relayURL(destination string, protocol domain.PushProtocol) string {
+	parsed, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+	query := parsed.Query()
+	switch protocol {
+	case domain.PushProtocolUDP:
+		if query.Get("pkt_size") == "" {
+			query.Set("pkt_size", "1316")
+		}
+	case domain.PushProtocolSRT:
+		if query.Get("mode") == "" {
+			query.Set("mode", "caller")
+		}
+	default:
+		return destination
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
@@ -0,0 +1,9 @@
+package ffmpeg
+
+import "syscall"
+
+// relaySysProcAttr ends relays together with the backend
+// Unlike channel processes, relays are not adopted after a restart and would push twice.
+func relaySysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Pdeathsig: syscall.SIGTERM}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ffmpeg
+
+import "syscall"
+
+// relaySysProcAttr returns no attributes, parent death signals are Linux only
+func relaySysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,261 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	recordingWaitTimeout = 30 * time.Second // How long a recording waits for the channel playlist
+	recordingStopTimeout = 10 * time.Second // Time FFmpeg gets to finalize the MP4 after an interrupt
+
+	// RecordingPartSuffix marks an archive FFmpeg is still writing
+	RecordingPartSuffix = ".part"
+)
+
+// errChannelStopped ends a recording whose channel was stopped
+var errChannelStopped = fmt.Errorf("channel stopped")
+
+// Record copies the live output of a running channel into an MP4 file
+// Like push relays, the recorder reads the local HLS playlist with -c copy, so the channel
+// is encoded once. Each FFmpeg run of the channel is captured into its own part file
+// (path.N.part): an auto-restart, a watchdog kill or a source switch starts a new part
+// until the duration has passed, and the parts are joined into path at the end. When the
+// recording ends early (ctx cancelled, channel stopped) the captured parts are kept and the
+// returned error says why.
+func (m *ProcessManager) Record(ctx context.Context, channelID uuid.UUID, path string, duration time.Duration) error {
+	end := time.Now().Add(duration)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	var parts []string
+	var reason error
+	var process *Process
+	for {
+		next, err := m.waitForRun(ctx, channelID, end, process)
+		if err != nil || next == nil {
+			reason = err
+			break
+		}
+		process = next
+		if output := process.Channel.OutputConfig; output != nil && output.Encryption != nil && output.Encryption.Enabled {
+			reason = fmt.Errorf("encrypted channels cannot be recorded (the recorder reads the HLS output)")
+			break
+		}
+
+		part := fmt.Sprintf("%s.%d%s", path, len(parts), RecordingPartSuffix)
+		parts = append(parts, part)
+		if len(parts) > 1 {
+			m.persistLog(channelID, domain.LogLevelInfo, fmt.Sprintf("Channel process restarted, recording continues in part %d", len(parts)))
+		}
+		exited, err := m.recordRun(ctx, process, part, time.Until(end))
+		if !exited || time.Until(end) < time.Second {
+			reason = err
+			break
+		}
+	}
+
+	if err := joinRecordingParts(m.config.BinaryPath, parts, path); err != nil && reason == nil {
+		reason = err
+	}
+	return reason
+}
+
+// waitForRun returns the running process of a channel
+// Between two runs (pending auto-restart, slate on air) it waits for the next one after
+// previous. It returns nil once end has passed, and errChannelStopped when no run will follow.
+func (m *ProcessManager) waitForRun(ctx context.Context, channelID uuid.UUID, end time.Time, previous *Process) (*Process, error) {
+	for {
+		m.mu.RLock()
+		process, running := m.processes[channelID]
+		state, restarting := m.restarts[channelID]
+		pending := restarting && state.timer != nil
+		_, onSlate := m.slates[channelID]
+		m.mu.RUnlock()
+
+		switch {
+		case running && process != previous:
+			return process, nil
+		case previous == nil:
+			return nil, fmt.Errorf("channel is not running")
+		case running:
+			// previous has exited but handleExit has not taken it out of the map yet
+		case !pending && !onSlate:
+			return nil, errChannelStopped
+		}
+		if time.Now().After(end) {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(relayWaitInterval):
+		}
+	}
+}
+
+// recordRun captures one FFmpeg run of a channel into a part file
+// exited reports that the run ended before the part was complete (the channel may continue).
+func (m *ProcessManager) recordRun(ctx context.Context, process *Process, part string, duration time.Duration) (exited bool, err error) {
+	outputDir := filepath.Join(m.hlsPath, process.ChannelID.String())
+	input, videoIndex, ok := relayInput(process.Channel, outputDir)
+	for deadline := time.Now().Add(recordingWaitTimeout); !ok; input, videoIndex, ok = relayInput(process.Channel, outputDir) {
+		if time.Now().After(deadline) {
+			return false, fmt.Errorf("channel playlist not available after %v", recordingWaitTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-process.done:
+			return true, errChannelStopped
+		case <-time.After(relayWaitInterval):
+		}
+	}
+
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, m.config.BinaryPath, recordArgs(input, videoIndex, duration, part)...)
+	cmd.SysProcAttr = relaySysProcAttr()
+	// Interrupt instead of kill so FFmpeg writes the MP4 index of what it captured
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = recordingStopTimeout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("failed to start recorder: %w", err)
+	}
+
+	logger.Info().
+		Str("channel_id", process.ChannelID.String()).
+		Str("path", part).
+		Dur("duration", duration).
+		Msg("Recording started")
+
+	// Stop with the job or the channel run
+	stopReason := make(chan error, 1)
+	go func() {
+		select {
+		case <-cmdCtx.Done():
+			return
+		case <-ctx.Done():
+			stopReason <- ctx.Err()
+		case <-process.done:
+			stopReason <- errChannelStopped
+		}
+		cancel()
+	}()
+
+	lastError := ""
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if level, ok := classifyLogLine(line); ok && level == domain.LogLevelError {
+			lastError = line
+		}
+	}
+	err = cmd.Wait()
+
+	select {
+	case reason := <-stopReason:
+		return reason == errChannelStopped, reason
+	default:
+	}
+	if err != nil {
+		if lastError != "" {
+			return false, fmt.Errorf("recorder exited: %s", lastError)
+		}
+		return false, fmt.Errorf("recorder exited: %w", err)
+	}
+	return false, nil
+}
+
+// joinRecordingParts turns the captured parts into the archive at path
+// A single part is renamed, several are joined with the concat demuxer (-c copy). Empty
+// parts are dropped; without any data the recording fails.
+func joinRecordingParts(ffmpegPath string, parts []string, path string) error {
+	var captured []string
+	for _, part := range parts {
+		if info, err := os.Stat(part); err == nil && info.Size() > 0 {
+			captured = append(captured, part)
+		} else {
+			os.Remove(part)
+		}
+	}
+
+	switch len(captured) {
+	case 0:
+		return fmt.Errorf("recorder wrote no data")
+	case 1:
+		if err := os.Rename(captured[0], path); err != nil {
+			return fmt.Errorf("failed to finalize archive: %w", err)
+		}
+		return nil
+	}
+
+	list := path + ".parts.txt"
+	var entries strings.Builder
+	for _, part := range captured {
+		fmt.Fprintf(&entries, "file '%s'\n", strings.ReplaceAll(part, "'", `'\''`))
+	}
+	if err := os.WriteFile(list, []byte(entries.String()), 0644); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	defer os.Remove(list)
+
+	joined := path + RecordingPartSuffix
+	output, err := exec.Command(ffmpegPath,
+		"-hide_banner",
+		"-loglevel", "error",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", list,
+		"-c", "copy",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y", joined,
+	).CombinedOutput()
+	if err != nil {
+		os.Remove(joined)
+		return fmt.Errorf("failed to join recording parts: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if err := os.Rename(joined, path); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	for _, part := range captured {
+		os.Remove(part)
+	}
+	return nil
+}
+
+// recordArgs builds the FFmpeg arguments copying the channel output into an MP4 file
+func recordArgs(input string, videoIndex int, duration time.Duration, path string) []string {
+	return []string{
+		"-hide_banner",
+		"-loglevel", "level+warning",
+		"-nostats",
+		"-live_start_index", "-1", // Start at the newest segment
+		"-i", input,
+		"-map", fmt.Sprintf("0:v:%d", videoIndex),
+		"-map", "0:a:0?",
+		"-c", "copy",
+		"-t", strconv.FormatFloat(duration.Seconds(), 'f', -1, 64),
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y", path,
+	}
+}
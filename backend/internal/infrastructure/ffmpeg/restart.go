@@ -0,0 +1,277 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// restartState tracks the auto-restarts of a channel across process runs
+// It lives from the first unexpected exit until the channel is started or stopped manually.
+type restartState struct {
+	policy              domain.RestartPolicy
+	attempts            []time.Time // Restart times inside the policy window
+	totalRestarts       int
+	consecutiveFailures int // Runs in a row shorter than the policy min uptime
+	nextRetryAt         time.Time
+	gaveUp              bool
+	lastExitAt          time.Time
+	lastExitError       string
+	timer               *time.Timer // Pending restart (nil = none)
+}
+
+// restartDecision is the outcome of scheduleRestart
+type restartDecision struct {
+	delay  time.Duration
+	giveUp domain.GiveUpAction // Empty when a restart was scheduled
+}
+
+// scheduleRestart records an unexpected exit and schedules the next restart according to the channel policy
+// Must be called with m.mu held.
+func (m *ProcessManager) scheduleRestart(channel *domain.Channel, uptime time.Duration, exitErr error) restartDecision {
+	policy := channel.RestartPolicy.Normalized()
+	state, exists := m.restarts[channel.ID]
+	if !exists {
+		state = &restartState{}
+		m.restarts[channel.ID] = state
+	}
+	state.policy = policy
+	if state.timer != nil {
+		state.timer.Stop()
+		state.timer = nil
+	}
+
+	now := time.Now()
+	state.lastExitAt = now
+	state.lastExitError = ""
+	if exitErr != nil {
+		state.lastExitError = exitErr.Error()
+	}
+	if uptime >= time.Duration(policy.MinUptimeSeconds)*time.Second {
+		state.consecutiveFailures = 0
+	} else {
+		state.consecutiveFailures++
+	}
+	state.pruneAttempts(now)
+
+	delay := policy.Backoff(state.consecutiveFailures, rand.Float64())
+	if policy.MaxAttempts > 0 && len(state.attempts) >= policy.MaxAttempts {
+		if policy.GiveUp != domain.GiveUpCooldown {
+			state.gaveUp = true
+			state.nextRetryAt = time.Time{}
+			return restartDecision{giveUp: policy.GiveUp}
+		}
+		// Cooldown: pause, then retry with a fresh window and backoff
+		state.attempts = nil
+		state.consecutiveFailures = 0
+		delay = time.Duration(policy.CooldownSeconds) * time.Second
+	}
+
+	state.gaveUp = false
+	state.nextRetryAt = now.Add(delay)
+	state.timer = time.AfterFunc(delay, func() {
+		m.retryStart(channel, state)
+	})
+	return restartDecision{delay: delay}
+}
+
+// retryStart runs a scheduled restart unless it was cancelled in the meantime
+// While the slate is on air the source is probed first: the slate stays up until it answers.
+func (m *ProcessManager) retryStart(channel *domain.Channel, state *restartState) {
+	m.mu.RLock()
+	source, _ := m.activeSourceLocked(channel)
+	_, onSlate := m.slates[channel.ID]
+	m.mu.RUnlock()
+	if onSlate && !probeSource(m.config.BinaryPath, source) {
+		m.retrySlateLater(channel, state)
+		return
+	}
+	m.refreshSourceProbe(source)
+
+	m.mu.Lock()
+	if m.restarts[channel.ID] != state || state.timer == nil {
+		m.mu.Unlock()
+		return // Cancelled by a manual start or stop
+	}
+	if slate := m.takeSlateLocked(channel.ID); slate != nil {
+		// The slate must have finished its playlist before the source run appends to it
+		m.mu.Unlock()
+		slate.stop()
+		m.persistLog(channel.ID, domain.LogLevelInfo, "Source is back, switching from the slate")
+		m.mu.Lock()
+		if m.restarts[channel.ID] != state || state.timer == nil {
+			m.mu.Unlock()
+			return
+		}
+	}
+	now := time.Now()
+	state.timer = nil
+	state.nextRetryAt = time.Time{}
+	state.attempts = append(state.attempts, now)
+	state.totalRestarts++
+	attempt := state.totalRestarts
+
+	err := m.startLocked(channel)
+	var decision restartDecision
+	if err != nil {
+		decision = m.scheduleRestart(channel, 0, err)
+		if decision.giveUp == "" {
+			m.startSlateLocked(channel)
+		}
+	}
+	m.mu.Unlock()
+
+	if err == nil {
+		logger.Info().
+			Str("channel_id", channel.ID.String()).
+			Str("channel_name", channel.Name).
+			Int("attempt", attempt).
+			Msg("FFmpeg process auto-restarted successfully")
+		m.updateStatus(channel.ID, domain.ChannelStatusRunning)
+		return
+	}
+
+	logger.Error().
+		Err(err).
+		Str("channel_id", channel.ID.String()).
+		Int("attempt", attempt).
+		Msg("Failed to auto-restart FFmpeg process")
+	m.persistLog(channel.ID, domain.LogLevelError, fmt.Sprintf("Auto-restart failed: %v", err))
+	m.applyRestartDecision(channel, decision)
+}
+
+// retrySlateLater probes the source again after slateProbeInterval, unless the restart was cancelled
+// Probes do not count as restart attempts, the channel stays on the slate as long as needed.
+func (m *ProcessManager) retrySlateLater(channel *domain.Channel, state *restartState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.restarts[channel.ID] != state || state.timer == nil {
+		return
+	}
+	state.nextRetryAt = time.Now().Add(slateProbeInterval)
+	state.timer = time.AfterFunc(slateProbeInterval, func() {
+		m.retryStart(channel, state)
+	})
+}
+
+// applyRestartDecision logs the decision and updates the channel status
+func (m *ProcessManager) applyRestartDecision(channel *domain.Channel, decision restartDecision) {
+	outputDir := filepath.Join(m.hlsPath, channel.ID.String())
+
+	switch decision.giveUp {
+	case "":
+		logger.Info().
+			Str("channel_id", channel.ID.String()).
+			Str("channel_name", channel.Name).
+			Dur("delay", decision.delay).
+			Msg("Auto-restart scheduled")
+		m.persistLog(channel.ID, domain.LogLevelInfo, fmt.Sprintf("Auto-restart scheduled in %v", decision.delay.Round(time.Millisecond)))
+		m.updateStatus(channel.ID, domain.ChannelStatusStarting)
+		return
+	case domain.GiveUpStop:
+		m.updateStatus(channel.ID, domain.ChannelStatusStopped)
+	default:
+		m.updateStatus(channel.ID, domain.ChannelStatusError)
+	}
+
+	logger.Warn().
+		Str("channel_id", channel.ID.String()).
+		Str("channel_name", channel.Name).
+		Str("give_up", string(decision.giveUp)).
+		Msg("Restart attempts exhausted, giving up auto-restart")
+	m.persistLog(channel.ID, domain.LogLevelError, "Restart attempts exhausted, giving up auto-restart")
+
+	if err := os.RemoveAll(outputDir); err != nil {
+		logger.Warn().
+			Err(err).
+			Str("channel_id", channel.ID.String()).
+			Str("output_dir", outputDir).
+			Msg("Failed to remove channel directory after giving up")
+	}
+}
+
+// cancelRestartLocked drops the restart state of a channel and cancels a pending restart
+// Must be called with m.mu held.
+func (m *ProcessManager) cancelRestartLocked(channelID uuid.UUID) bool {
+	state, exists := m.restarts[channelID]
+	if !exists {
+		return false
+	}
+	pending := state.timer != nil
+	if pending {
+		state.timer.Stop()
+		state.timer = nil
+	}
+	delete(m.restarts, channelID)
+	return pending
+}
+
+// restartStatusLocked returns the restart counters of a channel (nil if it never auto-restarted)
+// Must be called with m.mu held (read lock is enough).
+func (m *ProcessManager) restartStatusLocked(channelID uuid.UUID) *domain.RestartStatus {
+	state, exists := m.restarts[channelID]
+	if !exists {
+		return nil
+	}
+
+	status := &domain.RestartStatus{
+		Attempts:            state.attemptsSince(time.Now()),
+		TotalRestarts:       state.totalRestarts,
+		ConsecutiveFailures: state.consecutiveFailures,
+		GaveUp:              state.gaveUp,
+		LastExitError:       state.lastExitError,
+	}
+	if !state.nextRetryAt.IsZero() {
+		nextRetryAt := state.nextRetryAt
+		status.NextRetryAt = &nextRetryAt
+	}
+	if !state.lastExitAt.IsZero() {
+		lastExitAt := state.lastExitAt
+		status.LastExitAt = &lastExitAt
+	}
+	return status
+}
+
+// pruneAttempts drops restart times that fell out of the policy window
+func (s *restartState) pruneAttempts(now time.Time) {
+	cutoff := now.Add(-time.Duration(s.policy.WindowSeconds) * time.Second)
+	kept := s.attempts[:0]
+	for _, attempt := range s.attempts {
+		if attempt.After(cutoff) {
+			kept = append(kept, attempt)
+		}
+	}
+	s.attempts = kept
+}
+
+// attemptsSince counts the restarts inside the policy window without modifying the state
+func (s *restartState) attemptsSince(now time.Time) int {
+	cutoff := now.Add(-time.Duration(s.policy.WindowSeconds) * time.Second)
+	count := 0
+	for _, attempt := range s.attempts {
+		if attempt.After(cutoff) {
+			count++
+		}
+	}
+	return count
+}
+
+// updateStatus reports a channel status change through the status callback
+func (m *ProcessManager) updateStatus(channelID uuid.UUID, status domain.ChannelStatus) {
+	if m.statusCallback == nil {
+		return
+	}
+	if err := m.statusCallback(channelID, status); err != nil {
+		logger.Error().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Str("status", string(status)).
+			Msg("Failed to update channel status")
+	}
+}
@@ -0,0 +1,223 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	slateProbeInterval = 5 * time.Second // How often the source is probed while the slate is on air
+	slateStopTimeout   = 5 * time.Second // Time the slate gets to finish its segment before it is killed
+	slateFrameRate     = 30              // Frame rate of still image slates (GOP sizes assume 30 fps)
+)
+
+// slateProcess is the FFmpeg process keeping the output of a channel alive while its source is down
+// It writes the same variants as the channel process, appending to its playlists, and is
+// replaced by the channel process once the source answers a probe again. Like relays, the
+// slate ends together with the backend and is never adopted.
+type slateProcess struct {
+	cmd       *exec.Cmd
+	cancel    context.CancelFunc
+	startedAt time.Time
+	backend   domain.EncoderBackend
+	device    int
+	done      chan struct{} // Closed once the slate has exited
+}
+
+// startSlateLocked puts the slate of a channel on air (must be called with m.mu held)
+// Failures are logged, the channel then waits for its restart without output.
+func (m *ProcessManager) startSlateLocked(channel *domain.Channel) {
+	if !channel.HasSlate() {
+		return
+	}
+	if _, exists := m.slates[channel.ID]; exists {
+		return
+	}
+
+	outputDir := filepath.Join(m.hlsPath, channel.ID.String())
+	source, _ := m.activeSourceLocked(channel)
+	input := *channel
+	input.SourceURL = source // Audio and subtitle layout of the source the channel returns to
+
+	slate, err := m.launchSlate(&input, outputDir)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Str("channel_id", channel.ID.String()).
+			Msg("Failed to start slate")
+		m.persistLog(channel.ID, domain.LogLevelError, fmt.Sprintf("Slate failed to start: %v", err))
+		return
+	}
+	m.slates[channel.ID] = slate
+	go m.watchSlate(channel.ID, slate)
+
+	logger.Info().
+		Str("channel_id", channel.ID.String()).
+		Str("slate", channel.OutputConfig.Slate.Path).
+		Int("pid", slate.cmd.Process.Pid).
+		Msg("Source is down, slate is on air")
+	m.persistLog(channel.ID, domain.LogLevelWarning, "Source is down, slate is on air")
+}
+
+// launchSlate starts the slate FFmpeg for a channel
+func (m *ProcessManager) launchSlate(channel *domain.Channel, outputDir string) (*slateProcess, error) {
+	plan, err := m.buildArgs(channel, outputDir, len(m.processes), false, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.admitSession(plan.encoder); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	argv, _ := m.commandArgv(plan.args, false)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = plan.env()
+	cmd.SysProcAttr = relaySysProcAttr()
+	// Interrupt instead of kill so the segment in progress is completed
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = slateStopTimeout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start FFmpeg: %w", err)
+	}
+
+	slate := &slateProcess{
+		cmd:       cmd,
+		cancel:    cancel,
+		startedAt: time.Now(),
+		backend:   plan.encoder.backend.Name(),
+		device:    plan.encoder.device,
+		done:      make(chan struct{}),
+	}
+	m.acquireSession(slate.backend, slate.device)
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			if level, ok := classifyLogLine(scanner.Text()); ok && level == domain.LogLevelError {
+				m.persistLog(channel.ID, level, "Slate: "+scanner.Text())
+			}
+		}
+	}()
+	return slate, nil
+}
+
+// watchSlate waits for the slate to exit and drops it if it ended on its own
+func (m *ProcessManager) watchSlate(channelID uuid.UUID, slate *slateProcess) {
+	err := slate.cmd.Wait()
+	close(slate.done)
+	m.releaseDeviceSession(slate.backend, slate.device)
+
+	m.mu.Lock()
+	current := m.slates[channelID] == slate
+	if current {
+		delete(m.slates, channelID)
+	}
+	m.mu.Unlock()
+
+	if current {
+		logger.Error().
+			Err(err).
+			Str("channel_id", channelID.String()).
+			Msg("Slate exited unexpectedly")
+		m.persistLog(channelID, domain.LogLevelError, fmt.Sprintf("Slate exited unexpectedly: %v", err))
+	}
+}
+
+// takeSlateLocked removes the slate of a channel from the map (must be called with m.mu held)
+// The caller stops the returned slate (nil if none is on air).
+func (m *ProcessManager) takeSlateLocked(channelID uuid.UUID) *slateProcess {
+	slate := m.slates[channelID]
+	delete(m.slates, channelID)
+	return slate
+}
+
+// stop interrupts the slate and waits until it has exited
+func (s *slateProcess) stop() {
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// slateStatusLocked returns since when the slate of a channel is on air (must be called with m.mu held)
+func (m *ProcessManager) slateStatusLocked(channelID uuid.UUID) *time.Time {
+	slate, exists := m.slates[channelID]
+	if !exists {
+		return nil
+	}
+	since := slate.startedAt
+	return &since
+}
+
+// slateInputArgs returns the input looping the slate file in real time
+func (m *ProcessManager) slateInputArgs(slate domain.SlateConfig) ([]string, error) {
+	path := slate.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.logoPath, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("slate file not found: %s", path)
+	}
+	if slate.IsImage() {
+		return []string{"-re", "-loop", "1", "-framerate", strconv.Itoa(slateFrameRate), "-i", path}, nil
+	}
+	return []string{"-re", "-stream_loop", "-1", "-i", path}, nil
+}
+
+// slateAudioTracks points every output audio track at the slate audio
+// Generated audio is read from the input at generatedInput, clip audio from the slate file.
+func slateAudioTracks(tracks []audioTrack, slate domain.SlateConfig, generatedInput int) []audioTrack {
+	input := fmt.Sprintf("%d:a", generatedInput)
+	if slate.Audio == domain.SlateAudioClip {
+		input = "0:a:0"
+	}
+	for i := range tracks {
+		tracks[i].input = input
+		tracks[i].silent = true // Always encoded, even in passthrough mode
+	}
+	return tracks
+}
+
+// slateAudioInputArgs returns the lavfi input generating silence or a tone for the slate
+// Nothing is generated for clip audio or channels without audio tracks.
+func slateAudioInputArgs(slate domain.SlateConfig, config domain.AudioConfig, tracks []audioTrack) []string {
+	if len(tracks) == 0 || slate.Audio == domain.SlateAudioClip {
+		return nil
+	}
+	if slate.Audio != domain.SlateAudioTone {
+		return append([]string{"-re"}, silentInputArgs(config)...)
+	}
+	return []string{
+		"-re",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("sine=frequency=%d:sample_rate=%d", slate.ToneFrequency, config.SampleRate),
+	}
+}
+
+// inputScaleFilter scales the input video to the output size
+// Slates keep their aspect ratio and are letterboxed, the source is scaled as before.
+func inputScaleFilter(width, height int, slate bool) string {
+	if !slate {
+		return fmt.Sprintf("scale=%d:%d", width, height)
+	}
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1",
+		width, height, width, height)
+}
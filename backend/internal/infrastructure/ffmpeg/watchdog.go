@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/pkg/logger"
+)
+
+const stallCheckInterval = 5 * time.Second
+
+// watchStall kills a process whose frame counter or segment output stops advancing
+// for Config.StallTimeout; watchProcess then restarts it like a crashed process.
+// Adopted processes have no progress output, only their segments are watched.
+// Segments are tracked from the segment opens on stderr (the adopted playlist for adopted
+// processes), so the check does not touch the output directory.
+func (m *ProcessManager) watchStall(process *Process) {
+	timeout := time.Duration(m.config.StallTimeout) * time.Second
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+
+	var lastFrame int64
+	lastFrameAt := process.StartedAt
+	for {
+		select {
+		case <-process.done:
+			return
+		case now := <-ticker.C:
+			process.mu.RLock()
+			frame := process.Metrics.Frame
+			lastSegmentAt := process.lastSegmentAt
+			process.mu.RUnlock()
+			if frame > lastFrame || process.Adopted {
+				lastFrame = frame
+				lastFrameAt = now
+			}
+
+			// Until the first segment the process has the timeout from its start
+			if lastSegmentAt.Before(process.StartedAt) {
+				lastSegmentAt = process.StartedAt
+			}
+
+			var reason string
+			if stalled := now.Sub(lastFrameAt); stalled > timeout {
+				reason = fmt.Sprintf("frame counter stuck at %d for %v", frame, stalled.Round(time.Second))
+			} else if stalled := now.Sub(lastSegmentAt); stalled > timeout {
+				reason = fmt.Sprintf("no new segment for %v", stalled.Round(time.Second))
+			}
+			if reason != "" {
+				m.killStalled(process, reason)
+				return
+			}
+		}
+	}
+}
+
+// killStalled records the stall reason and kills the process group
+// The process stays in the map, so watchProcess treats the exit as a failure and restarts it.
+func (m *ProcessManager) killStalled(process *Process, reason string) {
+	process.mu.Lock()
+	process.StallReason = reason
+	process.LastError = "stalled: " + reason
+	pid := 0
+	if process.Cmd != nil && process.Cmd.Process != nil {
+		pid = process.Cmd.Process.Pid
+	}
+	process.mu.Unlock()
+
+	logger.Warn().
+		Str("channel_id", process.ChannelID.String()).
+		Int("pid", pid).
+		Str("reason", reason).
+		Msg("Watchdog detected stalled FFmpeg process, killing it")
+	m.persistLog(process.ChannelID, domain.LogLevelWarning, fmt.Sprintf("Watchdog: %s, restarting process", reason))
+
+	if pid == 0 {
+		return
+	}
+	if pgid, err := syscall.Getpgid(pid); err == nil {
+		syscall.Kill(-pgid, syscall.SIGKILL)
+	} else {
+		process.Cmd.Process.Kill()
+	}
+}
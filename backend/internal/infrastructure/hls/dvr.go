@@ -0,0 +1,192 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DVRMarkerName is the file marking an output directory as a DVR channel
+const DVRMarkerName = ".dvr"
+
+// dvrSegmentRegex matches the media and WebVTT segment files FFmpeg writes for a channel
+var dvrSegmentRegex = regexp.MustCompile(`(\d+)\.(ts|m4s|vtt)$`)
+
+// DVRMarker describes the time-shift window of a DVR channel
+type DVRMarker struct {
+	WindowSegments int `json:"window_segments"` // Segments FFmpeg keeps listed
+	Floor          int `json:"floor,omitempty"` // Lowest segment still on disk after disk limit trimming
+}
+
+// WriteDVRMarker marks dir as DVR output (replaced atomically, the stream handler reads it per request)
+func WriteDVRMarker(dir string, marker DVRMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, DVRMarkerName+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, DVRMarkerName))
+}
+
+// RemoveDVRMarker drops the DVR marker of dir (the channel no longer keeps a window)
+func RemoveDVRMarker(dir string) {
+	os.Remove(filepath.Join(dir, DVRMarkerName))
+}
+
+// ReadDVRMarker returns the DVR marker of dir
+func ReadDVRMarker(dir string) (*DVRMarker, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, DVRMarkerName))
+	if err != nil {
+		return nil, false
+	}
+	var marker DVRMarker
+	if err := json.Unmarshal(data, &marker); err != nil || marker.WindowSegments <= 0 {
+		return nil, false
+	}
+	return &marker, true
+}
+
+// ParseDVRSegmentName returns the number of a segment file written by FFmpeg
+func ParseDVRSegmentName(name string) (int, bool) {
+	matches := dvrSegmentRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, false
+	}
+	number, err := strconv.Atoi(matches[1])
+	return number, err == nil
+}
+
+// TrimPlaylist drops the segments numbered below floor from a media playlist
+// The newest EXT-X-KEY and EXT-X-MAP of the dropped segments are carried over so the
+// remaining segments keep their key and init segment.
+func TrimPlaylist(data []byte, floor int) []byte {
+	var out, pending []string
+	carried := map[string]string{}
+	sequenceLine, discontinuityLine := -1, -1
+	sequence, discontinuitySequence := 0, 0
+	droppedDiscontinuities := 0
+	started := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+			out = append(out, pending...)
+			pending = nil
+			sequenceLine = len(out)
+			out = append(out, line)
+		case strings.HasPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"):
+			discontinuitySequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"))
+			out = append(out, pending...)
+			pending = nil
+			discontinuityLine = len(out)
+			out = append(out, line)
+		case strings.HasPrefix(line, "#"):
+			pending = append(pending, line)
+		case sequence < floor:
+			for _, tag := range pending {
+				switch {
+				case strings.HasPrefix(tag, "#EXT-X-KEY:"), strings.HasPrefix(tag, "#EXT-X-MAP:"):
+					carried[tag[:strings.Index(tag, ":")]] = tag
+				case tag == "#EXT-X-DISCONTINUITY":
+					droppedDiscontinuities++
+				case strings.HasPrefix(tag, "#EXTINF:"), strings.HasPrefix(tag, "#EXT-X-PROGRAM-DATE-TIME:"),
+					strings.HasPrefix(tag, "#EXT-X-BYTERANGE:"), tag == "#EXT-X-GAP":
+				default:
+					out = append(out, tag) // Playlist header
+				}
+			}
+			pending = nil
+			sequence++
+		default:
+			if !started {
+				started = true
+				for _, name := range []string{"#EXT-X-MAP", "#EXT-X-KEY"} {
+					if tag, ok := carried[name]; ok && !hasTag(pending, name) {
+						out = append(out, tag)
+					}
+				}
+				if sequenceLine >= 0 {
+					out[sequenceLine] = fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", sequence)
+				}
+			}
+			out = append(out, pending...)
+			out = append(out, line)
+			pending = nil
+			sequence++
+		}
+	}
+	out = append(out, pending...)
+
+	if droppedDiscontinuities > 0 {
+		tag := fmt.Sprintf("#EXT-X-DISCONTINUITY-SEQUENCE:%d", discontinuitySequence+droppedDiscontinuities)
+		if discontinuityLine >= 0 {
+			out[discontinuityLine] = tag
+		} else if sequenceLine >= 0 {
+			out = append(out[:sequenceLine+1], append([]string{tag}, out[sequenceLine+1:]...)...)
+		}
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+func hasTag(tags []string, name string) bool {
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, name+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// StartOffset returns the offset from the start of a media playlist of the segment playing at
+// the given wall clock time, located with the EXT-X-PROGRAM-DATE-TIME tags (clamped to the window)
+func StartOffset(data []byte, at time.Time) (float64, bool) {
+	pl := parsePlaylist(data)
+	elapsed := 0.0
+	found := false
+	for _, p := range pl.parts {
+		start, ok := parseProgramDateTime(p.programDateTime)
+		if !ok {
+			elapsed += p.duration
+			continue
+		}
+		found = true
+		if at.Before(start) {
+			return elapsed, true // Before the window (or in a gap): start at this segment
+		}
+		if at.Before(start.Add(time.Duration(p.duration * float64(time.Second)))) {
+			return elapsed + at.Sub(start).Seconds(), true
+		}
+		elapsed += p.duration
+	}
+	return elapsed, found
+}
+
+// WithStart sets the EXT-X-START offset of a playlist (negative offsets count back from the live edge)
+func WithStart(data []byte, offset float64) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	out := make([]string, 0, len(lines)+1)
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#EXT-X-START:") {
+			continue
+		}
+		out = append(out, line)
+		if i == 0 {
+			out = append(out, fmt.Sprintf("#EXT-X-START:TIME-OFFSET=%.3f,PRECISE=YES", offset))
+		}
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
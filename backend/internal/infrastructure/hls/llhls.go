@@ -0,0 +1,373 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarkerName is the file marking an output directory as Low-Latency HLS
+// FFmpeg cannot write LL-HLS itself: it writes short segments which the stream handler
+// publishes as parts and groups into full segments.
+const MarkerName = ".llhls"
+
+// heldSegments is the number of complete segments whose parts stay listed
+const heldSegments = 3
+
+var (
+	fullSegmentRegex = regexp.MustCompile(`^full_(\d+)\.(m4s|ts|mp4)$`)
+	lastNumberRegex  = regexp.MustCompile(`(\d+)(\.[^.]+)$`)
+)
+
+// Marker describes how the FFmpeg output of a low-latency channel is grouped
+type Marker struct {
+	PartTarget      float64 `json:"part_target"`       // Seconds per part (one FFmpeg segment)
+	PartsPerSegment int     `json:"parts_per_segment"` // Parts making up one full segment
+}
+
+// WriteMarker marks dir as low-latency output
+func WriteMarker(dir string, marker Marker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MarkerName), data, 0644)
+}
+
+// RemoveMarker drops the low-latency marker of dir (the channel switched to classic HLS)
+func RemoveMarker(dir string) {
+	os.Remove(filepath.Join(dir, MarkerName))
+}
+
+// ReadMarker returns the low-latency marker of dir
+func ReadMarker(dir string) (*Marker, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, MarkerName))
+	if err != nil {
+		return nil, false
+	}
+	var marker Marker
+	if err := json.Unmarshal(data, &marker); err != nil || marker.PartsPerSegment < 1 || marker.PartTarget <= 0 {
+		return nil, false
+	}
+	return &marker, true
+}
+
+// part is one FFmpeg segment listed in the FFmpeg playlist
+type part struct {
+	seq             int
+	duration        float64
+	uri             string
+	programDateTime string
+	discontinuity   bool
+}
+
+// segment is a group of PartsPerSegment consecutive parts
+type segment struct {
+	msn   int
+	parts []part
+}
+
+func (s segment) duration() float64 {
+	var total float64
+	for _, p := range s.parts {
+		total += p.duration
+	}
+	return total
+}
+
+// Position is how far a low-latency playlist has progressed
+type Position struct {
+	LastComplete int // Media sequence number of the newest complete segment (-1 = none)
+	Current      int // Media sequence number of the segment being written
+	CurrentParts int // Parts of the current segment already written
+}
+
+// Satisfies reports whether a blocking reload for msn (and part, -1 = whole segment) can be answered
+func (p Position) Satisfies(msn, partIndex int) bool {
+	if p.LastComplete >= msn {
+		return true
+	}
+	if partIndex < 0 {
+		return false
+	}
+	return p.Current > msn || (p.Current == msn && p.CurrentParts > partIndex)
+}
+
+// TooFarAhead reports whether a blocking reload asks for a segment more than two segments in the future
+func (p Position) TooFarAhead(msn int) bool {
+	return msn > p.Current+2
+}
+
+// playlist is a parsed FFmpeg media playlist of parts
+type playlist struct {
+	mapURI string
+	parts  []part
+}
+
+func parsePlaylist(data []byte) *playlist {
+	pl := &playlist{}
+	sequence := 0
+	var pending part
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if start := strings.Index(line, `URI="`); start >= 0 {
+				rest := line[start+5:]
+				if end := strings.Index(rest, `"`); end >= 0 {
+					pl.mapURI = rest[:end]
+				}
+			}
+		case strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+			pending.programDateTime = strings.TrimPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:")
+		case line == "#EXT-X-DISCONTINUITY":
+			pending.discontinuity = true
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimSuffix(strings.SplitN(strings.TrimPrefix(line, "#EXTINF:"), ",", 2)[0], ",")
+			pending.duration, _ = strconv.ParseFloat(value, 64)
+		case strings.HasPrefix(line, "#"):
+		default:
+			pending.uri = line
+			pending.seq = sequence
+			pl.parts = append(pl.parts, pending)
+			pending = part{}
+			sequence++
+		}
+	}
+	return pl
+}
+
+// segments groups the parts; a head segment truncated by segment deletion is dropped
+func (pl *playlist) segments(partsPerSegment int) (complete []segment, current segment) {
+	var groups []segment
+	for _, p := range pl.parts {
+		msn := p.seq / partsPerSegment
+		if len(groups) == 0 || groups[len(groups)-1].msn != msn {
+			if len(groups) == 0 && p.seq%partsPerSegment != 0 {
+				continue
+			}
+			groups = append(groups, segment{msn: msn})
+		}
+		groups[len(groups)-1].parts = append(groups[len(groups)-1].parts, p)
+	}
+
+	for _, group := range groups {
+		if len(group.parts) == partsPerSegment {
+			complete = append(complete, group)
+		} else {
+			current = group // Only the newest group can be incomplete
+		}
+	}
+	if current.parts == nil {
+		current.msn = 0
+		if len(complete) > 0 {
+			current.msn = complete[len(complete)-1].msn + 1
+		}
+	}
+	return complete, current
+}
+
+// Render converts an FFmpeg playlist of parts into an LL-HLS media playlist
+func Render(data []byte, marker Marker) ([]byte, Position) {
+	pl := parsePlaylist(data)
+	complete, current := pl.segments(marker.PartsPerSegment)
+
+	position := Position{LastComplete: -1, Current: current.msn, CurrentParts: len(current.parts)}
+	if len(complete) > 0 {
+		position.LastComplete = complete[len(complete)-1].msn
+	}
+
+	partTarget := marker.PartTarget
+	targetDuration := marker.PartTarget * float64(marker.PartsPerSegment)
+	for _, p := range pl.parts {
+		partTarget = math.Max(partTarget, p.duration)
+	}
+	for _, s := range complete {
+		targetDuration = math.Max(targetDuration, s.duration())
+	}
+	firstMSN := current.msn
+	if len(complete) > 0 {
+		firstMSN = complete[0].msn
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:9\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(targetDuration)))
+	fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%.3f\n", 3*partTarget)
+	fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", partTarget)
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", firstMSN)
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	if pl.mapURI != "" {
+		fmt.Fprintf(&b, "#EXT-X-MAP:URI=%q\n", pl.mapURI)
+	}
+
+	extension := ".m4s"
+	if len(pl.parts) > 0 {
+		extension = filepath.Ext(pl.parts[0].uri)
+	}
+	for i, s := range complete {
+		writeSegmentHead(&b, s)
+		if i >= len(complete)-heldSegments {
+			writeParts(&b, s.parts)
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.5f,\n", s.duration())
+		b.WriteString(SegmentName(s.msn, extension) + "\n")
+	}
+	if len(current.parts) > 0 {
+		writeSegmentHead(&b, current)
+		writeParts(&b, current.parts)
+	}
+	if len(pl.parts) > 0 {
+		if next, ok := nextPartURI(pl.parts[len(pl.parts)-1].uri); ok {
+			fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=%q\n", next)
+		}
+	}
+	return []byte(b.String()), position
+}
+
+func writeSegmentHead(b *strings.Builder, s segment) {
+	if s.parts[0].discontinuity {
+		b.WriteString("#EXT-X-DISCONTINUITY\n")
+	}
+	if s.parts[0].programDateTime != "" {
+		b.WriteString("#EXT-X-PROGRAM-DATE-TIME:" + s.parts[0].programDateTime + "\n")
+	}
+}
+
+// writeParts lists the parts of a segment; the first part starts on a keyframe
+func writeParts(b *strings.Builder, parts []part) {
+	for i, p := range parts {
+		fmt.Fprintf(b, "#EXT-X-PART:DURATION=%.5f,URI=%q", p.duration, p.uri)
+		if i == 0 {
+			b.WriteString(",INDEPENDENT=YES")
+		}
+		b.WriteString("\n")
+	}
+}
+
+// nextPartURI predicts the name of the part FFmpeg writes next (segment_00042.m4s -> segment_00043.m4s)
+func nextPartURI(uri string) (string, bool) {
+	matches := lastNumberRegex.FindStringSubmatchIndex(uri)
+	if matches == nil {
+		return "", false
+	}
+	digits := uri[matches[2]:matches[3]]
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s%0*d%s", uri[:matches[2]], len(digits), n+1, uri[matches[3]:]), true
+}
+
+// SegmentName is the URI of a full segment assembled from its parts
+func SegmentName(msn int, extension string) string {
+	return fmt.Sprintf("full_%05d%s", msn, extension)
+}
+
+// ParseSegmentName returns the media sequence number of a full segment URI
+func ParseSegmentName(name string) (int, bool) {
+	matches := fullSegmentRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, false
+	}
+	msn, err := strconv.Atoi(matches[1])
+	return msn, err == nil
+}
+
+// SegmentParts returns the part URIs of full segment msn (false if it is not complete)
+func SegmentParts(data []byte, marker Marker, msn int) ([]string, bool) {
+	complete, _ := parsePlaylist(data).segments(marker.PartsPerSegment)
+	for _, s := range complete {
+		if s.msn != msn {
+			continue
+		}
+		uris := make([]string, len(s.parts))
+		for i, p := range s.parts {
+			uris[i] = p.uri
+		}
+		return uris, true
+	}
+	return nil, false
+}
+
+// ErrTooFarAhead is returned for blocking reloads of segments more than two segments in the future
+var ErrTooFarAhead = errors.New("requested segment is too far in the future")
+
+// ErrReloadTimeout is returned when a blocking reload was not satisfied in time
+var ErrReloadTimeout = errors.New("blocking playlist reload timed out")
+
+// pollInterval is how often a blocking reload re-reads the FFmpeg playlist
+const pollInterval = 100 * time.Millisecond
+
+// WaitFor renders the playlist at path once it holds segment msn (and part, -1 = whole segment)
+// FFmpeg rewrites the playlist after every part, so the file is polled until the request is
+// satisfied, the timeout passes or ctx is cancelled.
+func WaitFor(ctx context.Context, path string, marker Marker, msn, partIndex int, timeout time.Duration) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rendered, position := Render(data, marker)
+		if position.Satisfies(msn, partIndex) {
+			return rendered, nil
+		}
+		if position.TooFarAhead(msn) {
+			return nil, ErrTooFarAhead
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrReloadTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// programDateTimeLayouts are the EXT-X-PROGRAM-DATE-TIME formats written by FFmpeg
+var programDateTimeLayouts = []string{"2006-01-02T15:04:05.000-0700", time.RFC3339Nano}
+
+// FirstSegmentTime returns the media sequence number and program date time of the first
+// segment of a media playlist
+func FirstSegmentTime(data []byte) (int, time.Time, bool) {
+	pl := parsePlaylist(data)
+	if len(pl.parts) == 0 || pl.parts[0].programDateTime == "" {
+		return 0, time.Time{}, false
+	}
+	if t, ok := parseProgramDateTime(pl.parts[0].programDateTime); ok {
+		return pl.parts[0].seq, t, true
+	}
+	return 0, time.Time{}, false
+}
+
+// parseProgramDateTime parses an EXT-X-PROGRAM-DATE-TIME value
+func parseProgramDateTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range programDateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,55 @@
+package hls
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// uriAttributeRegex matches the URI attribute of a playlist tag (EXT-X-MAP, EXT-X-PART, EXT-X-MEDIA...)
+var uriAttributeRegex = regexp.MustCompile(`URI="([^"]*)"`)
+
+// AppendQuery adds query (without "?") to every relative URI of a playlist
+// Other absolute URIs are left untouched, except key URIs: the key endpoint under /api
+// accepts the same playback token.
+func AppendQuery(data []byte, query string) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+		if trimmed[0] != '#' {
+			lines[i] = []byte(WithQuery(string(trimmed), query))
+			continue
+		}
+		isKey := bytes.HasPrefix(trimmed, []byte("#EXT-X-KEY:")) || bytes.HasPrefix(trimmed, []byte("#EXT-X-SESSION-KEY:"))
+		lines[i] = uriAttributeRegex.ReplaceAllFunc(line, func(match []byte) []byte {
+			uri := string(uriAttributeRegex.FindSubmatch(match)[1])
+			if isKey {
+				return []byte(`URI="` + addQuery(uri, query) + `"`)
+			}
+			return []byte(`URI="` + WithQuery(uri, query) + `"`)
+		})
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// WithQuery adds query to a relative URI
+func WithQuery(uri, query string) string {
+	if strings.HasPrefix(uri, "/") || strings.Contains(uri, "://") {
+		return uri
+	}
+	return addQuery(uri, query)
+}
+
+// addQuery adds query to a URI, relative or not (data: URIs are left untouched)
+func addQuery(uri, query string) string {
+	if uri == "" || strings.HasPrefix(uri, "data:") {
+		return uri
+	}
+	if strings.Contains(uri, "?") {
+		return uri + "&" + query
+	}
+	return uri + "?" + query
+}
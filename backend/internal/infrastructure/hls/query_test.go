@@ -0,0 +1,57 @@
+package hls
+
+import "testing"
+
+func TestAppendQuery(t *testing.T) {
+	const query = "token=abc.def"
+
+	tests := []struct {
+		name     string
+		playlist string
+		want     string
+	}{
+		{
+			name:     "media segments",
+			playlist: "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:7\n#EXTINF:2.000000,\nsegment_007.ts\n#EXTINF:2.000000,\nsegment_008.ts?v=2\n",
+			want:     "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:7\n#EXTINF:2.000000,\nsegment_007.ts?token=abc.def\n#EXTINF:2.000000,\nsegment_008.ts?v=2&token=abc.def\n",
+		},
+		{
+			name:     "absolute segment URIs are left untouched",
+			playlist: "#EXTINF:2.000000,\n/streams/other/segment_001.ts\n#EXTINF:2.000000,\nhttps://cdn.example.com/segment_002.ts\n",
+			want:     "#EXTINF:2.000000,\n/streams/other/segment_001.ts\n#EXTINF:2.000000,\nhttps://cdn.example.com/segment_002.ts\n",
+		},
+		{
+			name:     "init segment",
+			playlist: "#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:2.000000,\nsegment_001.m4s\n",
+			want:     "#EXT-X-MAP:URI=\"init.mp4?token=abc.def\"\n#EXTINF:2.000000,\nsegment_001.m4s?token=abc.def\n",
+		},
+		{
+			name:     "absolute key URI gets the token",
+			playlist: "#EXT-X-KEY:METHOD=AES-128,URI=\"/api/v1/channels/6f1c/keys/1a2b\",IV=0x01\n#EXTINF:2.000000,\nsegment_001.ts\n",
+			want:     "#EXT-X-KEY:METHOD=AES-128,URI=\"/api/v1/channels/6f1c/keys/1a2b?token=abc.def\",IV=0x01\n#EXTINF:2.000000,\nsegment_001.ts?token=abc.def\n",
+		},
+		{
+			name:     "session key and data URI key",
+			playlist: "#EXT-X-SESSION-KEY:METHOD=AES-128,URI=\"https://keys.example.com/k?id=1\"\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"data:text/plain;base64,AAAA\"\n",
+			want:     "#EXT-X-SESSION-KEY:METHOD=AES-128,URI=\"https://keys.example.com/k?id=1&token=abc.def\"\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"data:text/plain;base64,AAAA\"\n",
+		},
+		{
+			name:     "low latency parts and preload hints",
+			playlist: "#EXT-X-PART:DURATION=0.5,URI=\"segment_009.0.m4s\",INDEPENDENT=YES\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"segment_009.1.m4s\"\n",
+			want:     "#EXT-X-PART:DURATION=0.5,URI=\"segment_009.0.m4s?token=abc.def\",INDEPENDENT=YES\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"segment_009.1.m4s?token=abc.def\"\n",
+		},
+		{
+			name:     "master playlist",
+			playlist: "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"eng\",URI=\"audio_eng.m3u8\"\n#EXT-X-STREAM-INF:BANDWIDTH=5000000,AUDIO=\"aud\"\n720p.m3u8\n",
+			want:     "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"eng\",URI=\"audio_eng.m3u8?token=abc.def\"\n#EXT-X-STREAM-INF:BANDWIDTH=5000000,AUDIO=\"aud\"\n720p.m3u8?token=abc.def\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(AppendQuery([]byte(tt.playlist), query)); got != tt.want {
+				t.Errorf("AppendQuery()\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Stats returns the segments listed in the live playlist of a channel directory and the
+// write time of the newest one. Ladder channels report their first variant, low-latency
+// channels count whole segments rather than parts.
+func Stats(dir string) (int, time.Time, bool) {
+	playlistPath, ok := MediaPlaylistPath(dir)
+	if !ok {
+		return 0, time.Time{}, false
+	}
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	pl := parsePlaylist(data)
+	if len(pl.parts) == 0 {
+		return 0, time.Time{}, true
+	}
+
+	count := len(pl.parts)
+	if marker, ok := ReadMarker(dir); ok {
+		complete, _ := pl.segments(marker.PartsPerSegment)
+		count = len(complete)
+	}
+
+	var last time.Time
+	newest := pl.parts[len(pl.parts)-1].uri
+	if info, err := os.Stat(filepath.Join(filepath.Dir(playlistPath), filepath.FromSlash(newest))); err == nil {
+		last = info.ModTime()
+	}
+	return count, last, true
+}
+
+// MediaPlaylistPath returns the media playlist describing a channel directory
+// (the first variant of ladder channels, index.m3u8 otherwise)
+func MediaPlaylistPath(dir string) (string, bool) {
+	master, err := os.ReadFile(filepath.Join(dir, "master.m3u8"))
+	if err != nil {
+		return filepath.Join(dir, "index.m3u8"), true
+	}
+	uri, ok := firstURI(master)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(dir, filepath.FromSlash(uri)), true
+}
+
+// firstURI returns the first URI line of a playlist
+func firstURI(data []byte) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, true
+		}
+	}
+	return "", false
+}
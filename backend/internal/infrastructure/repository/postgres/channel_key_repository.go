@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChannelKeyRepository implements domain.ChannelKeyRepository with PostgreSQL
+type ChannelKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewChannelKeyRepository creates a new PostgreSQL content key repository
+func NewChannelKeyRepository(db *pgxpool.Pool) *ChannelKeyRepository {
+	return &ChannelKeyRepository{db: db}
+}
+
+// Create inserts a new content key
+func (r *ChannelKeyRepository) Create(key *domain.ChannelKey) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO channel_keys (id, channel_id, key, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.Exec(ctx, query, key.ID, key.ChannelID, key.Key, key.CreatedAt)
+	return err
+}
+
+// Get retrieves a content key of a channel
+func (r *ChannelKeyRepository) Get(channelID, keyID uuid.UUID) (*domain.ChannelKey, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, channel_id, key, created_at
+		FROM channel_keys
+		WHERE id = $1 AND channel_id = $2
+	`
+
+	var key domain.ChannelKey
+	err := r.db.QueryRow(ctx, query, keyID, channelID).Scan(&key.ID, &key.ChannelID, &key.Key, &key.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("key not found: %w", err)
+	}
+
+	return &key, nil
+}
+
+// DeleteOlderThan deletes the keys of a channel created before a time, keeping the key in use at that time
+func (r *ChannelKeyRepository) DeleteOlderThan(channelID uuid.UUID, before time.Time) (int64, error) {
+	ctx := context.Background()
+
+	query := `
+		DELETE FROM channel_keys
+		WHERE channel_id = $1 AND created_at < $2
+		AND id <> (
+			SELECT id FROM channel_keys
+			WHERE channel_id = $1 AND created_at < $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		)
+	`
+
+	result, err := r.db.Exec(ctx, query, channelID, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// DeleteByChannel deletes all keys of a channel
+func (r *ChannelKeyRepository) DeleteByChannel(channelID uuid.UUID) (int64, error) {
+	ctx := context.Background()
+
+	result, err := r.db.Exec(ctx, `DELETE FROM channel_keys WHERE channel_id = $1`, channelID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChannelLogRepository implements domain.ChannelLogRepository with PostgreSQL
+type ChannelLogRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewChannelLogRepository creates a new PostgreSQL channel log repository
+func NewChannelLogRepository(db *pgxpool.Pool) *ChannelLogRepository {
+	return &ChannelLogRepository{db: db}
+}
+
+// InsertBatch inserts log lines in a single statement
+// Lines of channels that were deleted in the meantime are skipped
+func (r *ChannelLogRepository) InsertBatch(logs []*domain.ChannelLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+
+	channelIDs := make([]uuid.UUID, len(logs))
+	levels := make([]string, len(logs))
+	messages := make([]string, len(logs))
+	createdAt := make([]time.Time, len(logs))
+	for i, log := range logs {
+		channelIDs[i] = log.ChannelID
+		levels[i] = string(log.Level)
+		messages[i] = log.Message
+		createdAt[i] = log.CreatedAt
+	}
+
+	query := `
+		INSERT INTO channel_logs (channel_id, level, message, created_at)
+		SELECT l.channel_id, l.level, l.message, l.created_at
+		FROM unnest($1::uuid[], $2::text[], $3::text[], $4::timestamptz[]) AS l(channel_id, level, message, created_at)
+		WHERE EXISTS (SELECT 1 FROM channels c WHERE c.id = l.channel_id)
+	`
+
+	_, err := r.db.Exec(ctx, query, channelIDs, levels, messages, createdAt)
+	return err
+}
+
+// List retrieves log lines matching the filter (newest first) and the total match count
+func (r *ChannelLogRepository) List(filter domain.ChannelLogFilter) ([]*domain.ChannelLog, int, error) {
+	ctx := context.Background()
+
+	conditions := []string{"channel_id = $1"}
+	args := []interface{}{filter.ChannelID}
+	if len(filter.Levels) > 0 {
+		levels := make([]string, len(filter.Levels))
+		for i, level := range filter.Levels {
+			levels[i] = string(level)
+		}
+		args = append(args, levels)
+		conditions = append(conditions, fmt.Sprintf("level = ANY($%d)", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, "%"+escapeLike(filter.Search)+"%")
+		conditions = append(conditions, fmt.Sprintf("message ILIKE $%d", len(args)))
+	}
+	if filter.Since != nil {
+		args = append(args, *filter.Since)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM channel_logs WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT id, channel_id, level, message, created_at
+		FROM channel_logs WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := make([]*domain.ChannelLog, 0, filter.Limit)
+	for rows.Next() {
+		var log domain.ChannelLog
+		if err := rows.Scan(&log.ID, &log.ChannelID, &log.Level, &log.Message, &log.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, &log)
+	}
+
+	return logs, total, rows.Err()
+}
+
+// DeleteOlderThan deletes log lines created before the cutoff
+func (r *ChannelLogRepository) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	ctx := context.Background()
+
+	result, err := r.db.Exec(ctx, "DELETE FROM channel_logs WHERE created_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// escapeLike escapes LIKE wildcards in a user supplied search term
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
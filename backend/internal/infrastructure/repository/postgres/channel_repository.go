@@ -26,22 +26,26 @@ func NewChannelRepository(db *pgxpool.Pool) *ChannelRepository {
 func (r *ChannelRepository) Create(channel *domain.Channel) error {
 	ctx := context.Background()
 
-	logoJSON, _ := json.Marshal(channel.Logo)
+	overlaysJSON, _ := json.Marshal(channel.Overlays)
 	outputJSON, _ := json.Marshal(channel.OutputConfig)
+	restartJSON, _ := json.Marshal(channel.RestartPolicy)
+	backupJSON, _ := json.Marshal(channel.BackupSources)
 
 	query := `
-		INSERT INTO channels (id, name, source_url, logo, output_config, status, auto_restart, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO channels (id, name, source_url, backup_sources, overlays, output_config, status, auto_restart, restart_policy, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	_, err := r.db.Exec(ctx, query,
 		channel.ID,
 		channel.Name,
 		channel.SourceURL,
-		logoJSON,
+		backupJSON,
+		overlaysJSON,
 		outputJSON,
 		channel.Status,
 		channel.AutoRestart,
+		restartJSON,
 		channel.CreatedAt,
 		channel.UpdatedAt,
 	)
@@ -54,21 +58,24 @@ func (r *ChannelRepository) GetByID(id uuid.UUID) (*domain.Channel, error) {
 	ctx := context.Background()
 
 	query := `
-		SELECT id, name, source_url, logo, output_config, status, auto_restart, created_at, updated_at
+		SELECT id, name, source_url, backup_sources, overlays, output_config, status, auto_restart, restart_policy, desired_running, created_at, updated_at
 		FROM channels WHERE id = $1
 	`
 
 	var channel domain.Channel
-	var logoJSON, outputJSON sql.NullString
+	var backupJSON, overlaysJSON, outputJSON, restartJSON sql.NullString
 
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&channel.ID,
 		&channel.Name,
 		&channel.SourceURL,
-		&logoJSON,
+		&backupJSON,
+		&overlaysJSON,
 		&outputJSON,
 		&channel.Status,
 		&channel.AutoRestart,
+		&restartJSON,
+		&channel.DesiredRunning,
 		&channel.CreatedAt,
 		&channel.UpdatedAt,
 	)
@@ -77,12 +84,18 @@ func (r *ChannelRepository) GetByID(id uuid.UUID) (*domain.Channel, error) {
 		return nil, fmt.Errorf("channel not found: %w", err)
 	}
 
-	if logoJSON.Valid {
-		json.Unmarshal([]byte(logoJSON.String), &channel.Logo)
+	if backupJSON.Valid {
+		json.Unmarshal([]byte(backupJSON.String), &channel.BackupSources)
+	}
+	if overlaysJSON.Valid {
+		json.Unmarshal([]byte(overlaysJSON.String), &channel.Overlays)
 	}
 	if outputJSON.Valid {
 		json.Unmarshal([]byte(outputJSON.String), &channel.OutputConfig)
 	}
+	if restartJSON.Valid {
+		json.Unmarshal([]byte(restartJSON.String), &channel.RestartPolicy)
+	}
 
 	return &channel, nil
 }
@@ -92,7 +105,7 @@ func (r *ChannelRepository) GetAll() ([]*domain.Channel, error) {
 	ctx := context.Background()
 
 	query := `
-		SELECT id, name, source_url, logo, output_config, status, auto_restart, created_at, updated_at
+		SELECT id, name, source_url, backup_sources, overlays, output_config, status, auto_restart, restart_policy, desired_running, created_at, updated_at
 		FROM channels ORDER BY created_at DESC
 	`
 
@@ -105,16 +118,19 @@ func (r *ChannelRepository) GetAll() ([]*domain.Channel, error) {
 	var channels []*domain.Channel
 	for rows.Next() {
 		var channel domain.Channel
-		var logoJSON, outputJSON sql.NullString
+		var backupJSON, overlaysJSON, outputJSON, restartJSON sql.NullString
 
 		err := rows.Scan(
 			&channel.ID,
 			&channel.Name,
 			&channel.SourceURL,
-			&logoJSON,
+			&backupJSON,
+			&overlaysJSON,
 			&outputJSON,
 			&channel.Status,
 			&channel.AutoRestart,
+			&restartJSON,
+			&channel.DesiredRunning,
 			&channel.CreatedAt,
 			&channel.UpdatedAt,
 		)
@@ -122,12 +138,18 @@ func (r *ChannelRepository) GetAll() ([]*domain.Channel, error) {
 			return nil, err
 		}
 
-		if logoJSON.Valid {
-			json.Unmarshal([]byte(logoJSON.String), &channel.Logo)
+		if backupJSON.Valid {
+			json.Unmarshal([]byte(backupJSON.String), &channel.BackupSources)
+		}
+		if overlaysJSON.Valid {
+			json.Unmarshal([]byte(overlaysJSON.String), &channel.Overlays)
 		}
 		if outputJSON.Valid {
 			json.Unmarshal([]byte(outputJSON.String), &channel.OutputConfig)
 		}
+		if restartJSON.Valid {
+			json.Unmarshal([]byte(restartJSON.String), &channel.RestartPolicy)
+		}
 
 		channels = append(channels, &channel)
 	}
@@ -139,21 +161,25 @@ func (r *ChannelRepository) GetAll() ([]*domain.Channel, error) {
 func (r *ChannelRepository) Update(channel *domain.Channel) error {
 	ctx := context.Background()
 
-	logoJSON, _ := json.Marshal(channel.Logo)
+	overlaysJSON, _ := json.Marshal(channel.Overlays)
 	outputJSON, _ := json.Marshal(channel.OutputConfig)
+	restartJSON, _ := json.Marshal(channel.RestartPolicy)
+	backupJSON, _ := json.Marshal(channel.BackupSources)
 
 	query := `
 		UPDATE channels 
-		SET name = $1, source_url = $2, logo = $3, output_config = $4, auto_restart = $5, updated_at = $6
-		WHERE id = $7
+		SET name = $1, source_url = $2, backup_sources = $3, overlays = $4, output_config = $5, auto_restart = $6, restart_policy = $7, updated_at = $8
+		WHERE id = $9
 	`
 
 	_, err := r.db.Exec(ctx, query,
 		channel.Name,
 		channel.SourceURL,
-		logoJSON,
+		backupJSON,
+		overlaysJSON,
 		outputJSON,
 		channel.AutoRestart,
+		restartJSON,
 		time.Now(),
 		channel.ID,
 	)
@@ -177,7 +203,10 @@ func (r *ChannelRepository) UpdateStatus(id uuid.UUID, status domain.ChannelStat
 	return err
 }
 
-
-
-
-
+// SetDesiredRunning records whether the operator wants the channel on air
+func (r *ChannelRepository) SetDesiredRunning(id uuid.UUID, running bool) error {
+	ctx := context.Background()
+	query := `UPDATE channels SET desired_running = $1 WHERE id = $2`
+	_, err := r.db.Exec(ctx, query, running, id)
+	return err
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RecordingRepository implements domain.RecordingRepository with PostgreSQL
+type RecordingRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRecordingRepository creates a new PostgreSQL recording repository
+func NewRecordingRepository(db *pgxpool.Pool) *RecordingRepository {
+	return &RecordingRepository{db: db}
+}
+
+const recordingColumns = `id, channel_id, name, status, start_at, duration, started_at, ended_at, file_path, size_bytes, error, created_at`
+
+// Create inserts a new recording job
+func (r *RecordingRepository) Create(recording *domain.Recording) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO recordings (` + recordingColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		recording.ID,
+		recording.ChannelID,
+		recording.Name,
+		recording.Status,
+		recording.StartAt,
+		recording.Duration,
+		recording.StartedAt,
+		recording.EndedAt,
+		recording.FilePath,
+		recording.SizeBytes,
+		recording.Error,
+		recording.CreatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a recording job by ID
+func (r *RecordingRepository) GetByID(id uuid.UUID) (*domain.Recording, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + recordingColumns + ` FROM recordings WHERE id = $1`
+
+	recording, err := scanRecording(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("recording not found: %w", err)
+	}
+	return recording, nil
+}
+
+// List retrieves the recording jobs of a channel (nil = all channels), newest first
+func (r *RecordingRepository) List(channelID *uuid.UUID) ([]*domain.Recording, error) {
+	if channelID != nil {
+		return r.query(`SELECT `+recordingColumns+` FROM recordings WHERE channel_id = $1 ORDER BY start_at DESC`, *channelID)
+	}
+	return r.query(`SELECT ` + recordingColumns + ` FROM recordings ORDER BY start_at DESC`)
+}
+
+// ListByStatus retrieves the recording jobs in a status, oldest start first
+func (r *RecordingRepository) ListByStatus(status domain.RecordingStatus) ([]*domain.Recording, error) {
+	return r.query(`SELECT `+recordingColumns+` FROM recordings WHERE status = $1 ORDER BY start_at`, status)
+}
+
+// ListFinishedBefore retrieves the finished recording jobs that ended before the cutoff
+func (r *RecordingRepository) ListFinishedBefore(cutoff time.Time) ([]*domain.Recording, error) {
+	return r.query(`SELECT `+recordingColumns+` FROM recordings WHERE status IN ($1, $2, $3) AND ended_at < $4`,
+		domain.RecordingStatusCompleted, domain.RecordingStatusFailed, domain.RecordingStatusCancelled, cutoff)
+}
+
+// Update updates the state of a recording job
+func (r *RecordingRepository) Update(recording *domain.Recording) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE recordings
+		SET status = $1, started_at = $2, ended_at = $3, file_path = $4, size_bytes = $5, error = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		recording.Status,
+		recording.StartedAt,
+		recording.EndedAt,
+		recording.FilePath,
+		recording.SizeBytes,
+		recording.Error,
+		recording.ID,
+	)
+	return err
+}
+
+// Delete deletes a recording job
+func (r *RecordingRepository) Delete(id uuid.UUID) error {
+	ctx := context.Background()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM recordings WHERE id = $1", id)
+	return err
+}
+
+func (r *RecordingRepository) query(query string, args ...interface{}) ([]*domain.Recording, error) {
+	ctx := context.Background()
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recordings := make([]*domain.Recording, 0)
+	for rows.Next() {
+		recording, err := scanRecording(rows)
+		if err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, rows.Err()
+}
+
+func scanRecording(row pgx.Row) (*domain.Recording, error) {
+	var recording domain.Recording
+	err := row.Scan(
+		&recording.ID,
+		&recording.ChannelID,
+		&recording.Name,
+		&recording.Status,
+		&recording.StartAt,
+		&recording.Duration,
+		&recording.StartedAt,
+		&recording.EndedAt,
+		&recording.FilePath,
+		&recording.SizeBytes,
+		&recording.Error,
+		&recording.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &recording, nil
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ScheduleRepository implements domain.ScheduleRepository with PostgreSQL
+type ScheduleRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewScheduleRepository creates a new PostgreSQL schedule repository
+func NewScheduleRepository(db *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+const scheduleColumns = `id, channel_id, name, type, timezone, start_at, end_at, cron, duration, enabled,
+	next_action, next_run_at, window_end, last_action, last_run_at, last_error, last_error_at, created_at, updated_at`
+
+// Create inserts a new schedule
+func (r *ScheduleRepository) Create(schedule *domain.Schedule) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO channel_schedules (` + scheduleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		schedule.ID,
+		schedule.ChannelID,
+		schedule.Name,
+		schedule.Type,
+		schedule.Timezone,
+		schedule.StartAt,
+		schedule.EndAt,
+		schedule.Cron,
+		schedule.Duration,
+		schedule.Enabled,
+		schedule.NextAction,
+		schedule.NextRunAt,
+		schedule.WindowEnd,
+		schedule.LastAction,
+		schedule.LastRunAt,
+		schedule.LastError,
+		schedule.LastErrorAt,
+		schedule.CreatedAt,
+		schedule.UpdatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves a schedule by ID
+func (r *ScheduleRepository) GetByID(id uuid.UUID) (*domain.Schedule, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + scheduleColumns + ` FROM channel_schedules WHERE id = $1`
+
+	schedule, err := scanSchedule(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("schedule not found: %w", err)
+	}
+	return schedule, nil
+}
+
+// List retrieves the schedules of a channel (nil = all channels)
+func (r *ScheduleRepository) List(channelID *uuid.UUID) ([]*domain.Schedule, error) {
+	if channelID != nil {
+		return r.query(`SELECT `+scheduleColumns+` FROM channel_schedules WHERE channel_id = $1 ORDER BY created_at`, *channelID)
+	}
+	return r.query(`SELECT ` + scheduleColumns + ` FROM channel_schedules ORDER BY created_at`)
+}
+
+// ListDue retrieves the enabled schedules whose next action is due at now, oldest first
+func (r *ScheduleRepository) ListDue(now time.Time) ([]*domain.Schedule, error) {
+	return r.query(`SELECT `+scheduleColumns+` FROM channel_schedules WHERE enabled AND next_run_at <= $1 ORDER BY next_run_at`, now)
+}
+
+// Update updates a schedule with its plan
+func (r *ScheduleRepository) Update(schedule *domain.Schedule) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE channel_schedules
+		SET name = $1, type = $2, timezone = $3, start_at = $4, end_at = $5, cron = $6, duration = $7, enabled = $8,
+			next_action = $9, next_run_at = $10, window_end = $11, last_action = $12, last_run_at = $13,
+			last_error = $14, last_error_at = $15, updated_at = $16
+		WHERE id = $17
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		schedule.Name,
+		schedule.Type,
+		schedule.Timezone,
+		schedule.StartAt,
+		schedule.EndAt,
+		schedule.Cron,
+		schedule.Duration,
+		schedule.Enabled,
+		schedule.NextAction,
+		schedule.NextRunAt,
+		schedule.WindowEnd,
+		schedule.LastAction,
+		schedule.LastRunAt,
+		schedule.LastError,
+		schedule.LastErrorAt,
+		schedule.UpdatedAt,
+		schedule.ID,
+	)
+	return err
+}
+
+// Delete deletes a schedule
+func (r *ScheduleRepository) Delete(id uuid.UUID) error {
+	ctx := context.Background()
+
+	_, err := r.db.Exec(ctx, "DELETE FROM channel_schedules WHERE id = $1", id)
+	return err
+}
+
+func (r *ScheduleRepository) query(query string, args ...interface{}) ([]*domain.Schedule, error) {
+	ctx := context.Background()
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*domain.Schedule, 0)
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func scanSchedule(row pgx.Row) (*domain.Schedule, error) {
+	var schedule domain.Schedule
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.ChannelID,
+		&schedule.Name,
+		&schedule.Type,
+		&schedule.Timezone,
+		&schedule.StartAt,
+		&schedule.EndAt,
+		&schedule.Cron,
+		&schedule.Duration,
+		&schedule.Enabled,
+		&schedule.NextAction,
+		&schedule.NextRunAt,
+		&schedule.WindowEnd,
+		&schedule.LastAction,
+		&schedule.LastRunAt,
+		&schedule.LastError,
+		&schedule.LastErrorAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ViewerHistoryRepository implements domain.ViewerHistoryRepository with PostgreSQL
+type ViewerHistoryRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewViewerHistoryRepository creates a new PostgreSQL viewer history repository
+func NewViewerHistoryRepository(db *pgxpool.Pool) *ViewerHistoryRepository {
+	return &ViewerHistoryRepository{db: db}
+}
+
+// InsertBatch inserts viewer samples in a single statement
+// Samples of channels that were deleted in the meantime are skipped
+func (r *ViewerHistoryRepository) InsertBatch(samples []*domain.ViewerSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+
+	channelIDs := make([]uuid.UUID, len(samples))
+	viewers := make([]int32, len(samples))
+	sampledAt := make([]time.Time, len(samples))
+	for i, sample := range samples {
+		channelIDs[i] = sample.ChannelID
+		viewers[i] = int32(sample.Viewers)
+		sampledAt[i] = sample.SampledAt
+	}
+
+	query := `
+		INSERT INTO channel_viewer_history (channel_id, viewers, sampled_at)
+		SELECT s.channel_id, s.viewers, s.sampled_at
+		FROM unnest($1::uuid[], $2::int[], $3::timestamptz[]) AS s(channel_id, viewers, sampled_at)
+		WHERE EXISTS (SELECT 1 FROM channels c WHERE c.id = s.channel_id)
+	`
+
+	_, err := r.db.Exec(ctx, query, channelIDs, viewers, sampledAt)
+	return err
+}
+
+// List retrieves the samples of a channel within a time range (oldest first)
+func (r *ViewerHistoryRepository) List(channelID uuid.UUID, since, until time.Time) ([]*domain.ViewerSample, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT channel_id, viewers, sampled_at
+		FROM channel_viewer_history
+		WHERE channel_id = $1 AND sampled_at >= $2 AND sampled_at <= $3
+		ORDER BY sampled_at
+	`
+
+	rows, err := r.db.Query(ctx, query, channelID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]*domain.ViewerSample, 0)
+	for rows.Next() {
+		var sample domain.ViewerSample
+		if err := rows.Scan(&sample.ChannelID, &sample.Viewers, &sample.SampledAt); err != nil {
+			return nil, err
+		}
+		samples = append(samples, &sample)
+	}
+
+	return samples, rows.Err()
+}
+
+// DeleteOlderThan deletes samples taken before the cutoff
+func (r *ViewerHistoryRepository) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	ctx := context.Background()
+
+	result, err := r.db.Exec(ctx, "DELETE FROM channel_viewer_history WHERE sampled_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
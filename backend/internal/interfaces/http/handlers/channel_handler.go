@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 	
 	"github.com/cashbacktv/backend/internal/application"
 	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/dash"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -46,18 +50,24 @@ func NewChannelHandlerWithFFmpeg(service *application.ChannelService, hlsPath, l
 
 // CreateChannelRequest represents channel creation request
 type CreateChannelRequest struct {
-	Name         string              `json:"name" validate:"required"`
-	SourceURL    string              `json:"source_url" validate:"required,url"`
-	Logo         *domain.LogoConfig  `json:"logo,omitempty"`
-	OutputConfig *domain.OutputConfig `json:"output_config,omitempty"`
+	Name          string                `json:"name" validate:"required"`
+	SourceURL     string                `json:"source_url" validate:"required,url"`
+	BackupSources []string              `json:"backup_sources,omitempty"`
+	Overlays      []domain.OverlayLayer `json:"overlays,omitempty"`
+	OutputConfig  *domain.OutputConfig  `json:"output_config,omitempty"`
+	AutoRestart   *bool                 `json:"auto_restart,omitempty"`
+	RestartPolicy *domain.RestartPolicy `json:"restart_policy,omitempty"`
 }
 
 // UpdateChannelRequest represents channel update request
 type UpdateChannelRequest struct {
-	Name         string              `json:"name,omitempty"`
-	SourceURL    string              `json:"source_url,omitempty"`
-	Logo         *domain.LogoConfig  `json:"logo,omitempty"`
-	OutputConfig *domain.OutputConfig `json:"output_config,omitempty"`
+	Name          string                `json:"name,omitempty"`
+	SourceURL     string                `json:"source_url,omitempty"`
+	BackupSources []string              `json:"backup_sources,omitempty"` // Empty list removes the backups
+	Overlays      []domain.OverlayLayer `json:"overlays,omitempty"`       // Empty list removes the overlays
+	OutputConfig  *domain.OutputConfig  `json:"output_config,omitempty"`
+	AutoRestart   *bool                 `json:"auto_restart,omitempty"`
+	RestartPolicy *domain.RestartPolicy `json:"restart_policy,omitempty"`
 }
 
 // List returns all channels
@@ -104,8 +114,13 @@ func (h *ChannelHandler) Create(c *fiber.Ctx) error {
 		})
 	}
 
-	channel, err := h.service.CreateChannel(req.Name, req.SourceURL, req.Logo, req.OutputConfig)
+	channel, err := h.service.CreateChannel(req.Name, req.SourceURL, req.BackupSources, req.Overlays, req.OutputConfig, req.AutoRestart, req.RestartPolicy)
 	if err != nil {
+		if errors.Is(err, application.ErrInvalidChannel) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -132,7 +147,7 @@ func (h *ChannelHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
-	channel, err := h.service.UpdateChannel(id, req.Name, req.SourceURL, req.Logo, req.OutputConfig)
+	channel, err := h.service.UpdateChannel(id, req.Name, req.SourceURL, req.BackupSources, req.Overlays, req.OutputConfig, req.AutoRestart, req.RestartPolicy)
 	if err != nil {
 		if err == application.ErrChannelNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -144,6 +159,11 @@ func (h *ChannelHandler) Update(c *fiber.Ctx) error {
 				"error": "çalışan kanal güncellenemez",
 			})
 		}
+		if errors.Is(err, application.ErrInvalidChannel) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -186,9 +206,7 @@ func (h *ChannelHandler) Start(c *fiber.Ctx) error {
 	}
 
 	if err := h.service.StartChannel(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return startError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -198,6 +216,26 @@ func (h *ChannelHandler) Start(c *fiber.Ctx) error {
 	})
 }
 
+// startError responds to a failed start; capacity refusals return 503 naming the limit that was hit
+func startError(c *fiber.Ctx, err error) error {
+	var capacityErr *domain.CapacityError
+	if errors.As(err, &capacityErr) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":    err.Error(),
+			"limit":    capacityErr.Limit,
+			"capacity": capacityErr,
+		})
+	}
+	if errors.Is(err, application.ErrChannelNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
 // Stop stops transcoding for a channel
 func (h *ChannelHandler) Stop(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
@@ -230,9 +268,7 @@ func (h *ChannelHandler) Restart(c *fiber.Ctx) error {
 	}
 
 	if err := h.service.RestartChannel(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return startError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -304,6 +340,32 @@ func (h *ChannelHandler) Logs(c *fiber.Ctx) error {
 	})
 }
 
+// Command returns the FFmpeg command that would be executed for a channel (dry-run)
+func (h *ChannelHandler) Command(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	preview, err := h.service.PreviewChannelCommand(id)
+	if err != nil {
+		if errors.Is(err, application.ErrChannelNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": preview,
+	})
+}
+
 // BatchStartRequest represents batch start request
 type BatchStartRequest struct {
 	ChannelIDs []string `json:"channel_ids" validate:"required,min=1"`
@@ -484,10 +546,26 @@ func (h *ChannelHandler) BatchDelete(c *fiber.Ctx) error {
 func (h *ChannelHandler) ServeStream(c *fiber.Ctx) error {
 	channelIDStr := c.Params("channelId")
 	
+	// Ladder channels publish a master playlist referencing one playlist per rendition
+	if h.hlsPath != "" {
+		masterPath := filepath.Join(h.hlsPath, channelIDStr, "master.m3u8")
+		if _, err := os.Stat(masterPath); err == nil {
+			return c.SendFile(masterPath)
+		}
+	}
+
 	// Check if regular m3u8 file exists (live stream)
 	if h.hlsPath != "" {
 		m3u8Path := filepath.Join(h.hlsPath, channelIDStr, "index.m3u8")
 		if _, err := os.Stat(m3u8Path); err == nil {
+			// Low-latency channels publish FFmpeg's playlist of parts as LL-HLS
+			if marker, ok := hls.ReadMarker(filepath.Dir(m3u8Path)); ok {
+				return h.serveLowLatencyPlaylist(c, m3u8Path, *marker)
+			}
+			// DVR channels drop the segments trimmed by the disk limit
+			if marker, ok := hls.ReadDVRMarker(filepath.Dir(m3u8Path)); ok && marker.Floor > 0 {
+				return h.serveTrimmedPlaylist(c, m3u8Path, marker.Floor)
+			}
 			// File exists, serve it directly
 			return c.SendFile(m3u8Path)
 		}
@@ -497,3 +575,29 @@ func (h *ChannelHandler) ServeStream(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusNotFound).SendString("Stream not available")
 }
 
+// ServeManifest serves the DASH manifest of CMAF channels
+func (h *ChannelHandler) ServeManifest(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("channelId"))
+	if err != nil || h.hlsPath == "" {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	channelDir := filepath.Join(h.hlsPath, channelID.String())
+	layout, ok := dash.ReadLayout(channelDir)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("DASH is not enabled for this channel")
+	}
+
+	// The timeline is anchored on the HLS playlist of the same segments
+	anchor, err := os.ReadFile(layout.AnchorPlaylist(channelDir))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	manifest, err := dash.Render(*layout, anchor, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	c.Set(fiber.HeaderContentType, "application/dash+xml")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(manifest)
+}
+
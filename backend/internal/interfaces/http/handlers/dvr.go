@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ServeDVR serves the playlist of a DVR channel starting at an earlier point of its window
+// Query: start (RFC3339 or Unix seconds, located with the program date times) or offset
+// (seconds behind the live edge); without either, playback starts at the oldest segment.
+func (h *ChannelHandler) ServeDVR(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("channelId"))
+	if err != nil || h.hlsPath == "" {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	channelDir := filepath.Join(h.hlsPath, channelID.String())
+	marker, ok := hls.ReadDVRMarker(channelDir)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("DVR is not enabled for this channel")
+	}
+
+	// Ladder channels carry EXT-X-START in the master playlist, the offset is located in the first variant
+	mediaPath, ok := hls.MediaPlaylistPath(channelDir)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	media, err := os.ReadFile(mediaPath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	media = hls.TrimPlaylist(media, marker.Floor)
+	playlist := media
+	if master, err := os.ReadFile(filepath.Join(channelDir, "master.m3u8")); err == nil {
+		playlist = master
+	}
+
+	offset := 0.0
+	if value := c.Query("start"); value != "" {
+		at, err := parseStartTime(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("start must be RFC3339 or Unix seconds")
+		}
+		if offset, ok = hls.StartOffset(media, at); !ok {
+			return c.Status(fiber.StatusServiceUnavailable).SendString("Playlist has no program date time yet")
+		}
+	} else if value := c.Query("offset"); value != "" {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds < 0 {
+			return c.Status(fiber.StatusBadRequest).SendString("offset must be a positive number of seconds")
+		}
+		offset = -seconds // Counted back from the live edge
+	}
+
+	c.Set(fiber.HeaderContentType, playlistContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(hls.WithStart(playlist, offset))
+}
+
+// serveDVRFile serves the media playlists of a DVR channel without the segments trimmed by the
+// disk limit, every other file falls through to the static handler
+func (h *ChannelHandler) serveDVRFile(c *fiber.Ctx, channelDir string) error {
+	marker, ok := hls.ReadDVRMarker(channelDir)
+	if !ok || marker.Floor == 0 {
+		return c.Next()
+	}
+	rel := path.Clean("/" + c.Params("*"))
+	if strings.Contains(rel, "..") || !strings.HasSuffix(rel, ".m3u8") {
+		return c.Next()
+	}
+	return h.serveTrimmedPlaylist(c, filepath.Join(channelDir, filepath.FromSlash(rel)), marker.Floor)
+}
+
+// serveTrimmedPlaylist serves a media playlist starting at floor
+func (h *ChannelHandler) serveTrimmedPlaylist(c *fiber.Ctx, playlistPath string, floor int) error {
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	c.Set(fiber.HeaderContentType, playlistContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(hls.TrimPlaylist(data, floor))
+}
+
+// parseStartTime parses an RFC3339 time or Unix seconds
+func parseStartTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// KeyHandler handles HTTP requests for HLS content keys
+type KeyHandler struct {
+	service *application.KeyService
+}
+
+// NewKeyHandler creates a new key handler
+func NewKeyHandler(service *application.KeyService) *KeyHandler {
+	return &KeyHandler{service: service}
+}
+
+// Get returns the AES-128 key referenced by the EXT-X-KEY URI of an encrypted channel
+func (h *KeyHandler) Get(c *fiber.Ctx) error {
+	channelID, err := uuid.Parse(c.Params("channelId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+	keyID, err := uuid.Parse(c.Params("keyId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz anahtar ID",
+		})
+	}
+
+	key, err := h.service.GetKey(channelID, keyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "anahtar bulunamadı",
+		})
+	}
+
+	c.Set("Content-Type", "application/octet-stream")
+	c.Set("Cache-Control", "private, no-store")
+	return c.Send(key)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LogHandler handles HTTP requests for persisted channel logs
+type LogHandler struct {
+	service *application.LogService
+}
+
+// NewLogHandler creates a new log handler
+func NewLogHandler(service *application.LogService) *LogHandler {
+	return &LogHandler{service: service}
+}
+
+// History returns the persisted log history of a channel
+// Query: level (comma separated), search, since/until (RFC3339), page, limit
+func (h *LogHandler) History(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 {
+		limit = 100
+	} else if limit > 1000 {
+		limit = 1000
+	}
+
+	filter := domain.ChannelLogFilter{
+		Search: c.Query("search"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+	if levels := c.Query("level"); levels != "" {
+		for _, value := range strings.Split(levels, ",") {
+			level := domain.LogLevel(strings.ToLower(strings.TrimSpace(value)))
+			if !level.IsValid() {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "geçersiz log seviyesi: " + value,
+				})
+			}
+			filter.Levels = append(filter.Levels, level)
+		}
+	}
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "geçersiz tarih (RFC3339 bekleniyor): " + param,
+				})
+			}
+			*target = &t
+		}
+	}
+
+	logs, total, err := h.service.ListChannelLogs(id, filter)
+	if err != nil {
+		if errors.Is(err, application.ErrChannelNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  logs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
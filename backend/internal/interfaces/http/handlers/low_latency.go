@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const playlistContentType = "application/vnd.apple.mpegurl"
+
+// ServeStreamFile serves the generated parts of Low-Latency HLS channels
+// Variant playlists are rewritten to LL-HLS and full segments are assembled from their parts,
+// DVR playlists drop trimmed segments, every other file falls through to the static handler.
+func (h *ChannelHandler) ServeStreamFile(c *fiber.Ctx) error {
+	if h.hlsPath == "" {
+		return c.Next()
+	}
+	channelID, err := uuid.Parse(c.Params("channelId"))
+	if err != nil {
+		return c.Next()
+	}
+	channelDir := filepath.Join(h.hlsPath, channelID.String())
+	marker, ok := hls.ReadMarker(channelDir)
+	if !ok {
+		return h.serveDVRFile(c, channelDir)
+	}
+
+	rel := path.Clean("/" + c.Params("*"))
+	if strings.Contains(rel, "..") {
+		return c.Next()
+	}
+	dir := filepath.Join(channelDir, filepath.FromSlash(path.Dir(rel)))
+	name := path.Base(rel)
+
+	if name == "index.m3u8" {
+		return h.serveLowLatencyPlaylist(c, filepath.Join(dir, name), *marker)
+	}
+	if msn, ok := hls.ParseSegmentName(name); ok {
+		return h.serveFullSegment(c, dir, msn, *marker)
+	}
+	return c.Next()
+}
+
+// serveLowLatencyPlaylist renders an LL-HLS playlist, holding blocking reloads (_HLS_msn/_HLS_part)
+// until the requested part exists
+func (h *ChannelHandler) serveLowLatencyPlaylist(c *fiber.Ctx, playlistPath string, marker hls.Marker) error {
+	c.Set(fiber.HeaderContentType, playlistContentType)
+
+	msn := c.QueryInt("_HLS_msn", -1)
+	if msn < 0 {
+		data, err := os.ReadFile(playlistPath)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+		}
+		rendered, _ := hls.Render(data, marker)
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		return c.Send(rendered)
+	}
+	partIndex := c.QueryInt("_HLS_part", -1)
+
+	// A blocking reload may wait for up to three target durations
+	timeout := time.Duration(3 * marker.PartTarget * float64(marker.PartsPerSegment) * float64(time.Second))
+	rendered, err := hls.WaitFor(c.Context(), playlistPath, marker, msn, partIndex, timeout)
+	switch {
+	case err == nil:
+		// The response for a given msn/part never changes, CDNs may cache it
+		c.Set(fiber.HeaderCacheControl, "max-age=60")
+		return c.Send(rendered)
+	case errors.Is(err, hls.ErrTooFarAhead):
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	case errors.Is(err, hls.ErrReloadTimeout):
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	case os.IsNotExist(err):
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	default:
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+}
+
+// serveFullSegment concatenates the parts of a complete segment
+func (h *ChannelHandler) serveFullSegment(c *fiber.Ctx, dir string, msn int, marker hls.Marker) error {
+	data, err := os.ReadFile(filepath.Join(dir, "index.m3u8"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Stream not available")
+	}
+	parts, ok := hls.SegmentParts(data, marker, msn)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).SendString("Segment not available")
+	}
+
+	var segment []byte
+	for _, part := range parts {
+		partData, err := os.ReadFile(filepath.Join(dir, filepath.Base(part)))
+		if err != nil {
+			// Deleted by FFmpeg between reading the playlist and the part
+			return c.Status(fiber.StatusNotFound).SendString("Segment not available")
+		}
+		segment = append(segment, partData...)
+	}
+	c.Set(fiber.HeaderContentType, "video/mp4")
+	return c.Send(segment)
+}
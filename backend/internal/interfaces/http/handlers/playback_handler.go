@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PlaybackHandler handles HTTP requests for signed playback URLs
+type PlaybackHandler struct {
+	service *application.PlaybackService
+}
+
+// NewPlaybackHandler creates a new playback handler
+func NewPlaybackHandler(service *application.PlaybackService) *PlaybackHandler {
+	return &PlaybackHandler{service: service}
+}
+
+// PlaybackTokenRequest represents a playback token request
+type PlaybackTokenRequest struct {
+	TTL      int    `json:"ttl,omitempty"`       // Lifetime in seconds (0 = configured default, capped at the configured maximum)
+	BindIP   bool   `json:"bind_ip,omitempty"`   // Bind the token to the requesting client's IP
+	ClientIP string `json:"client_ip,omitempty"` // Bind the token to another client (e.g. a viewer of a portal backend)
+}
+
+// IssueToken signs a playback URL for a channel
+func (h *PlaybackHandler) IssueToken(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	var req PlaybackTokenRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "geçersiz istek gövdesi",
+			})
+		}
+	}
+	if req.TTL < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ttl negatif olamaz",
+		})
+	}
+	clientIP := req.ClientIP
+	if clientIP == "" && req.BindIP {
+		clientIP = c.IP()
+	}
+
+	token, payload, err := h.service.IssueToken(id, req.TTL, clientIP)
+	if err != nil {
+		if errors.Is(err, application.ErrChannelNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, application.ErrPlaybackTokensDisabled) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"token":      token,
+		"expires_at": time.Unix(payload.ExpiresAt, 0).UTC(),
+		"client_ip":  payload.ClientIP,
+		"url":        "/streams/" + id.String() + "/index.m3u8?token=" + url.QueryEscape(token),
+	})
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RecordingHandler handles HTTP requests for channel recordings
+type RecordingHandler struct {
+	service *application.RecordingService
+}
+
+// NewRecordingHandler creates a new recording handler
+func NewRecordingHandler(service *application.RecordingService) *RecordingHandler {
+	return &RecordingHandler{service: service}
+}
+
+// List returns all recordings, or the recordings of one channel (/channels/:id/recordings or ?channel_id=)
+func (h *RecordingHandler) List(c *fiber.Ctx) error {
+	var channelID *uuid.UUID
+	value := c.Params("id")
+	if value == "" {
+		value = c.Query("channel_id")
+	}
+	if value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "geçersiz kanal ID",
+			})
+		}
+		channelID = &id
+	}
+
+	recordings, err := h.service.List(channelID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": recordings,
+	})
+}
+
+// Create schedules a recording of a channel (start_at omitted = start now)
+func (h *RecordingHandler) Create(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	var input application.CreateRecordingInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz istek gövdesi",
+		})
+	}
+
+	recording, err := h.service.Create(id, input)
+	if err != nil {
+		if errors.Is(err, application.ErrChannelNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, application.ErrInvalidRecording) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": recording,
+	})
+}
+
+// Get returns a recording with its status
+func (h *RecordingHandler) Get(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kayıt ID",
+		})
+	}
+
+	recording, err := h.service.Get(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "kayıt bulunamadı",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": recording,
+	})
+}
+
+// Cancel cancels a scheduled recording or stops a running one
+func (h *RecordingHandler) Cancel(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kayıt ID",
+		})
+	}
+
+	if _, err := h.service.Cancel(id); err != nil {
+		if errors.Is(err, application.ErrRecordingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "kayıt bulunamadı",
+			})
+		}
+		if errors.Is(err, application.ErrRecordingFinished) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "kayıt zaten tamamlandı",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"message": "kayıt iptal edildi",
+		},
+	})
+}
+
+// Delete deletes a recording and its archive
+func (h *RecordingHandler) Delete(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kayıt ID",
+		})
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		if errors.Is(err, application.ErrRecordingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "kayıt bulunamadı",
+			})
+		}
+		if errors.Is(err, application.ErrRecordingActive) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "kayıt devam ediyor, önce iptal edin",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"message": "kayıt silindi",
+		},
+	})
+}
+
+// Download sends the MP4 archive of a finished recording
+func (h *RecordingHandler) Download(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kayıt ID",
+		})
+	}
+
+	recording, path, err := h.service.Archive(id)
+	if err != nil {
+		if errors.Is(err, application.ErrRecordingNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "kayıt bulunamadı",
+			})
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "kayıt dosyası yok",
+		})
+	}
+
+	return c.Download(path, archiveFilename(recording.Name, recording.StartAt.Format("20060102-1504")))
+}
+
+// archiveFilename builds a download name from the recording name (path separators and quotes dropped)
+func archiveFilename(name, stamp string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', '"', ':', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "recording"
+	}
+	return fmt.Sprintf("%s_%s.mp4", name, stamp)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ScheduleHandler handles HTTP requests for channel schedules
+type ScheduleHandler struct {
+	service *application.ScheduleService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(service *application.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+// scheduleChannelID returns the channel of /channels/:id routes or the channel_id query (nil = all channels)
+func scheduleChannelID(c *fiber.Ctx) (*uuid.UUID, error) {
+	value := c.Params("id")
+	if value == "" {
+		value = c.Query("channel_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// List returns all schedules, or the schedules of one channel (/channels/:id/schedules or ?channel_id=)
+func (h *ScheduleHandler) List(c *fiber.Ctx) error {
+	channelID, err := scheduleChannelID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	schedules, err := h.service.List(channelID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": schedules,
+	})
+}
+
+// Upcoming returns the planned channel starts and stops
+// Query: hours (default 24, at most 744), channel_id
+func (h *ScheduleHandler) Upcoming(c *fiber.Ctx) error {
+	channelID, err := scheduleChannelID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+	hours := c.QueryInt("hours", 24)
+	if hours <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "hours pozitif olmalı",
+		})
+	}
+
+	actions, err := h.service.Upcoming(channelID, time.Duration(hours)*time.Hour)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": actions,
+	})
+}
+
+// Create adds a schedule to a channel
+func (h *ScheduleHandler) Create(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	var input application.ScheduleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz istek gövdesi",
+		})
+	}
+
+	schedule, err := h.service.Create(id, input)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": schedule,
+	})
+}
+
+// Get returns a schedule with its next planned action and last failure
+func (h *ScheduleHandler) Get(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz plan ID",
+		})
+	}
+
+	schedule, err := h.service.Get(id)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": schedule,
+	})
+}
+
+// Update replaces a schedule definition
+func (h *ScheduleHandler) Update(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz plan ID",
+		})
+	}
+
+	var input application.ScheduleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz istek gövdesi",
+		})
+	}
+
+	schedule, err := h.service.Update(id, input)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": schedule,
+	})
+}
+
+// Delete deletes a schedule
+func (h *ScheduleHandler) Delete(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz plan ID",
+		})
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"message": "plan silindi",
+		},
+	})
+}
+
+// scheduleError maps schedule service errors to responses
+func scheduleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrChannelNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, application.ErrScheduleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "plan bulunamadı",
+		})
+	case errors.Is(err, application.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
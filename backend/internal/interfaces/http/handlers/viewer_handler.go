@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/cashbacktv/backend/internal/domain"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ViewerHandler handles HTTP requests for channel viewers and stream information
+type ViewerHandler struct {
+	service *application.ViewerService
+	hlsPath string
+}
+
+// NewViewerHandler creates a new viewer handler
+func NewViewerHandler(service *application.ViewerService, hlsPath string) *ViewerHandler {
+	return &ViewerHandler{service: service, hlsPath: hlsPath}
+}
+
+// StreamInfo returns the playlist, segment and viewer information of a channel
+func (h *ViewerHandler) StreamInfo(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	viewers := h.service.GetViewers(id)
+	info := domain.StreamInfo{
+		ChannelID:   id,
+		PlaylistURL: "/streams/" + id.String() + "/index.m3u8",
+		Viewers:     viewers.Viewers,
+		PeakViewers: viewers.PeakViewers,
+		PeakAt:      viewers.PeakAt,
+	}
+	if h.hlsPath != "" {
+		if count, last, ok := hls.Stats(filepath.Join(h.hlsPath, id.String())); ok {
+			info.SegmentCount = count
+			info.LastSegmentTime = last
+		}
+	}
+	return c.JSON(info)
+}
+
+// AllViewers returns the current and peak viewers of every watched channel
+func (h *ViewerHandler) AllViewers(c *fiber.Ctx) error {
+	return c.JSON(h.service.GetAllViewers())
+}
+
+// History returns the viewer counts of a channel over time
+// Query: since/until (RFC3339, default: last 24 hours)
+func (h *ViewerHandler) History(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "geçersiz kanal ID",
+		})
+	}
+
+	var since, until *time.Time
+	for param, target := range map[string]**time.Time{"since": &since, "until": &until} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "geçersiz tarih (RFC3339 bekleniyor): " + param,
+				})
+			}
+			*target = &t
+		}
+	}
+
+	samples, err := h.service.History(id, since, until)
+	if err != nil {
+		if errors.Is(err, application.ErrChannelNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	peak := 0
+	for _, sample := range samples {
+		if sample.Viewers > peak {
+			peak = sample.Viewers
+		}
+	}
+	return c.JSON(fiber.Map{
+		"channel_id":   id,
+		"samples":      samples,
+		"peak_viewers": peak,
+	})
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/cashbacktv/backend/internal/infrastructure/dash"
+	"github.com/cashbacktv/backend/internal/infrastructure/hls"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PlaybackMiddleware validates signed playback tokens on /streams
+type PlaybackMiddleware struct {
+	service *application.PlaybackService
+}
+
+// NewPlaybackMiddleware creates a new playback middleware
+func NewPlaybackMiddleware(service *application.PlaybackService) *PlaybackMiddleware {
+	return &PlaybackMiddleware{service: service}
+}
+
+// Authorize checks the token query parameter of playlist and segment requests
+// Playlists and manifests served with a token are rewritten so every relative URI carries it,
+// players then fetch variants, segments and parts with the same token.
+func (m *PlaybackMiddleware) Authorize() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !m.service.Enabled() {
+			return c.Next()
+		}
+
+		token := c.Query("token")
+		if token == "" {
+			if m.service.Required() {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": application.ErrPlaybackTokenRequired.Error(),
+				})
+			}
+			return c.Next()
+		}
+
+		// Path: /streams/<channelId>/...
+		rest := strings.TrimPrefix(c.Path(), "/streams/")
+		channelID, err := uuid.Parse(strings.SplitN(rest, "/", 2)[0])
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": application.ErrPlaybackTokenScope.Error(),
+			})
+		}
+		if err := m.service.ValidateToken(token, channelID, c.IP()); err != nil {
+			status := fiber.StatusForbidden
+			if errors.Is(err, application.ErrPlaybackTokenExpired) {
+				status = fiber.StatusUnauthorized
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		query := "token=" + url.QueryEscape(token)
+		switch {
+		case strings.HasSuffix(c.Path(), ".m3u8"):
+			c.Response().SetBody(hls.AppendQuery(c.Response().Body(), query))
+		case strings.HasSuffix(c.Path(), ".mpd"):
+			c.Response().SetBody(dash.AppendQuery(c.Response().Body(), query))
+		}
+		return nil
+	}
+}
+
+// AuthorizeKey lets players fetch the content keys of a channel with its playback token
+// The token query parameter is validated against the channel of the key URI; requests without
+// a token (or with playback tokens disabled) go through authenticate instead.
+func (m *PlaybackMiddleware) AuthorizeKey(authenticate fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Query("token")
+		if token == "" || !m.service.Enabled() {
+			return authenticate(c)
+		}
+
+		channelID, err := uuid.Parse(c.Params("channelId"))
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": application.ErrPlaybackTokenScope.Error(),
+			})
+		}
+		if err := m.service.ValidateToken(token, channelID, c.IP()); err != nil {
+			status := fiber.StatusForbidden
+			if errors.Is(err, application.ErrPlaybackTokenExpired) {
+				status = fiber.StatusUnauthorized
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/cashbacktv/backend/internal/application"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ViewerMiddleware records playback sessions from /streams requests
+type ViewerMiddleware struct {
+	service *application.ViewerService
+}
+
+// NewViewerMiddleware creates a new viewer middleware
+func NewViewerMiddleware(service *application.ViewerService) *ViewerMiddleware {
+	return &ViewerMiddleware{service: service}
+}
+
+// Track counts successful playlist and segment requests towards the channel's viewers
+// A session is the combination of playback token, client IP and user agent.
+func (m *ViewerMiddleware) Track() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusOK {
+			return nil
+		}
+
+		// Path: /streams/<channelId>/...
+		rest := strings.TrimPrefix(c.Path(), "/streams/")
+		channelID, err := uuid.Parse(strings.SplitN(rest, "/", 2)[0])
+		if err != nil {
+			return nil
+		}
+		m.service.Touch(channelID, c.Query("token")+"|"+c.IP()+"|"+c.Get(fiber.HeaderUserAgent))
+		return nil
+	}
+}
@@ -23,7 +23,15 @@ type Router struct {
 	uploadHandler  *handlers.UploadHandler
 	settingsHandler *handlers.SettingsHandler
 	systemHandler  *handlers.SystemHandler
+	logHandler     *handlers.LogHandler
+	keyHandler     *handlers.KeyHandler
+	playbackHandler *handlers.PlaybackHandler
+	viewerHandler  *handlers.ViewerHandler
+	recordingHandler *handlers.RecordingHandler
+	scheduleHandler *handlers.ScheduleHandler
 	authMiddleware *middleware.AuthMiddleware
+	playbackMiddleware *middleware.PlaybackMiddleware
+	viewerMiddleware *middleware.ViewerMiddleware
 	logoPath       string
 	hlsPath        string
 }
@@ -34,7 +42,15 @@ func NewRouter(
 	channelHandler *handlers.ChannelHandler,
 	uploadHandler *handlers.UploadHandler,
 	settingsHandler *handlers.SettingsHandler,
+	logHandler *handlers.LogHandler,
+	keyHandler *handlers.KeyHandler,
+	playbackHandler *handlers.PlaybackHandler,
+	viewerHandler *handlers.ViewerHandler,
+	recordingHandler *handlers.RecordingHandler,
+	scheduleHandler *handlers.ScheduleHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	playbackMiddleware *middleware.PlaybackMiddleware,
+	viewerMiddleware *middleware.ViewerMiddleware,
 	logoPath string,
 	hlsPath string,
 	serverConfig *config.ServerConfig,
@@ -42,6 +58,12 @@ func NewRouter(
 	// Check if running in production (prefork mode for performance)
 	isProd := os.Getenv("ENV") == "production" || os.Getenv("ENVIRONMENT") == "production"
 	
+	// The client IP header is only honoured from the configured proxies, any client could set it
+	proxyHeader := serverConfig.ProxyHeader
+	if len(serverConfig.TrustedProxies) == 0 {
+		proxyHeader = ""
+	}
+
 	app := fiber.New(fiber.Config{
 		ErrorHandler:    customErrorHandler,
 		BodyLimit:       10 * 1024 * 1024, // 10MB for file uploads
@@ -53,6 +75,9 @@ func NewRouter(
 		Concurrency:     256 * 1024, // Maximum number of concurrent connections
 		Prefork:         false, // Disable prefork for now (can enable if needed)
 		ServerHeader:    "CashbackTV",
+		ProxyHeader:     proxyHeader, // Client IP behind the reverse proxy (playback token IP binding)
+		EnableTrustedProxyCheck: len(serverConfig.TrustedProxies) > 0,
+		TrustedProxies:          serverConfig.TrustedProxies,
 		AppName:         "CashbackTV API",
 	})
 
@@ -93,7 +118,15 @@ func NewRouter(
 		uploadHandler:  uploadHandler,
 		settingsHandler: settingsHandler,
 		systemHandler:  handlers.NewSystemHandler(),
+		logHandler:     logHandler,
+		keyHandler:     keyHandler,
+		playbackHandler: playbackHandler,
+		viewerHandler:  viewerHandler,
+		recordingHandler: recordingHandler,
+		scheduleHandler: scheduleHandler,
 		authMiddleware: authMiddleware,
+		playbackMiddleware: playbackMiddleware,
+		viewerMiddleware: viewerMiddleware,
 		logoPath:       logoPath,
 		hlsPath:        hlsPath,
 	}
@@ -104,9 +137,24 @@ func (r *Router) SetupRoutes() {
 	// Static file serving for logos
 	r.app.Static("/logos", r.logoPath)
 	
+	// Viewer sessions (counted from successful playlist and segment responses)
+	r.app.Use("/streams", r.viewerMiddleware.Track())
+
+	// Signed playback tokens (validated before any /streams handler, playlists rewritten to carry them)
+	r.app.Use("/streams", r.playbackMiddleware.Authorize())
+
 	// Custom stream handler for /streams/:channelId/index.m3u8
 	// This must come BEFORE static serving to intercept m3u8 requests
 	r.app.Get("/streams/:channelId/index.m3u8", r.channelHandler.ServeStream)
+
+	// DASH manifest of CMAF channels (rendered from the HLS playlists of the same segments)
+	r.app.Get("/streams/:channelId/manifest.mpd", r.channelHandler.ServeManifest)
+
+	// DVR playlist starting at an earlier point of the time-shift window (start or offset)
+	r.app.Get("/streams/:channelId/dvr.m3u8", r.channelHandler.ServeDVR)
+
+	// Low-Latency HLS variant playlists and assembled segments, trimmed DVR playlists (other files fall through)
+	r.app.Get("/streams/:channelId/*", r.channelHandler.ServeStreamFile)
 	
 	// Static file serving for HLS streams (segments, etc.)
 	// Note: This will handle all other /streams/* requests except /streams/:channelId/index.m3u8
@@ -127,6 +175,10 @@ func (r *Router) SetupRoutes() {
 	auth.Post("/logout", r.authHandler.Logout)
 	auth.Post("/refresh", r.authHandler.Refresh)
 
+	// HLS content keys of encrypted channels (EXT-X-KEY URI): the channel's playback token or
+	// any authenticated user; registered before the protected group so its JWT check does not apply
+	api.Get("/keys/:channelId/:keyId", r.playbackMiddleware.AuthorizeKey(r.authMiddleware.Authenticate()), r.keyHandler.Get)
+
 	// Protected routes
 	protected := api.Group("")
 	protected.Use(r.authMiddleware.Authenticate())
@@ -149,11 +201,20 @@ func (r *Router) SetupRoutes() {
 	
 	// Batch metrics endpoint (must come before /:id routes to avoid route conflicts)
 	channels.Get("/metrics", r.channelHandler.AllMetrics)
+	channels.Get("/viewers", r.viewerHandler.AllViewers)
 	
 	// Individual channel routes (must come after batch routes)
 	channels.Get("/:id", r.channelHandler.Get)
 	channels.Get("/:id/metrics", r.channelHandler.Metrics)
 	channels.Get("/:id/logs", r.channelHandler.Logs)
+	channels.Get("/:id/logs/history", r.logHandler.History)
+	channels.Get("/:id/command", r.channelHandler.Command)
+	channels.Post("/:id/playback-token", r.playbackHandler.IssueToken)
+	channels.Get("/:id/stream", r.viewerHandler.StreamInfo)
+	channels.Get("/:id/viewers/history", r.viewerHandler.History)
+	channels.Get("/:id/recordings", r.recordingHandler.List)
+	channels.Get("/:id/schedules", r.scheduleHandler.List)
+	channels.Get("/:id/schedules/upcoming", r.scheduleHandler.Upcoming)
 
 	// Operator+ only
 	channels.Post("/", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.channelHandler.Create)
@@ -161,10 +222,33 @@ func (r *Router) SetupRoutes() {
 	channels.Post("/:id/start", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.channelHandler.Start)
 	channels.Post("/:id/stop", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.channelHandler.Stop)
 	channels.Post("/:id/restart", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.channelHandler.Restart)
+	channels.Post("/:id/recordings", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.recordingHandler.Create)
+	channels.Post("/:id/schedules", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.scheduleHandler.Create)
 
 	// Admin only
 	channels.Delete("/:id", r.authMiddleware.RequireRole(domain.UserRoleAdmin), r.channelHandler.Delete)
 
+	// Recordings (MP4 archives of channel output)
+	recordings := protected.Group("/recordings")
+	recordings.Get("/", r.recordingHandler.List)
+	recordings.Get("/:id", r.recordingHandler.Get)
+	recordings.Get("/:id/download", r.recordingHandler.Download)
+
+	// Operator+ only
+	recordings.Post("/:id/cancel", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.recordingHandler.Cancel)
+	recordings.Delete("/:id", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.recordingHandler.Delete)
+
+	// Schedules (start/stop windows of channels)
+	// Upcoming must be defined BEFORE /:id to avoid route conflicts
+	schedules := protected.Group("/schedules")
+	schedules.Get("/", r.scheduleHandler.List)
+	schedules.Get("/upcoming", r.scheduleHandler.Upcoming)
+	schedules.Get("/:id", r.scheduleHandler.Get)
+
+	// Operator+ only
+	schedules.Put("/:id", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.scheduleHandler.Update)
+	schedules.Delete("/:id", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.scheduleHandler.Delete)
+
 	// Upload routes (Operator+ only)
 	uploads := protected.Group("/uploads")
 	uploads.Post("/logo", r.authMiddleware.RequireRole(domain.UserRoleOperator), r.uploadHandler.UploadLogo)
@@ -9,12 +9,16 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	FFmpeg   FFmpegConfig   `mapstructure:"ffmpeg"`
-	Storage  StorageConfig  `mapstructure:"storage"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	Redis      RedisConfig      `mapstructure:"redis"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	FFmpeg     FFmpegConfig     `mapstructure:"ffmpeg"`
+	Storage    StorageConfig    `mapstructure:"storage"`
+	Startup    StartupConfig    `mapstructure:"startup"`
+	Playback   PlaybackConfig   `mapstructure:"playback"`
+	Viewers    ViewersConfig    `mapstructure:"viewers"`
+	Recordings RecordingsConfig `mapstructure:"recordings"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -24,6 +28,10 @@ type ServerConfig struct {
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
 	IdleTimeout  int    `mapstructure:"idle_timeout"`
+	ProxyHeader  string `mapstructure:"proxy_header"` // Header holding the client IP set by the reverse proxy ("" = connection address)
+	// Addresses or CIDR ranges of the reverse proxy; ProxyHeader is only read from these peers
+	// and ignored entirely while the list is empty, so clients cannot spoof their IP
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -54,19 +62,54 @@ type JWTConfig struct {
 
 // FFmpegConfig holds FFmpeg configuration
 type FFmpegConfig struct {
-	BinaryPath     string `mapstructure:"binary_path"`
-	WorkerCount    int    `mapstructure:"worker_count"`
-	SegmentTime    int    `mapstructure:"segment_time"`
-	PlaylistSize   int    `mapstructure:"playlist_size"`
-	DefaultPreset  string `mapstructure:"default_preset"`
-	DefaultBitrate string `mapstructure:"default_bitrate"`
+	BinaryPath        string `mapstructure:"binary_path"`
+	WorkerCount       int    `mapstructure:"worker_count"`
+	SegmentTime       int    `mapstructure:"segment_time"`
+	PlaylistSize      int    `mapstructure:"playlist_size"`
+	DefaultPreset     string `mapstructure:"default_preset"`
+	DefaultBitrate    string `mapstructure:"default_bitrate"`
+	EncoderBackend    string `mapstructure:"encoder_backend"`
+	StallTimeout      int    `mapstructure:"stall_timeout"`        // Seconds without new frames or segments before a process is restarted (0 = disabled)
+	MaxSessionsPerGPU int    `mapstructure:"max_sessions_per_gpu"` // Encoder sessions allowed per GPU (0 = unlimited)
+	MinFreeMemoryMB   int    `mapstructure:"min_free_memory_mb"`   // Available memory required to start a channel (0 = not checked)
 }
 
 // StorageConfig holds storage paths configuration
 type StorageConfig struct {
-	HLSPath    string `mapstructure:"hls_path"`
-	LogoPath   string `mapstructure:"logo_path"`
-	UploadPath string `mapstructure:"upload_path"`
+	HLSPath      string `mapstructure:"hls_path"`
+	LogoPath     string `mapstructure:"logo_path"`
+	UploadPath   string `mapstructure:"upload_path"`
+	RunPath      string `mapstructure:"run_path"`        // FFmpeg pidfiles, kept across backend restarts
+	KeyPath      string `mapstructure:"key_path"`        // Key files of encrypted channels (never under hls_path)
+	ArchivePath  string `mapstructure:"archive_path"`    // MP4 recordings, on persistent storage (never under hls_path)
+	DVRMaxDiskMB int    `mapstructure:"dvr_max_disk_mb"` // Space shared by all DVR windows under hls_path, counted against the tmpfs (0 = unlimited, not for a tmpfs)
+}
+
+// StartupConfig holds channel reconciliation settings applied on boot
+type StartupConfig struct {
+	Mode           string `mapstructure:"mode"`            // stop (all channels stay off) or resume (restart desired running channels)
+	StaggerSeconds int    `mapstructure:"stagger_seconds"` // Delay between resumed channel starts
+	AdoptProcesses bool   `mapstructure:"adopt_processes"` // Keep FFmpeg processes of the previous instance running instead of killing them
+}
+
+// PlaybackConfig holds signed playback URL settings for /streams
+type PlaybackConfig struct {
+	TokenSecret  string `mapstructure:"token_secret"`  // HMAC secret of playback tokens ("" = tokens disabled)
+	RequireToken bool   `mapstructure:"require_token"` // Refuse /streams requests without a valid token
+	TokenTTL     int    `mapstructure:"token_ttl"`     // Default token lifetime in seconds
+	MaxTokenTTL  int    `mapstructure:"max_token_ttl"` // Longest lifetime a token may be issued for
+}
+
+// ViewersConfig holds viewer tracking settings
+type ViewersConfig struct {
+	IdleTimeout    int `mapstructure:"idle_timeout"`    // Seconds without playlist or segment requests before a session ends
+	SampleInterval int `mapstructure:"sample_interval"` // Seconds between viewer history samples
+	RetentionDays  int `mapstructure:"retention_days"`  // Days of viewer history kept (0 = keep forever)
+}
+
+// RecordingsConfig holds channel recording settings
+type RecordingsConfig struct {
+	RetentionDays int `mapstructure:"retention_days"` // Days finished recordings and their archives are kept (0 = keep forever)
 }
 
 // Load reads configuration from file and environment
@@ -106,6 +149,8 @@ func setDefaults() {
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
 	viper.SetDefault("server.idle_timeout", 60)
+	viper.SetDefault("server.proxy_header", "")
+	viper.SetDefault("server.trusted_proxies", []string{})
 
 	// Database defaults
 	viper.SetDefault("database.host", "localhost")
@@ -134,11 +179,38 @@ func setDefaults() {
 	viper.SetDefault("ffmpeg.playlist_size", 10)
 	viper.SetDefault("ffmpeg.default_preset", "ultrafast")
 	viper.SetDefault("ffmpeg.default_bitrate", "5000k")
+	viper.SetDefault("ffmpeg.encoder_backend", "auto")
+	viper.SetDefault("ffmpeg.stall_timeout", 30)
+	viper.SetDefault("ffmpeg.max_sessions_per_gpu", 0)
+	viper.SetDefault("ffmpeg.min_free_memory_mb", 512)
 
 	// Storage defaults
 	viper.SetDefault("storage.hls_path", "/var/lib/cashbacktv/streams")
 	viper.SetDefault("storage.logo_path", "/var/lib/cashbacktv/logos")
 	viper.SetDefault("storage.upload_path", "/var/lib/cashbacktv/uploads")
+	viper.SetDefault("storage.run_path", "/var/lib/cashbacktv/run")
+	viper.SetDefault("storage.key_path", "/var/lib/cashbacktv/keys")
+	viper.SetDefault("storage.archive_path", "/var/lib/cashbacktv/archive")
+	viper.SetDefault("storage.dvr_max_disk_mb", 2048)
+
+	// Startup defaults
+	viper.SetDefault("startup.mode", "stop")
+	viper.SetDefault("startup.stagger_seconds", 2)
+	viper.SetDefault("startup.adopt_processes", false)
+
+	// Playback token defaults
+	viper.SetDefault("playback.token_secret", "")
+	viper.SetDefault("playback.require_token", false)
+	viper.SetDefault("playback.token_ttl", 3600)
+	viper.SetDefault("playback.max_token_ttl", 86400)
+
+	// Viewer tracking defaults
+	viper.SetDefault("viewers.idle_timeout", 30)
+	viper.SetDefault("viewers.sample_interval", 60)
+	viper.SetDefault("viewers.retention_days", 30)
+
+	// Recording defaults
+	viper.SetDefault("recordings.retention_days", 30)
 }
 
 // DSN returns PostgreSQL connection string
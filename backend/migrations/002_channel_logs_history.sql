@@ -0,0 +1,5 @@
+-- CashbackTV Database Schema
+-- Channel log history
+
+-- Channel log history is queried per channel, newest first
+CREATE INDEX IF NOT EXISTS idx_channel_logs_channel_created ON channel_logs(channel_id, created_at DESC);
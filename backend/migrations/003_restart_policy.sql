@@ -0,0 +1,5 @@
+-- CashbackTV Database Schema
+-- Per-channel restart policy
+
+-- Restart policy stored next to auto_restart (NULL uses the default policy)
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS restart_policy JSONB;
@@ -0,0 +1,5 @@
+-- CashbackTV Database Schema
+-- Backup sources for input failover
+
+-- Ordered backup sources tried after source_url
+ALTER TABLE channels ADD COLUMN IF NOT EXISTS backup_sources JSONB;
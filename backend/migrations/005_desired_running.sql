@@ -0,0 +1,15 @@
+-- CashbackTV Database Schema
+-- Desired running state for resuming channels after a restart
+
+-- Channels that were on air when the column is added are marked as desired running
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'channels' AND column_name = 'desired_running'
+    ) THEN
+        ALTER TABLE channels ADD COLUMN desired_running BOOLEAN NOT NULL DEFAULT false;
+        UPDATE channels SET desired_running = true WHERE status IN ('running', 'starting');
+    END IF;
+END
+$$;
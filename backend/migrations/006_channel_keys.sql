@@ -0,0 +1,12 @@
+-- CashbackTV Database Schema
+-- Content keys of encrypted channels
+
+-- AES-128 keys, rotated every N segments and served by the key endpoint
+CREATE TABLE IF NOT EXISTS channel_keys (
+    id UUID PRIMARY KEY,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    key BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_keys_channel_created ON channel_keys(channel_id, created_at DESC);
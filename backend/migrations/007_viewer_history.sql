@@ -0,0 +1,13 @@
+-- CashbackTV Database Schema
+-- Concurrent viewer history
+
+-- Viewer counts sampled per channel while it has viewers
+CREATE TABLE IF NOT EXISTS channel_viewer_history (
+    id BIGSERIAL PRIMARY KEY,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    viewers INTEGER NOT NULL,
+    sampled_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_viewer_history_channel_sampled ON channel_viewer_history(channel_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_channel_viewer_history_sampled ON channel_viewer_history(sampled_at);
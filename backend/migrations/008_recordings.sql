@@ -0,0 +1,21 @@
+-- CashbackTV Database Schema
+-- Recording jobs
+
+-- Scheduled and finished recordings of channel output into MP4 archives
+CREATE TABLE IF NOT EXISTS recordings (
+    id UUID PRIMARY KEY,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration INTEGER NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    file_path TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recordings_channel_start ON recordings(channel_id, start_at DESC);
+CREATE INDEX IF NOT EXISTS idx_recordings_status_start ON recordings(status, start_at);
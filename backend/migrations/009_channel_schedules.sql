@@ -0,0 +1,28 @@
+-- CashbackTV Database Schema
+-- Channel schedules
+
+-- One-off and recurring start/stop windows of channels, with the scheduler's persisted plan
+CREATE TABLE IF NOT EXISTS channel_schedules (
+    id UUID PRIMARY KEY,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    start_at TIMESTAMP WITH TIME ZONE,
+    end_at TIMESTAMP WITH TIME ZONE,
+    cron VARCHAR(255) NOT NULL DEFAULT '',
+    duration INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    next_action VARCHAR(10) NOT NULL DEFAULT '',
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    window_end TIMESTAMP WITH TIME ZONE,
+    last_action VARCHAR(10) NOT NULL DEFAULT '',
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    last_error_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_schedules_channel ON channel_schedules(channel_id);
+CREATE INDEX IF NOT EXISTS idx_channel_schedules_next_run ON channel_schedules(next_run_at) WHERE enabled;
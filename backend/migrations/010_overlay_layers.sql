@@ -0,0 +1,17 @@
+-- CashbackTV Database Schema
+-- Overlay layers
+
+-- Ordered image, text and clock layers drawn over the video
+-- The single logo of existing channels becomes their first image layer, the logo column is no longer used
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'channels' AND column_name = 'overlays'
+    ) THEN
+        ALTER TABLE channels ADD COLUMN overlays JSONB;
+        UPDATE channels SET overlays = jsonb_build_array(logo || '{"type": "image"}'::jsonb)
+        WHERE jsonb_typeof(logo) = 'object' AND COALESCE(logo->>'path', '') <> '';
+    END IF;
+END
+$$;
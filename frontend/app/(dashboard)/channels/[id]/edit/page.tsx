@@ -24,7 +24,7 @@ import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Card, CardContent, CardHeader, CardTitle, CardDescription } from "@/components/ui/card";
-import { api, Channel, LogoConfig } from "@/lib/api";
+import { api, Channel, OverlayLayer } from "@/lib/api";
 import { useToast } from "@/hooks/use-toast";
 import { cn } from "@/lib/utils";
 
@@ -46,6 +46,21 @@ const getFullUrl = (path: string) => {
   return path;
 };
 
+// Replaces the first image layer with the edited logo (removed when undefined), keeping the other layers
+const withLogoLayer = (layers: OverlayLayer[], logo: OverlayLayer | undefined): OverlayLayer[] => {
+  const index = layers.findIndex((layer) => layer.type === "image" && layer.path);
+  if (index < 0) {
+    return logo ? [...layers, logo] : layers;
+  }
+  const updated = [...layers];
+  if (logo) {
+    updated[index] = { ...layers[index], ...logo };
+  } else {
+    updated.splice(index, 1);
+  }
+  return updated;
+};
+
 export default function ChannelEditPage() {
   const params = useParams();
   const router = useRouter();
@@ -118,16 +133,18 @@ export default function ChannelEditPage() {
           }
         }
 
-        if (ch.logo) {
-          setLogoPath(ch.logo.path);
+        // The first image layer is edited as the logo, other layers are kept as they are
+        const logo = ch.overlays?.find((layer) => layer.type === "image" && layer.path);
+        if (logo && logo.path) {
+          setLogoPath(logo.path);
           // Use relative path for logo, handled by next.config.js rewrite
-          const logoRelPath = ch.logo.path.startsWith("/") ? ch.logo.path : `/logos/${ch.logo.path}`;
+          const logoRelPath = logo.path.startsWith("/") ? logo.path : `/logos/${logo.path}`;
           setLogoUrl(logoRelPath);
-          setLogoX(ch.logo.x);
-          setLogoY(ch.logo.y);
-          setLogoWidth(ch.logo.width);
-          setLogoHeight(ch.logo.height);
-          setLogoOpacity(ch.logo.opacity);
+          setLogoX(logo.x);
+          setLogoY(logo.y);
+          setLogoWidth(logo.width ?? 200);
+          setLogoHeight(logo.height ?? 100);
+          setLogoOpacity(logo.opacity || 1.0);
         }
       }
       setLoading(false);
@@ -693,13 +710,19 @@ ${tsUrl}
       await new Promise(resolve => setTimeout(resolve, 1000));
     }
 
-    const logoConfig: LogoConfig | undefined = logoPath
+    const logoLayer: OverlayLayer | undefined = logoPath
       ? {
+          type: "image",
           path: logoPath,
+          // The editor places the logo in pixels, replacing any anchored placement
+          anchor: "",
           x: logoX,
           y: logoY,
           width: logoWidth,
           height: logoHeight,
+          width_percent: 0,
+          height_percent: 0,
+          auto_height: false,
           opacity: logoOpacity,
         }
       : undefined;
@@ -707,13 +730,15 @@ ${tsUrl}
     const result = await api.updateChannel(channelId, {
       name,
       source_url: sourceUrl,
-      logo: logoConfig,
+      overlays: withLogoLayer(channel?.overlays ?? [], logoLayer),
+      // Keep the settings this page does not edit (codec, ladder, audio, encryption, DVR...)
       output_config: {
-        codec: "libx264",
+        ...channel?.output_config,
+        codec: channel?.output_config?.codec || "libx264",
         bitrate,
         resolution: `${videoWidth}x${videoHeight}`,
         preset,
-        profile: "high",
+        profile: channel?.output_config?.profile || "high",
       },
     });
 
@@ -998,8 +1023,9 @@ ${tsUrl}
                             await api.updateChannel(channelId, {
                               name: channel.name,
                               source_url: channel.source_url,
-                              logo: channel.logo,
+                              overlays: channel.overlays,
                               output_config: {
+                                ...channel.output_config,
                                 codec: channel.output_config?.codec || "libx264",
                                 bitrate: channel.output_config?.bitrate || "4000k",
                                 resolution: newResolution,
@@ -233,13 +233,36 @@ export interface User {
   role: "admin" | "operator" | "viewer";
 }
 
-export interface LogoConfig {
-  path: string;
+export interface OverlayLayer {
+  type: "image" | "text" | "clock";
+  name?: string;
+  disabled?: boolean;
+  // Anchored layers are placed with margins in percent of the frame ("" = pixel x/y)
+  anchor?: "" | "top-left" | "top-right" | "bottom-left" | "bottom-right" | "center";
+  margin_x?: number;
+  margin_y?: number;
   x: number;
   y: number;
-  width: number;
-  height: number;
-  opacity: number;
+  opacity?: number;
+  z_index?: number;
+  // Image layers
+  path?: string;
+  width?: number;
+  height?: number;
+  width_percent?: number;
+  height_percent?: number;
+  auto_height?: boolean;
+  // Text and clock layers
+  text?: string;
+  format?: string;
+  timezone?: string;
+  font?: string;
+  font_size?: number;
+  font_size_percent?: number;
+  font_color?: string;
+  box?: boolean;
+  box_color?: string;
+  box_border?: number;
 }
 
 export interface OutputConfig {
@@ -248,6 +271,9 @@ export interface OutputConfig {
   resolution: string;
   preset: string;
   profile: string;
+  // Ladder, audio, captions, container, push, encryption, DVR, slate... are kept as received,
+  // updates replace the whole output_config so edits must carry them along
+  [key: string]: unknown;
 }
 
 export interface Channel {
@@ -255,7 +281,7 @@ export interface Channel {
   name: string;
   source_url: string;
   output_url?: string;
-  logo?: LogoConfig;
+  overlays?: OverlayLayer[];
   output_config?: OutputConfig;
   status: "stopped" | "starting" | "running" | "error" | "stopping";
   auto_restart: boolean;
@@ -280,14 +306,14 @@ export interface ProcessMetrics {
 export interface CreateChannelRequest {
   name: string;
   source_url: string;
-  logo?: LogoConfig;
+  overlays?: OverlayLayer[];
   output_config?: OutputConfig;
 }
 
 export interface UpdateChannelRequest {
   name?: string;
   source_url?: string;
-  logo?: LogoConfig;
+  overlays?: OverlayLayer[];
   output_config?: OutputConfig;
 }
 